@@ -0,0 +1,19 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultBookingHold is how long a pending booking holds its seats
+const DefaultBookingHold = 15 * time.Minute
+
+// BookingHold reads BOOKING_HOLD_MINUTES, falling back to DefaultBookingHold
+func BookingHold() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("BOOKING_HOLD_MINUTES"))
+	if err != nil || minutes <= 0 {
+		return DefaultBookingHold
+	}
+	return time.Duration(minutes) * time.Minute
+}
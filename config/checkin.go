@@ -0,0 +1,19 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultCheckinWindow is how long before the show time the gate opens
+const DefaultCheckinWindow = 60 * time.Minute
+
+// CheckinWindow reads CHECKIN_WINDOW_MINUTES, falling back to DefaultCheckinWindow
+func CheckinWindow() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("CHECKIN_WINDOW_MINUTES"))
+	if err != nil || minutes <= 0 {
+		return DefaultCheckinWindow
+	}
+	return time.Duration(minutes) * time.Minute
+}
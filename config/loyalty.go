@@ -0,0 +1,84 @@
+package config
+
+import (
+	"cinema-ticket-api/models"
+	"os"
+	"strconv"
+	"time"
+)
+
+// LoyaltyRates decides how customers earn and spend loyalty points
+type LoyaltyRates struct {
+	EarnPer    models.Money // amount paid for each point earned
+	PointValue models.Money // discount one point is worth when redeemed
+	ExpiryDays int          // days earned points stay valid
+}
+
+// DefaultLoyaltyRates are used when no rates are configured: a point per
+// Rp 10.000 paid, worth Rp 100 and valid for a year
+var DefaultLoyaltyRates = LoyaltyRates{
+	EarnPer:    models.NewMoney(1000000),
+	PointValue: models.NewMoney(10000),
+	ExpiryDays: 365,
+}
+
+// DefaultLoyaltyExpiryInterval is how often expired points are written off
+const DefaultLoyaltyExpiryInterval = time.Hour
+
+// LoadLoyaltyRates reads LOYALTY_EARN_PER, LOYALTY_POINT_VALUE and
+// LOYALTY_EXPIRY_DAYS, falling back to DefaultLoyaltyRates for anything
+// missing or malformed
+func LoadLoyaltyRates() LoyaltyRates {
+	rates := DefaultLoyaltyRates
+
+	if earnPer, err := models.ParseMoney(os.Getenv("LOYALTY_EARN_PER")); err == nil && earnPer.Amount > 0 {
+		rates.EarnPer = earnPer
+	}
+
+	if value, err := models.ParseMoney(os.Getenv("LOYALTY_POINT_VALUE")); err == nil && value.Amount > 0 {
+		rates.PointValue = value
+	}
+
+	if days, err := strconv.Atoi(os.Getenv("LOYALTY_EXPIRY_DAYS")); err == nil && days > 0 {
+		rates.ExpiryDays = days
+	}
+
+	return rates
+}
+
+// LoyaltyExpiryInterval reads LOYALTY_EXPIRY_INTERVAL_MINUTES, falling back to
+// DefaultLoyaltyExpiryInterval
+func LoyaltyExpiryInterval() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("LOYALTY_EXPIRY_INTERVAL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		return DefaultLoyaltyExpiryInterval
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// PointsEarned returns the points a booking of paid earns. Partial units of
+// EarnPer earn nothing.
+func (r LoyaltyRates) PointsEarned(paid models.Money) int {
+	if r.EarnPer.Amount <= 0 || paid.Amount <= 0 {
+		return 0
+	}
+	return int(paid.Amount / r.EarnPer.Amount)
+}
+
+// PointsValue returns the discount points are worth
+func (r LoyaltyRates) PointsValue(points int) models.Money {
+	return r.PointValue.Mul(points)
+}
+
+// RedeemablePoints returns how many of the requested points can be spent on
+// a booking whose discountable subtotal is subtotal, so the discount never
+// exceeds it
+func (r LoyaltyRates) RedeemablePoints(requested int, subtotal models.Money) int {
+	if r.PointValue.Amount <= 0 || subtotal.Amount <= 0 || requested <= 0 {
+		return 0
+	}
+	if limit := int(subtotal.Amount / r.PointValue.Amount); requested > limit {
+		return limit
+	}
+	return requested
+}
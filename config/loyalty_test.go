@@ -0,0 +1,42 @@
+package config
+
+import (
+	"cinema-ticket-api/models"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoyaltyRates_PointsEarned(t *testing.T) {
+	rates := DefaultLoyaltyRates
+
+	assert.Equal(t, 8, rates.PointsEarned(models.NewMoney(8968800)))
+	assert.Equal(t, 1, rates.PointsEarned(models.NewMoney(1000000)))
+	assert.Equal(t, 0, rates.PointsEarned(models.NewMoney(999999)))
+	assert.Equal(t, 0, rates.PointsEarned(models.NewMoney(-1000000)))
+}
+
+func TestLoyaltyRates_RedeemablePoints(t *testing.T) {
+	rates := DefaultLoyaltyRates
+
+	assert.Equal(t, 50, rates.RedeemablePoints(50, models.NewMoney(9100000)))
+	assert.Equal(t, 910, rates.RedeemablePoints(1000, models.NewMoney(9100000)))
+	assert.Equal(t, 0, rates.RedeemablePoints(10, models.NewMoney(9999)))
+	assert.Equal(t, models.NewMoney(500000), rates.PointsValue(50))
+}
+
+func TestLoadLoyaltyRates(t *testing.T) {
+	os.Setenv("LOYALTY_EARN_PER", "5000")
+	os.Setenv("LOYALTY_POINT_VALUE", "abc")
+	os.Setenv("LOYALTY_EXPIRY_DAYS", "90")
+	defer os.Unsetenv("LOYALTY_EARN_PER")
+	defer os.Unsetenv("LOYALTY_POINT_VALUE")
+	defer os.Unsetenv("LOYALTY_EXPIRY_DAYS")
+
+	rates := LoadLoyaltyRates()
+
+	assert.Equal(t, models.NewMoney(500000), rates.EarnPer)
+	assert.Equal(t, DefaultLoyaltyRates.PointValue, rates.PointValue)
+	assert.Equal(t, 90, rates.ExpiryDays)
+}
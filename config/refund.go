@@ -0,0 +1,110 @@
+package config
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RefundTier grants Percentage of the booking total when the refund is
+// requested at least MinHours before the show time
+type RefundTier struct {
+	MinHours   float64
+	Percentage int
+}
+
+// RefundPolicy decides how much of a booking is refunded
+type RefundPolicy struct {
+	CutoffHours float64
+	Tiers       []RefundTier
+}
+
+// DefaultRefundPolicy is used when no policy is configured
+var DefaultRefundPolicy = RefundPolicy{
+	CutoffHours: 2,
+	Tiers: []RefundTier{
+		{MinHours: 48, Percentage: 100},
+		{MinHours: 24, Percentage: 75},
+		{MinHours: 2, Percentage: 50},
+	},
+}
+
+// DefaultRefundRetryInterval is how often refunds the payment gateway has not
+// confirmed are sent again
+const DefaultRefundRetryInterval = 15 * time.Minute
+
+// LoadRefundPolicy reads the refund policy from REFUND_CUTOFF_HOURS and
+// REFUND_TIERS (comma separated "hours:percentage" pairs), falling back to
+// DefaultRefundPolicy for anything missing or malformed
+func LoadRefundPolicy() RefundPolicy {
+	policy := DefaultRefundPolicy
+
+	if cutoff, err := strconv.ParseFloat(os.Getenv("REFUND_CUTOFF_HOURS"), 64); err == nil && cutoff >= 0 {
+		policy.CutoffHours = cutoff
+	}
+
+	if tiers, ok := parseRefundTiers(os.Getenv("REFUND_TIERS")); ok {
+		policy.Tiers = tiers
+	}
+
+	return policy
+}
+
+// RefundRetryInterval reads REFUND_RETRY_INTERVAL_MINUTES, falling back to
+// DefaultRefundRetryInterval
+func RefundRetryInterval() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("REFUND_RETRY_INTERVAL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		return DefaultRefundRetryInterval
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func parseRefundTiers(value string) ([]RefundTier, bool) {
+	if value == "" {
+		return nil, false
+	}
+
+	var tiers []RefundTier
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			return nil, false
+		}
+
+		hours, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil || hours < 0 {
+			return nil, false
+		}
+
+		percentage, err := strconv.Atoi(parts[1])
+		if err != nil || percentage < 0 || percentage > 100 {
+			return nil, false
+		}
+
+		tiers = append(tiers, RefundTier{MinHours: hours, Percentage: percentage})
+	}
+
+	return tiers, true
+}
+
+// Percentage returns the refundable percentage for a request made
+// hoursBeforeShow hours before the show time, or 0 past the cut-off
+func (p RefundPolicy) Percentage(hoursBeforeShow float64) int {
+	if hoursBeforeShow < p.CutoffHours {
+		return 0
+	}
+
+	tiers := append([]RefundTier(nil), p.Tiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinHours > tiers[j].MinHours })
+
+	for _, tier := range tiers {
+		if hoursBeforeShow >= tier.MinHours {
+			return tier.Percentage
+		}
+	}
+
+	return 0
+}
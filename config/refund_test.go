@@ -0,0 +1,41 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefundPolicy_Percentage(t *testing.T) {
+	policy := DefaultRefundPolicy
+
+	assert.Equal(t, 100, policy.Percentage(72))
+	assert.Equal(t, 100, policy.Percentage(48))
+	assert.Equal(t, 75, policy.Percentage(30))
+	assert.Equal(t, 50, policy.Percentage(3))
+	assert.Equal(t, 0, policy.Percentage(1))
+	assert.Equal(t, 0, policy.Percentage(-1))
+}
+
+func TestLoadRefundPolicy(t *testing.T) {
+	os.Setenv("REFUND_CUTOFF_HOURS", "6")
+	os.Setenv("REFUND_TIERS", "12:50,72:100")
+	defer os.Unsetenv("REFUND_CUTOFF_HOURS")
+	defer os.Unsetenv("REFUND_TIERS")
+
+	policy := LoadRefundPolicy()
+
+	assert.Equal(t, 6.0, policy.CutoffHours)
+	assert.Equal(t, 100, policy.Percentage(80))
+	assert.Equal(t, 50, policy.Percentage(24))
+	assert.Equal(t, 0, policy.Percentage(8))
+	assert.Equal(t, 0, policy.Percentage(5))
+}
+
+func TestLoadRefundPolicy_Malformed(t *testing.T) {
+	os.Setenv("REFUND_TIERS", "48:150")
+	defer os.Unsetenv("REFUND_TIERS")
+
+	assert.Equal(t, DefaultRefundPolicy.Tiers, LoadRefundPolicy().Tiers)
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"cinema-ticket-api/models"
+	"log"
+	"os"
+)
+
+// DefaultPPNRate is the Indonesian VAT rate in percent
+const DefaultPPNRate = "11"
+
+// PPNRate reads PPN_RATE, the VAT percentage charged by theaters without
+// their own rate, falling back to DefaultPPNRate
+func PPNRate() models.Decimal {
+	value := os.Getenv("PPN_RATE")
+	if value == "" {
+		value = DefaultPPNRate
+	}
+
+	rate, err := models.ParseDecimal(value)
+	if err != nil || rate < 0 {
+		log.Printf("Invalid PPN_RATE %q, using %s", value, DefaultPPNRate)
+		rate, _ = models.ParseDecimal(DefaultPPNRate)
+	}
+	return rate
+}
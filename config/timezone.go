@@ -0,0 +1,52 @@
+package config
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	_ "time/tzdata" // The production image has no system zoneinfo
+)
+
+// DefaultTimezone is used when APP_TIMEZONE is not set
+const DefaultTimezone = "Asia/Jakarta"
+
+// Location returns the app-wide time zone read from APP_TIMEZONE. Show times
+// are displayed in their theater's zone, see TheaterLocation; this is only the
+// fallback for a theater without one and the zone of dates not tied to a theater.
+func Location() *time.Location {
+	name := os.Getenv("APP_TIMEZONE")
+	if name == "" {
+		name = DefaultTimezone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Unknown APP_TIMEZONE %q, using %s", name, DefaultTimezone)
+		loc, _ = time.LoadLocation(DefaultTimezone)
+	}
+	return loc
+}
+
+var theaterLocations sync.Map
+
+// TheaterLocation returns the time zone of a theater from its IANA name such
+// as Asia/Makassar, falling back to Location when the name is empty or
+// unknown. Zones are loaded once and cached.
+func TheaterLocation(name string) *time.Location {
+	if name == "" {
+		return Location()
+	}
+	if loc, ok := theaterLocations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Unknown theater timezone %q, using APP_TIMEZONE", name)
+		return Location()
+	}
+	theaterLocations.Store(name, loc)
+	return loc
+}
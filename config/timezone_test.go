@@ -0,0 +1,13 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTheaterLocation(t *testing.T) {
+	assert.Equal(t, "Asia/Makassar", TheaterLocation("Asia/Makassar").String())
+	assert.Equal(t, Location().String(), TheaterLocation("").String())
+	assert.Equal(t, Location().String(), TheaterLocation("Mars/Olympus_Mons").String())
+}
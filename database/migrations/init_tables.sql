@@ -7,10 +7,14 @@ CREATE TABLE IF NOT EXISTS users (
     phone_number VARCHAR(20),
     date_of_birth DATE,
     email_verified BOOLEAN DEFAULT FALSE,
+    calendar_token_version INTEGER NOT NULL DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Columns added after the first release, for databases created before them
+ALTER TABLE users ADD COLUMN IF NOT EXISTS calendar_token_version INTEGER NOT NULL DEFAULT 0;
+
 CREATE TABLE IF NOT EXISTS movies (
     id SERIAL PRIMARY KEY,
     title VARCHAR(200) NOT NULL,
@@ -26,10 +30,41 @@ CREATE TABLE IF NOT EXISTS movies (
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Movie search: full text over title, cast, crew, genre and description with
+-- accents stripped, plus trigram matching on titles to tolerate typos
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent() is only STABLE because its dictionary could change, which keeps
+-- it out of indexes. The dictionary is pinned here so it can be IMMUTABLE.
+CREATE OR REPLACE FUNCTION immutable_unaccent(TEXT) RETURNS TEXT AS $$
+    SELECT public.unaccent('public.unaccent'::regdictionary, $1)
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+CREATE OR REPLACE FUNCTION movie_search_document(
+    m_title TEXT, m_description TEXT, m_director TEXT, m_cast TEXT[], m_genre VARCHAR[]
+) RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('simple', immutable_unaccent(COALESCE(m_title, ''))), 'A') ||
+           setweight(to_tsvector('simple', immutable_unaccent(
+               COALESCE(m_director, '') || ' ' || COALESCE(array_to_string(m_cast, ' '), ''))), 'B') ||
+           setweight(to_tsvector('simple', immutable_unaccent(COALESCE(array_to_string(m_genre, ' '), ''))), 'B') ||
+           setweight(to_tsvector('simple', immutable_unaccent(COALESCE(m_description, ''))), 'C')
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE;
+
+CREATE INDEX IF NOT EXISTS idx_movies_search
+    ON movies USING GIN (movie_search_document(title, description, director, "cast", genre));
+CREATE INDEX IF NOT EXISTS idx_movies_title_trgm
+    ON movies USING GIN (immutable_unaccent(LOWER(title)) gin_trgm_ops);
+
 CREATE TABLE IF NOT EXISTS theaters (
     id SERIAL PRIMARY KEY,
     name VARCHAR(200) NOT NULL,
     address TEXT NOT NULL,
+    city VARCHAR(100),
+    latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+    -- IANA zone show times are local to: Asia/Jakarta (WIB), Asia/Makassar (WITA) or Asia/Jayapura (WIT)
+    timezone VARCHAR(50) NOT NULL DEFAULT 'Asia/Jakarta',
     total_halls INTEGER NOT NULL DEFAULT 1,
     contact_phone VARCHAR(20),
     contact_email VARCHAR(100),
@@ -37,6 +72,13 @@ CREATE TABLE IF NOT EXISTS theaters (
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Columns added after the first release, for databases created before them
+ALTER TABLE theaters
+    ADD COLUMN IF NOT EXISTS city VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+    ADD COLUMN IF NOT EXISTS timezone VARCHAR(50) NOT NULL DEFAULT 'Asia/Jakarta';
+
 CREATE TABLE IF NOT EXISTS halls (
     id SERIAL PRIMARY KEY,
     theater_id INTEGER NOT NULL REFERENCES theaters(id) ON DELETE CASCADE,
@@ -48,43 +90,469 @@ CREATE TABLE IF NOT EXISTS halls (
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Recurring screenings: every show time on every weekday (0 = Sunday) of the
+-- date range. Show times are local to the theater.
+CREATE TABLE IF NOT EXISTS screening_schedules (
+    id SERIAL PRIMARY KEY,
+    movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    theater_id INTEGER NOT NULL REFERENCES theaters(id) ON DELETE CASCADE,
+    hall_id INTEGER NOT NULL REFERENCES halls(id) ON DELETE CASCADE,
+    days_of_week SMALLINT[] NOT NULL,
+    show_times VARCHAR(5)[] NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL CHECK (end_date >= start_date),
+    price DECIMAL(10,2) NOT NULL,
+    price_3d DECIMAL(10,2),
+    is_3d BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
 CREATE TABLE IF NOT EXISTS screenings (
     id SERIAL PRIMARY KEY,
     movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
     theater_id INTEGER NOT NULL REFERENCES theaters(id) ON DELETE CASCADE,
     hall_id INTEGER NOT NULL REFERENCES halls(id) ON DELETE CASCADE,
-    show_time TIMESTAMP NOT NULL,
-    end_time TIMESTAMP NOT NULL,
+    show_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
     price DECIMAL(10,2) NOT NULL,
     price_3d DECIMAL(10,2),
     available_seats INTEGER NOT NULL,
     is_3d BOOLEAN DEFAULT FALSE,
     is_available BOOLEAN DEFAULT TRUE,
+    cancellation_reason TEXT,
+    cancelled_at TIMESTAMP,
+    -- Occurrence of a schedule, left alone by series edits once it has been
+    -- edited or cancelled on its own
+    schedule_id INTEGER REFERENCES screening_schedules(id) ON DELETE SET NULL,
+    schedule_exception BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Columns added after the first release, for databases created before them
+ALTER TABLE screenings
+    ADD COLUMN IF NOT EXISTS cancellation_reason TEXT,
+    ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS schedule_id INTEGER REFERENCES screening_schedules(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS schedule_exception BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Show times used to be TIMESTAMP values local to the theater. ALTER ... USING
+-- can't look up the theater's zone, so the columns are converted as if they
+-- were UTC and then moved to the same wall-clock time in the theater's zone.
+DO $$
+BEGIN
+    IF (SELECT data_type FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'screenings' AND column_name = 'show_time'
+    ) = 'timestamp without time zone' THEN
+        ALTER TABLE screenings
+            ALTER COLUMN show_time TYPE TIMESTAMPTZ USING show_time AT TIME ZONE 'UTC',
+            ALTER COLUMN end_time TYPE TIMESTAMPTZ USING end_time AT TIME ZONE 'UTC';
+
+        UPDATE screenings s
+        SET show_time = (s.show_time AT TIME ZONE 'UTC') AT TIME ZONE t.timezone,
+            end_time = (s.end_time AT TIME ZONE 'UTC') AT TIME ZONE t.timezone
+        FROM theaters t
+        WHERE t.id = s.theater_id;
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_screenings_show_time ON screenings (show_time, id);
+CREATE INDEX IF NOT EXISTS idx_screenings_schedule ON screenings (schedule_id) WHERE schedule_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_screenings_hall_time ON screenings (hall_id, show_time);
+
+CREATE INDEX IF NOT EXISTS idx_theaters_city ON theaters (LOWER(city));
+CREATE INDEX IF NOT EXISTS idx_theaters_location ON theaters (latitude, longitude) WHERE latitude IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS hall_seats (
+    id SERIAL PRIMARY KEY,
+    hall_id INTEGER NOT NULL REFERENCES halls(id) ON DELETE CASCADE,
+    seat_label VARCHAR(10) NOT NULL,
+    seat_type VARCHAR(20) NOT NULL DEFAULT 'regular',
+    UNIQUE (hall_id, seat_label)
+);
+
+-- Ticket category prices. Theater rows are defaults, screening rows override them.
+CREATE TABLE IF NOT EXISTS price_tiers (
+    id SERIAL PRIMARY KEY,
+    theater_id INTEGER REFERENCES theaters(id) ON DELETE CASCADE,
+    screening_id INTEGER REFERENCES screenings(id) ON DELETE CASCADE,
+    category VARCHAR(20) NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    price_3d DECIMAL(10,2),
+    CHECK ((theater_id IS NULL) <> (screening_id IS NULL)),
+    UNIQUE (theater_id, category),
+    UNIQUE (screening_id, category)
+);
+
+CREATE TABLE IF NOT EXISTS seat_surcharges (
+    theater_id INTEGER NOT NULL REFERENCES theaters(id) ON DELETE CASCADE,
+    seat_type VARCHAR(20) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    PRIMARY KEY (theater_id, seat_type)
+);
+
+CREATE TABLE IF NOT EXISTS bookings (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    screening_id INTEGER NOT NULL REFERENCES screenings(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    total_amount DECIMAL(10,2) NOT NULL,
+    gift_card_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    loyalty_points INTEGER NOT NULL DEFAULT 0,
+    pickup_code VARCHAR(10),
+    payment_reference VARCHAR(100),
+    payment_event_at TIMESTAMP,
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS booking_seats (
+    id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    seat_label VARCHAR(10) NOT NULL,
+    ticket_category VARCHAR(20) NOT NULL DEFAULT 'adult',
+    seat_type VARCHAR(20) NOT NULL DEFAULT 'regular',
+    checked_in_at TIMESTAMP,
+    checked_in_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Priced lines of a booking, written once when the booking is created
+CREATE TABLE IF NOT EXISTS booking_line_items (
+    id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL DEFAULT 'ticket',
+    description VARCHAR(200) NOT NULL,
+    quantity INTEGER NOT NULL,
+    unit_price DECIMAL(10,2) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Receipts are rebuilt from the stored lines, so they must never change once written
+CREATE OR REPLACE FUNCTION reject_line_item_update() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'booking line items are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS booking_line_items_immutable ON booking_line_items;
+CREATE TRIGGER booking_line_items_immutable
+    BEFORE UPDATE ON booking_line_items
+    FOR EACH ROW EXECUTE FUNCTION reject_line_item_update();
+
+-- Theaters without a row use PPN_RATE and no convenience fee
+CREATE TABLE IF NOT EXISTS theater_charges (
+    theater_id INTEGER PRIMARY KEY REFERENCES theaters(id) ON DELETE CASCADE,
+    ppn_rate DECIMAL(5,2) NOT NULL,
+    convenience_fee DECIMAL(10,2) NOT NULL DEFAULT 0,
+    fee_taxable BOOLEAN DEFAULT TRUE,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every webhook delivery is recorded once so retried events are ignored
+CREATE TABLE IF NOT EXISTS payment_events (
+    event_id VARCHAR(100) PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    applied BOOLEAN NOT NULL DEFAULT FALSE,
+    payload JSONB,
+    received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    amount DECIMAL(10,2) NOT NULL,
+    gift_card_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    percentage INTEGER NOT NULL,
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    payment_reference VARCHAR(100),
+    gateway_reference VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Column added later, for databases created before it
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS payment_reference VARCHAR(100);
+
+CREATE TABLE IF NOT EXISTS refund_status_history (
+    id SERIAL PRIMARY KEY,
+    refund_id INTEGER NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subject VARCHAR(200) NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Gate staff allowed to check in tickets for a theater
+CREATE TABLE IF NOT EXISTS theater_staff (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    theater_id INTEGER NOT NULL REFERENCES theaters(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, theater_id)
+);
+
+-- Every condition left NULL matches any screening
+CREATE TABLE IF NOT EXISTS pricing_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    theater_id INTEGER REFERENCES theaters(id) ON DELETE CASCADE,
+    priority INTEGER NOT NULL DEFAULT 0,
+    adjustment_type VARCHAR(10) NOT NULL CHECK (adjustment_type IN ('percent', 'fixed')),
+    adjustment_value DECIMAL(10,2) NOT NULL,
+    exclusive BOOLEAN DEFAULT FALSE,
+    days_of_week INTEGER[],
+    start_time TIME,
+    end_time TIME,
+    holidays_only BOOLEAN DEFAULT FALSE,
+    premiere_days INTEGER,
+    min_occupancy INTEGER,
+    valid_from DATE,
+    valid_until DATE,
+    is_active BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- Insert sample data for testing
+CREATE TABLE IF NOT EXISTS public_holidays (
+    holiday_date DATE PRIMARY KEY,
+    name VARCHAR(100) NOT NULL
+);
+
+-- Codes are stored upper case; restrictions and limits left NULL match every booking
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    description VARCHAR(200),
+    discount_type VARCHAR(10) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    discount_value DECIMAL(10,2) NOT NULL,
+    max_discount DECIMAL(10,2),
+    min_purchase DECIMAL(10,2) NOT NULL DEFAULT 0,
+    valid_from DATE,
+    valid_until DATE,
+    max_redemptions INTEGER,
+    max_per_user INTEGER,
+    movie_ids INTEGER[],
+    theater_ids INTEGER[],
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A booking takes at most one promo code. Only redemptions of pending and paid
+-- bookings count toward the limits, so failed payments give the code back.
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id SERIAL PRIMARY KEY,
+    promo_code_id INTEGER NOT NULL REFERENCES promo_codes(id),
+    booking_id INTEGER UNIQUE NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    discount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS gift_cards (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) UNIQUE NOT NULL,
+    initial_balance DECIMAL(10,2) NOT NULL,
+    balance DECIMAL(10,2) NOT NULL CHECK (balance >= 0),
+    recipient_email VARCHAR(255),
+    expires_on DATE,
+    is_active BOOLEAN DEFAULT TRUE,
+    issued_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every change of a gift card balance, debits negative. The balance of a card
+-- always equals the sum of its transactions.
+CREATE TABLE IF NOT EXISTS gift_card_transactions (
+    id SERIAL PRIMARY KEY,
+    gift_card_id INTEGER NOT NULL REFERENCES gift_cards(id) ON DELETE CASCADE,
+    booking_id INTEGER REFERENCES bookings(id) ON DELETE SET NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('issue', 'redeem', 'refund')),
+    amount DECIMAL(10,2) NOT NULL,
+    balance_after DECIMAL(10,2) NOT NULL,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_booking ON gift_card_transactions (booking_id);
+
+-- Every change of a customer's loyalty points, debits negative. Credits are
+-- lots that expire; remaining is what is left of a lot after debits took
+-- points from it, oldest first.
+CREATE TABLE IF NOT EXISTS loyalty_transactions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    booking_id INTEGER REFERENCES bookings(id) ON DELETE SET NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('earn', 'redeem', 'expire', 'reversal')),
+    points INTEGER NOT NULL,
+    remaining INTEGER NOT NULL DEFAULT 0 CHECK (remaining >= 0 AND remaining <= GREATEST(points, 0)),
+    expires_at TIMESTAMP,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_transactions_user ON loyalty_transactions (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_loyalty_transactions_open ON loyalty_transactions (expires_at) WHERE remaining > 0;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loyalty_transactions_earned ON loyalty_transactions (booking_id) WHERE kind = 'earn';
+
+-- Food and drinks sold by a theater. A failed payment that succeeds late only
+-- takes its items again if they are still in stock, otherwise it is refunded.
+CREATE TABLE IF NOT EXISTS concession_items (
+    id SERIAL PRIMARY KEY,
+    theater_id INTEGER NOT NULL REFERENCES theaters(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    price DECIMAL(10,2) NOT NULL CHECK (price > 0),
+    stock INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (theater_id, name)
+);
+
+-- Concessions ordered with a booking, at the price when they were ordered
+CREATE TABLE IF NOT EXISTS booking_concessions (
+    id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    concession_item_id INTEGER NOT NULL REFERENCES concession_items(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_concessions_booking ON booking_concessions (booking_id);
+
+-- Admin actions that bypassed a safety check. details holds what was
+-- bypassed, as recorded by the handler that allowed it.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(50) NOT NULL,
+    entity VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity, entity_id);
+
+-- Insert sample data for testing. Rows without a natural key are only
+-- seeded into an empty table, so running the script again to upgrade a
+-- database doesn't duplicate them.
 INSERT INTO users (email, password_hash, full_name, phone_number, date_of_birth, email_verified) 
 VALUES ('admin@cinema.com', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'Admin User', '08123456789', '1990-01-01', true)
 ON CONFLICT (email) DO NOTHING;
 
 INSERT INTO movies (title, description, duration, genre, rating, director, release_date, end_date)
-VALUES 
-('Avengers: Endgame', 'The epic conclusion to the Infinity Saga', 181, '{"Action","Adventure","Sci-Fi"}', '13+', 'Russo Brothers', '2019-04-26', '2019-07-26'),
+SELECT * FROM (VALUES
+('Avengers: Endgame', 'The epic conclusion to the Infinity Saga', 181, '{"Action","Adventure","Sci-Fi"}'::VARCHAR(100)[], '13+', 'Russo Brothers', '2019-04-26'::DATE, '2019-07-26'::DATE),
 ('The Batman', 'The Dark Knight investigates corruption in Gotham City', 176, '{"Action","Crime","Drama"}', '13+', 'Matt Reeves', '2022-03-04', '2022-06-04')
-ON CONFLICT DO NOTHING;
+) AS seed
+WHERE NOT EXISTS (SELECT 1 FROM movies);
 
-INSERT INTO theaters (name, address, total_halls, contact_phone, contact_email)
-VALUES 
-('Cinema XXI Grand Indonesia', 'Jl. M.H. Thamrin No.1, Jakarta', 8, '021-1234567', 'gi@cinema21.com'),
-('CGV Pacific Place', 'Jl. Jend. Sudirman Kav. 52-53, Jakarta', 6, '021-7654321', 'pp@cgv.com')
-ON CONFLICT DO NOTHING;
+INSERT INTO theaters (name, address, city, latitude, longitude, timezone, total_halls, contact_phone, contact_email)
+SELECT * FROM (VALUES
+('Cinema XXI Grand Indonesia', 'Jl. M.H. Thamrin No.1, Jakarta', 'Jakarta', -6.1951, 106.8213, 'Asia/Jakarta', 8, '021-1234567', 'gi@cinema21.com'),
+('CGV Pacific Place', 'Jl. Jend. Sudirman Kav. 52-53, Jakarta', 'Jakarta', -6.2247, 106.8096, 'Asia/Jakarta', 6, '021-7654321', 'pp@cgv.com')
+) AS seed
+WHERE NOT EXISTS (SELECT 1 FROM theaters);
 
 INSERT INTO halls (theater_id, name, capacity, screen_type, has_3d_capability)
-VALUES 
+SELECT * FROM (VALUES
 (1, 'Hall 1', 150, 'IMAX', true),
 (1, 'Hall 2', 120, 'Dolby Atmos', true),
 (2, 'Studio 1', 100, '4DX', true),
 (2, 'Studio 2', 80, 'Regular', false)
+) AS seed
+WHERE NOT EXISTS (SELECT 1 FROM halls);
+
+-- Ten seats per row, the back row of every hall is VIP
+INSERT INTO hall_seats (hall_id, seat_label, seat_type)
+SELECT h.id, chr(65 + r) || n, CASE WHEN r = h.capacity / 10 - 1 THEN 'vip' ELSE 'regular' END
+FROM halls h, generate_series(0, 25) r, generate_series(1, 10) n
+WHERE r < h.capacity / 10
+ON CONFLICT DO NOTHING;
+
+INSERT INTO price_tiers (theater_id, category, price, price_3d)
+VALUES
+(1, 'adult', 50000, 75000),
+(1, 'child', 35000, 55000),
+(1, 'student', 40000, 60000),
+(1, 'senior', 35000, 55000),
+(2, 'adult', 55000, 80000),
+(2, 'child', 40000, 60000),
+(2, 'student', 45000, 65000),
+(2, 'senior', 40000, 60000)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO seat_surcharges (theater_id, seat_type, amount)
+VALUES
+(1, 'vip', 25000),
+(2, 'vip', 30000)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO theater_charges (theater_id, ppn_rate, convenience_fee, fee_taxable)
+VALUES
+(1, 11.00, 4000, true),
+(2, 11.00, 5000, true)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO theater_staff (user_id, theater_id)
+VALUES
+(1, 1),
+(1, 2)
+ON CONFLICT DO NOTHING;
+
+-- Only seeded into an empty table so reruns don't stack the adjustments
+INSERT INTO pricing_rules (name, priority, adjustment_type, adjustment_value, exclusive, days_of_week, start_time, end_time, holidays_only, premiere_days, min_occupancy)
+SELECT * FROM (VALUES
+('Public holiday', 100, 'percent', 20.00, true, NULL::INTEGER[], NULL::TIME, NULL::TIME, true, NULL::INTEGER, NULL::INTEGER),
+('Weekend', 50, 'percent', 10.00, false, '{0,6}', NULL, NULL, false, NULL, NULL),
+('Weekday matinee', 40, 'fixed', -10000.00, false, '{1,2,3,4,5}', '10:00', '15:00', false, NULL, NULL),
+('Prime time', 30, 'fixed', 5000.00, false, NULL, '18:00', '22:00', false, NULL, NULL),
+('Premiere week', 20, 'percent', 15.00, false, NULL, NULL, NULL, false, 7, NULL),
+('High demand', 10, 'percent', 10.00, false, NULL, NULL, NULL, false, NULL, 80)
+) AS seed
+WHERE NOT EXISTS (SELECT 1 FROM pricing_rules);
+
+INSERT INTO concession_items (theater_id, name, description, price, stock)
+VALUES
+(1, 'Popcorn Regular', 'Salted popcorn, 85 g', 45000, 200),
+(1, 'Popcorn Large', 'Caramel popcorn, 130 g', 60000, 150),
+(1, 'Mineral Water', '600 ml', 15000, 300),
+(1, 'Iced Tea', '500 ml', 25000, 200),
+(2, 'Popcorn Regular', 'Salted popcorn, 85 g', 40000, 150),
+(2, 'Soft Drink', '500 ml', 25000, 250)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO promo_codes (code, description, discount_type, discount_value, max_discount, min_purchase, max_redemptions, max_per_user)
+VALUES
+('NONTON20', '20% off, up to Rp 25.000', 'percent', 20.00, 25000, 50000, 1000, 1),
+('HEMAT10K', 'Rp 10.000 off', 'fixed', 10000.00, NULL, 0, NULL, 3)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO public_holidays (holiday_date, name)
+VALUES
+('2025-12-25', 'Christmas Day'),
+('2026-01-01', 'New Year''s Day'),
+('2026-03-20', 'Eid al-Fitr'),
+('2026-03-21', 'Eid al-Fitr'),
+('2026-08-17', 'Independence Day'),
+('2026-12-25', 'Christmas Day')
 ON CONFLICT DO NOTHING;
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"cinema-ticket-api/models"
+	"encoding/json"
+)
+
+// recordAudit writes entry to the audit log, normally inside the transaction
+// of the action it records
+func recordAudit(db execer, entry models.AuditEntry) error {
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return err
+	}
+	if entry.Details == nil {
+		details = []byte("{}")
+	}
+
+	_, err = db.Exec(`
+        INSERT INTO audit_log (user_id, action, entity, entity_id, reason, details)
+        VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6)
+    `, entry.UserID, entry.Action, entry.Entity, entry.EntityID, entry.Reason, string(details))
+	return err
+}
+
+// screeningOverrideEntry records an admin scheduling screeningID despite
+// the rules it breaks
+func screeningOverrideEntry(userID, screeningID int, reason string, violations []string) models.AuditEntry {
+	return models.AuditEntry{
+		UserID:   userID,
+		Action:   models.AuditScreeningScheduleOverride,
+		Entity:   "screening",
+		EntityID: screeningID,
+		Reason:   reason,
+		Details:  map[string]interface{}{"violations": violations},
+	}
+}
@@ -0,0 +1,476 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// CreateBooking godoc
+//
+//	@Summary		Create a booking
+//	@Description	Hold seats for a screening. The total is computed server-side from the ticket category prices, seat type surcharges, dynamic pricing rules, convenience fee and PPN; the booking stays pending until the payment gateway confirms it. A booking still pending after BOOKING_HOLD_MINUTES fails and gives its seats back.
+//	@Tags			bookings
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			bookingRequest	body		models.CreateBookingRequest				true	"Seats to book"
+//	@Success		201				{object}	models.Response{data=models.Booking}	"Booking created successfully"
+//	@Failure		400				{object}	models.Response							"Invalid request"
+//	@Failure		401				{object}	models.Response							"Unauthorized"
+//	@Failure		404				{object}	models.Response							"Screening not found"
+//	@Failure		409				{object}	models.Response							"Seats already booked"
+//	@Failure		500				{object}	models.Response							"Internal server error"
+//	@Router			/bookings [post]
+func CreateBooking(c *gin.Context) {
+	var req models.CreateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	labels := make([]string, 0, len(req.Seats))
+	requested := map[string]bool{}
+	for i, seat := range req.Seats {
+		label := strings.ToUpper(strings.TrimSpace(seat.Seat))
+		if requested[label] {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Seat "+label+" is requested twice", nil))
+			return
+		}
+		requested[label] = true
+		labels = append(labels, label)
+
+		req.Seats[i].Seat = label
+		if seat.Category == "" {
+			req.Seats[i].Category = models.TicketCategoryAdult
+		}
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	// Locking the screening serializes bookings for it
+	var (
+		hallID         int
+		theaterID      int
+		availableSeats int
+		isAvailable    bool
+		upcoming       bool
+	)
+	err = tx.QueryRow(`
+        SELECT hall_id, theater_id, available_seats, is_available, show_time > NOW()
+        FROM screenings WHERE id = $1
+        FOR UPDATE
+    `, req.ScreeningID).Scan(&hallID, &theaterID, &availableSeats, &isAvailable, &upcoming)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Screening not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	if !isAvailable || !upcoming {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Screening is not available for booking", nil))
+		return
+	}
+
+	if availableSeats < len(labels) {
+		c.JSON(http.StatusConflict, models.ErrorResponse("Not enough seats available", nil))
+		return
+	}
+
+	seatTypes, err := hallSeatTypes(tx, hallID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	booking := models.Booking{
+		UserID:      c.GetInt("user_id"),
+		ScreeningID: req.ScreeningID,
+		Status:      models.BookingStatusPending,
+	}
+	for _, seat := range req.Seats {
+		seatType := models.SeatTypeRegular
+		if len(seatTypes) > 0 {
+			var ok bool
+			if seatType, ok = seatTypes[seat.Seat]; !ok {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse("Seat "+seat.Seat+" does not exist in this hall", nil))
+				return
+			}
+		}
+		booking.Seats = append(booking.Seats, models.BookedSeat{Seat: seat.Seat, Category: seat.Category, SeatType: seatType})
+	}
+
+	taken, err := takenSeats(tx, req.ScreeningID, labels)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	if len(taken) > 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse("Seats already booked: "+strings.Join(taken, ", "), nil))
+		return
+	}
+
+	priceList, err := loadPriceList(tx, req.ScreeningID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to load prices", err))
+		return
+	}
+
+	booking.LineItems, _, err = utils.PriceSeats(priceList, booking.Seats)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Failed to price seats", err))
+		return
+	}
+
+	// Pricing rules adjust the tickets, seat surcharges stay fixed
+	ticketSubtotal := models.Money{Currency: priceList.Price.Currency}
+	for _, seat := range booking.Seats {
+		ticketSubtotal = ticketSubtotal.Add(priceList.UnitPrice(seat.Category))
+	}
+
+	evaluation, err := dynamicPricing(tx, req.ScreeningID, ticketSubtotal, len(booking.Seats))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to apply pricing rules", err))
+		return
+	}
+	booking.LineItems = append(booking.LineItems, utils.RuleLineItems(evaluation)...)
+
+	charges, err := theaterCharges(tx, theaterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to load theater charges", err))
+		return
+	}
+	booking.LineItems = append(booking.LineItems, utils.ChargeLineItems(booking.LineItems, len(booking.Seats), charges)...)
+	booking.TotalAmount = utils.LineItemsTotal(booking.LineItems)
+	booking.GiftCardAmount = models.NewMoney(0)
+	booking.AmountDue = booking.TotalAmount
+
+	err = tx.QueryRow(`
+        INSERT INTO bookings (user_id, screening_id, status, total_amount)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at, updated_at
+    `, booking.UserID, booking.ScreeningID, booking.Status, booking.TotalAmount).Scan(
+		&booking.ID, &booking.CreatedAt, &booking.UpdatedAt,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create booking", err))
+		return
+	}
+
+	for _, seat := range booking.Seats {
+		_, err := tx.Exec(`
+            INSERT INTO booking_seats (booking_id, seat_label, ticket_category, seat_type)
+            VALUES ($1, $2, $3, $4)
+        `, booking.ID, seat.Seat, seat.Category, seat.SeatType)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create booking", err))
+			return
+		}
+	}
+
+	if err := insertLineItems(tx, booking.ID, booking.LineItems); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create booking", err))
+		return
+	}
+
+	_, err = tx.Exec(`
+        UPDATE screenings SET available_seats = available_seats - $1, updated_at = NOW()
+        WHERE id = $2
+    `, len(booking.Seats), booking.ScreeningID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update seats", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to commit transaction", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse("Booking created successfully", booking))
+}
+
+// GetBooking godoc
+//
+//	@Summary		Get a booking
+//	@Description	Get a booking of the current user with its seats and line-item price breakdown
+//	@Tags			bookings
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int										true	"Booking ID"
+//	@Success		200	{object}	models.Response{data=models.Booking}	"Booking fetched successfully"
+//	@Failure		400	{object}	models.Response							"Invalid ID"
+//	@Failure		401	{object}	models.Response							"Unauthorized"
+//	@Failure		404	{object}	models.Response							"Booking not found"
+//	@Failure		500	{object}	models.Response							"Internal server error"
+//	@Router			/bookings/{id} [get]
+func GetBooking(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid booking ID", err))
+		return
+	}
+
+	var booking models.Booking
+	err = config.DB.QueryRow(`
+        SELECT id, user_id, screening_id, status, total_amount, gift_card_amount, loyalty_points, COALESCE(payment_reference, ''),
+               COALESCE(pickup_code, ''), paid_at, created_at, updated_at
+        FROM bookings WHERE id = $1
+    `, id).Scan(
+		&booking.ID, &booking.UserID, &booking.ScreeningID, &booking.Status, &booking.TotalAmount,
+		&booking.GiftCardAmount, &booking.LoyaltyPoints, &booking.PaymentReference, &booking.PickupCode,
+		&booking.PaidAt,
+		&booking.CreatedAt, &booking.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	if booking.UserID != c.GetInt("user_id") {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		return
+	}
+	booking.AmountDue = booking.TotalAmount.Sub(booking.GiftCardAmount)
+
+	booking.Seats, err = bookedSeats(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	booking.LineItems, err = bookingLineItems(config.DB, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Booking fetched successfully", booking))
+}
+
+// hallSeatTypes maps the seat labels of a hall to their seat type. Halls
+// without a seat map return an empty map.
+// bookingExpiryInterval is how often pending bookings past their hold are
+// looked for
+const bookingExpiryInterval = time.Minute
+
+// StartBookingExpiry fails the pending bookings held for longer than hold now
+// and then every minute in the background
+func StartBookingExpiry(hold time.Duration) {
+	go func() {
+		ticker := time.NewTicker(bookingExpiryInterval)
+		defer ticker.Stop()
+
+		for {
+			expired, err := expirePendingBookings(hold)
+			if err != nil {
+				log.Printf("Failed to expire pending bookings: %v", err)
+			} else if expired > 0 {
+				log.Printf("Expired %d pending bookings", expired)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// expirePendingBookings fails the bookings left pending for longer than hold
+// and gives back their seats, concessions, gift card value and loyalty points
+// in one transaction. A payment that still arrives revives the booking like
+// any failed one. It returns the number of bookings expired.
+func expirePendingBookings(hold time.Duration) (int, error) {
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// A booking the webhook pays in the meantime no longer matches once its
+	// lock is released, so it is skipped
+	rows, err := tx.Query(`
+        UPDATE bookings SET status = $1, updated_at = NOW()
+        WHERE status = $2 AND created_at <= NOW() - $3 * INTERVAL '1 second'
+        RETURNING id, gift_card_amount, loyalty_points
+    `, models.BookingStatusFailed, models.BookingStatusPending, hold.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		expired []models.Booking
+		ids     []int
+	)
+	for rows.Next() {
+		var b models.Booking
+		if err := rows.Scan(&b.ID, &b.GiftCardAmount, &b.LoyaltyPoints); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		expired = append(expired, b)
+		ids = append(ids, b.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.Exec(`
+        UPDATE screenings s
+        SET available_seats = s.available_seats + held.seats, updated_at = NOW()
+        FROM (
+            SELECT b.screening_id, COUNT(*) AS seats
+            FROM booking_seats bs JOIN bookings b ON b.id = bs.booking_id
+            WHERE b.id = ANY($1)
+            GROUP BY b.screening_id
+        ) held
+        WHERE s.id = held.screening_id
+    `, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := restockConcessions(tx, ids, 1); err != nil {
+		return 0, err
+	}
+
+	for _, b := range expired {
+		if b.GiftCardAmount.Amount == 0 && b.LoyaltyPoints == 0 {
+			continue
+		}
+		if err := releaseBookingCredits(tx, b, fmt.Sprintf("Booking #%d expired unpaid", b.ID)); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(expired), nil
+}
+
+func hallSeatTypes(q queryer, hallID int) (map[string]string, error) {
+	rows, err := q.Query("SELECT seat_label, seat_type FROM hall_seats WHERE hall_id = $1", hallID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seatTypes := map[string]string{}
+	for rows.Next() {
+		var label, seatType string
+		if err := rows.Scan(&label, &seatType); err != nil {
+			return nil, err
+		}
+		seatTypes[label] = seatType
+	}
+
+	return seatTypes, rows.Err()
+}
+
+// takenSeats returns which of labels are held by pending or paid bookings
+func takenSeats(q queryer, screeningID int, labels []string) ([]string, error) {
+	rows, err := q.Query(`
+        SELECT bs.seat_label
+        FROM booking_seats bs JOIN bookings b ON b.id = bs.booking_id
+        WHERE b.screening_id = $1 AND b.status IN ('pending', 'paid') AND bs.seat_label = ANY($2)
+        ORDER BY bs.seat_label
+    `, screeningID, pq.Array(labels))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var taken []string
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, err
+		}
+		taken = append(taken, label)
+	}
+
+	return taken, rows.Err()
+}
+
+func insertLineItems(tx *sql.Tx, bookingID int, items []models.LineItem) error {
+	for _, item := range items {
+		_, err := tx.Exec(`
+            INSERT INTO booking_line_items (booking_id, kind, description, quantity, unit_price, amount)
+            VALUES ($1, $2, $3, $4, $5, $6)
+        `, bookingID, item.Kind, item.Description, item.Quantity, item.UnitPrice, item.Amount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bookedSeats(bookingID int) ([]models.BookedSeat, error) {
+	rows, err := config.DB.Query(`
+        SELECT seat_label, ticket_category, seat_type FROM booking_seats
+        WHERE booking_id = $1
+        ORDER BY seat_label
+    `, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seats []models.BookedSeat
+	for rows.Next() {
+		var s models.BookedSeat
+		if err := rows.Scan(&s.Seat, &s.Category, &s.SeatType); err != nil {
+			return nil, err
+		}
+		seats = append(seats, s)
+	}
+
+	return seats, rows.Err()
+}
+
+func bookingLineItems(q queryer, bookingID int) ([]models.LineItem, error) {
+	rows, err := q.Query(`
+        SELECT kind, description, quantity, unit_price, amount FROM booking_line_items
+        WHERE booking_id = $1
+        ORDER BY id
+    `, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.LineItem
+	for rows.Next() {
+		var item models.LineItem
+		if err := rows.Scan(&item.Kind, &item.Description, &item.Quantity, &item.UnitPrice, &item.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
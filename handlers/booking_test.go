@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// rupiah returns a whole amount of Rupiah
+func rupiah(amount int64) models.Money {
+	return models.NewMoney(amount * 100)
+}
+
+func newBookingRequest(seats ...models.BookingSeatRequest) *http.Request {
+	body, _ := json.Marshal(models.CreateBookingRequest{ScreeningID: 1, Seats: seats})
+	req, _ := http.NewRequest("POST", "/bookings", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func expectBookableScreening(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT hall_id, theater_id, available_seats, is_available, show_time > NOW\\(\\) FROM screenings").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"hall_id", "theater_id", "available_seats", "is_available", "upcoming"}).
+			AddRow(2, 1, 100, true, true))
+	mock.ExpectQuery("SELECT seat_label, seat_type FROM hall_seats WHERE hall_id = \\$1").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"seat_label", "seat_type"}).
+			AddRow("A1", "regular").
+			AddRow("J1", "vip"))
+}
+
+func expectPriceList(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT price, COALESCE\\(price_3d, 0\\), is_3d, theater_id FROM screenings").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"price", "price_3d", "is_3d", "theater_id"}).
+			AddRow(50000.0, 75000.0, false, 1))
+	mock.ExpectQuery("SELECT category, price, COALESCE\\(price_3d, 0\\) FROM price_tiers").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"category", "price", "price_3d"}).
+			AddRow("child", 35000.0, 55000.0).
+			AddRow("adult", 50000.0, 75000.0).
+			AddRow("child", 30000.0, 0.0))
+	mock.ExpectQuery("SELECT seat_type, amount FROM seat_surcharges").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"seat_type", "amount"}).AddRow("vip", 25000.0))
+}
+
+func TestCreateBooking_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	expectBookableScreening(mock)
+	mock.ExpectQuery("SELECT bs.seat_label FROM booking_seats bs").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"seat_label"}))
+	expectPriceList(mock)
+	expectPricingRules(mock, sqlmock.NewRows(pricingRuleRowColumns).
+		AddRow(2, "Weekend", nil, 50, "percent", 10.0, false, "{0,6}", "", "", false, 0, 0, "", "", true, now, now))
+	mock.ExpectQuery("SELECT ppn_rate, convenience_fee, fee_taxable FROM theater_charges").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ppn_rate", "convenience_fee", "fee_taxable"}).AddRow(11.0, 4000.0, true))
+	mock.ExpectQuery("INSERT INTO bookings").
+		WithArgs(1, 1, models.BookingStatusPending, "134310.00").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(5, now, now))
+	mock.ExpectExec("INSERT INTO booking_seats").
+		WithArgs(5, "A1", "child", "regular").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO booking_seats").
+		WithArgs(5, "J1", "adult", "vip").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemTicket, "Adult ticket", 1, "50000.00", "50000.00").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemTicket, "Child ticket", 1, "30000.00", "30000.00").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemSurcharge, "VIP seat surcharge", 1, "25000.00", "25000.00").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemPricingRule, "Weekend", 1, "8000.00", "8000.00").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemFee, "Convenience fee", 2, "4000.00", "8000.00").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemTax, "PPN 11%", 1, "13310.00", "13310.00").
+		WillReturnResult(sqlmock.NewResult(6, 1))
+	mock.ExpectExec("UPDATE screenings SET available_seats = available_seats - \\$1").
+		WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/bookings", withUser(1), CreateBooking)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newBookingRequest(
+		models.BookingSeatRequest{Seat: "a1", Category: "child"},
+		models.BookingSeatRequest{Seat: "J1"},
+	))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response struct {
+		Success bool           `json:"success"`
+		Data    models.Booking `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.True(t, response.Success)
+	assert.Equal(t, 5, response.Data.ID)
+	assert.Equal(t, rupiah(134310), response.Data.TotalAmount)
+	assert.Len(t, response.Data.LineItems, 6)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateBooking_SeatTaken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	expectBookableScreening(mock)
+	mock.ExpectQuery("SELECT bs.seat_label FROM booking_seats bs").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"seat_label"}).AddRow("A1"))
+	mock.ExpectRollback()
+
+	router := setupTestRouter()
+	router.POST("/bookings", withUser(1), CreateBooking)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newBookingRequest(models.BookingSeatRequest{Seat: "A1"}))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "Seats already booked: A1", response.Message)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateBooking_UnknownSeat(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	expectBookableScreening(mock)
+	mock.ExpectRollback()
+
+	router := setupTestRouter()
+	router.POST("/bookings", withUser(1), CreateBooking)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newBookingRequest(models.BookingSeatRequest{Seat: "Z99"}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateBooking_DuplicateSeat(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/bookings", withUser(1), CreateBooking)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newBookingRequest(
+		models.BookingSeatRequest{Seat: "A1"},
+		models.BookingSeatRequest{Seat: "a1"},
+	))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetBooking_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT id, user_id, screening_id, status, total_amount").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "screening_id", "status", "total_amount", "gift_card_amount", "loyalty_points",
+			"payment_reference", "pickup_code", "paid_at", "created_at", "updated_at",
+		}).AddRow(5, 1, 1, models.BookingStatusPending, 75000.0, 25000.0, 0, "", "", nil, now, now))
+	mock.ExpectQuery("SELECT seat_label, ticket_category, seat_type FROM booking_seats").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"seat_label", "ticket_category", "seat_type"}).
+			AddRow("J1", "adult", "vip"))
+	mock.ExpectQuery("SELECT kind, description, quantity, unit_price, amount FROM booking_line_items").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "description", "quantity", "unit_price", "amount"}).
+			AddRow(models.LineItemTicket, "Adult ticket", 1, 50000.0, 50000.0).
+			AddRow(models.LineItemSurcharge, "VIP seat surcharge", 1, 25000.0, 25000.0))
+
+	router := setupTestRouter()
+	router.GET("/bookings/:id", withUser(1), GetBooking)
+
+	req, _ := http.NewRequest("GET", "/bookings/5", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.Booking `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, []models.BookedSeat{{Seat: "J1", Category: "adult", SeatType: "vip"}}, response.Data.Seats)
+	assert.Len(t, response.Data.LineItems, 2)
+	assert.Equal(t, rupiah(50000), response.Data.AmountDue)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExpirePendingBookings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE bookings SET status = \\$1").
+		WithArgs(models.BookingStatusFailed, models.BookingStatusPending, 900.0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "gift_card_amount", "loyalty_points"}).
+			AddRow(3, 0.0, 0).
+			AddRow(4, 0.0, 50))
+	mock.ExpectExec("UPDATE screenings s").
+		WithArgs("{3,4}").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	expectRestockConcessions(mock, "{3,4}", 1)
+	expectGiftCardRedemptions(mock, 4, sqlmock.NewRows([]string{"gift_card_id", "amount"}))
+	expectLoyaltyPoints(mock, 4, 50, 0)
+	mock.ExpectExec("INSERT INTO loyalty_transactions").
+		WithArgs(1, 4, models.LoyaltyReversal, 50, 365, "Booking #4 expired unpaid").
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectCommit()
+
+	expired, err := expirePendingBookings(15 * time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, expired)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
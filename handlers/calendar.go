@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const calendarEventsQuery = `
+    SELECT b.id, b.status, GREATEST(b.updated_at, s.updated_at), m.title, m.duration,
+           t.name, t.address, h.name, s.show_time, s.end_time,
+           COALESCE(string_agg(bs.seat_label, ', ' ORDER BY bs.seat_label), '')
+    FROM bookings b
+    JOIN screenings s ON s.id = b.screening_id
+    JOIN movies m ON m.id = s.movie_id
+    JOIN theaters t ON t.id = s.theater_id
+    JOIN halls h ON h.id = s.hall_id
+    LEFT JOIN booking_seats bs ON bs.booking_id = b.id
+    WHERE b.status IN ('paid', 'refunded', 'cancelled') AND %s
+    GROUP BY b.id, s.id, m.id, t.id, h.id
+    ORDER BY s.show_time
+`
+
+// GetBookingCalendar godoc
+//
+//	@Summary		Download booking calendar event
+//	@Description	Download an iCalendar (RFC 5545) event for a booking. The UID is stable so importing it again updates the existing event.
+//	@Tags			bookings
+//	@Produce		text/calendar
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Booking ID"
+//	@Success		200	{file}		file			"iCalendar file"
+//	@Failure		400	{object}	models.Response	"Invalid ID"
+//	@Failure		401	{object}	models.Response	"Unauthorized"
+//	@Failure		404	{object}	models.Response	"Booking not found"
+//	@Failure		500	{object}	models.Response	"Internal server error"
+//	@Router			/bookings/{id}/calendar.ics [get]
+func GetBookingCalendar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid booking ID", err))
+		return
+	}
+
+	events, err := calendarEvents("b.id = $1 AND b.user_id = $2", id, c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	if len(events) == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="booking-%d.ics"`, id))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", utils.RenderCalendar("Cinema booking", events))
+}
+
+// GetCalendarFeedURL godoc
+//
+//	@Summary		Get calendar subscription URL
+//	@Description	Get the private URL of the current user's calendar feed of upcoming bookings, to subscribe to from a calendar app
+//	@Tags			bookings
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	models.Response{data=models.CalendarFeed}	"Calendar feed fetched successfully"
+//	@Failure		401	{object}	models.Response								"Unauthorized"
+//	@Failure		500	{object}	models.Response								"Internal server error"
+//	@Router			/me/calendar [get]
+func GetCalendarFeedURL(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var version int
+	err := config.DB.QueryRow("SELECT calendar_token_version FROM users WHERE id = $1", userID).Scan(&version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Calendar feed fetched successfully", calendarFeed(c, userID, version)))
+}
+
+// ResetCalendarFeedURL godoc
+//
+//	@Summary		Reset calendar subscription URL
+//	@Description	Revoke the current user's calendar feed URL, for example after it was shared by mistake, and get a new one
+//	@Tags			bookings
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	models.Response{data=models.CalendarFeed}	"Calendar feed reset successfully"
+//	@Failure		401	{object}	models.Response								"Unauthorized"
+//	@Failure		500	{object}	models.Response								"Internal server error"
+//	@Router			/me/calendar/reset [post]
+func ResetCalendarFeedURL(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var version int
+	err := config.DB.QueryRow(`
+        UPDATE users SET calendar_token_version = calendar_token_version + 1, updated_at = NOW()
+        WHERE id = $1
+        RETURNING calendar_token_version
+    `, userID).Scan(&version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to reset calendar feed", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Calendar feed reset successfully", calendarFeed(c, userID, version)))
+}
+
+func calendarFeed(c *gin.Context, userID, version int) models.CalendarFeed {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	token := utils.GenerateCalendarToken(userID, version)
+	return models.CalendarFeed{
+		URL: fmt.Sprintf("%s://%s/api/v1/calendar/%s.ics", scheme, c.Request.Host, token),
+	}
+}
+
+// GetCalendarFeed godoc
+//
+//	@Summary		Calendar subscription feed
+//	@Description	iCalendar (RFC 5545) feed of the user's upcoming bookings. The token comes from GET /me/calendar and stops working once the URL is reset.
+//	@Tags			bookings
+//	@Produce		text/calendar
+//	@Param			token	path		string			true	"Calendar feed token followed by .ics"
+//	@Success		200		{file}		file			"iCalendar feed"
+//	@Failure		404		{object}	models.Response	"Calendar not found"
+//	@Failure		500		{object}	models.Response	"Internal server error"
+//	@Router			/calendar/{token} [get]
+func GetCalendarFeed(c *gin.Context) {
+	userID, version, ok := utils.VerifyCalendarToken(strings.TrimSuffix(c.Param("token"), ".ics"))
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Calendar not found", nil))
+		return
+	}
+
+	var currentVersion int
+	err := config.DB.QueryRow("SELECT calendar_token_version FROM users WHERE id = $1", userID).Scan(&currentVersion)
+	if err == sql.ErrNoRows || (err == nil && currentVersion != version) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Calendar not found", nil))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	events, err := calendarEvents("b.user_id = $1 AND s.end_time > NOW()", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", utils.RenderCalendar("Cinema bookings", events))
+}
+
+// calendarEvents loads the bookings matching condition as calendar events.
+// condition is a fixed SQL fragment; values are always passed as args.
+func calendarEvents(condition string, args ...interface{}) ([]models.CalendarEvent, error) {
+	rows, err := config.DB.Query(fmt.Sprintf(calendarEventsQuery, condition), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.CalendarEvent
+	for rows.Next() {
+		var (
+			bookingID int
+			status    string
+			title     string
+			duration  int
+			theater   string
+			address   string
+			hall      string
+			seats     string
+			event     models.CalendarEvent
+		)
+		err := rows.Scan(&bookingID, &status, &event.LastModified, &title, &duration,
+			&theater, &address, &hall, &event.Start, &event.End, &seats)
+		if err != nil {
+			return nil, err
+		}
+
+		event.UID = fmt.Sprintf("booking-%d@cinema-ticket-api", bookingID)
+		event.Summary = title
+		event.Location = theater + ", " + address
+		event.Description = fmt.Sprintf("Booking #%d\n%s\nSeats: %s\nDuration: %s",
+			bookingID, hall, seats, time.Duration(duration)*time.Minute)
+		event.Cancelled = status != models.BookingStatusPaid
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func calendarRows(status string) *sqlmock.Rows {
+	showTime := time.Date(2025, 12, 25, 11, 0, 0, 0, time.UTC)
+	return sqlmock.NewRows([]string{
+		"id", "status", "updated_at", "title", "duration", "theater", "address", "hall", "show_time", "end_time", "seats",
+	}).AddRow(
+		1, status, time.Now(), "The Batman", 176, "CGV Pacific Place", "Jl. Jend. Sudirman Kav. 52-53, Jakarta",
+		"Studio 1", showTime, showTime.Add(176*time.Minute), "B1, B2",
+	)
+}
+
+func TestGetBookingCalendar_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("SELECT b.id, b.status").
+		WithArgs(1, 1).
+		WillReturnRows(calendarRows(models.BookingStatusPaid))
+
+	router := setupTestRouter()
+	router.GET("/bookings/:id/calendar.ics", withUser(1), GetBookingCalendar)
+
+	req, _ := http.NewRequest("GET", "/bookings/1/calendar.ics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, "UID:booking-1@cinema-ticket-api\r\n")
+	assert.Contains(t, body, "DTSTART:20251225T110000Z\r\n")
+	assert.Contains(t, body, "STATUS:CONFIRMED\r\n")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetBookingCalendar_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("SELECT b.id, b.status").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	router := setupTestRouter()
+	router.GET("/bookings/:id/calendar.ics", withUser(2), GetBookingCalendar)
+
+	req, _ := http.NewRequest("GET", "/bookings/1/calendar.ics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// expectCalendarTokenVersion expects the lookup of user 1's calendar token version
+func expectCalendarTokenVersion(mock sqlmock.Sqlmock, version int) {
+	mock.ExpectQuery("SELECT calendar_token_version FROM users WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"calendar_token_version"}).AddRow(version))
+}
+
+func TestCalendarFeed(t *testing.T) {
+	os.Setenv("JWT_SECRET", "test_secret_key")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	router := setupTestRouter()
+	router.GET("/me/calendar", withUser(1), GetCalendarFeedURL)
+	router.GET("/api/v1/calendar/:token", GetCalendarFeed)
+
+	expectCalendarTokenVersion(mock, 2)
+
+	req, _ := http.NewRequest("GET", "/me/calendar", nil)
+	req.Host = "localhost:4000"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.CalendarFeed `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "http://localhost:4000/api/v1/calendar/"+utils.GenerateCalendarToken(1, 2)+".ics", response.Data.URL)
+
+	expectCalendarTokenVersion(mock, 2)
+	mock.ExpectQuery("SELECT b.id, b.status").
+		WithArgs(1).
+		WillReturnRows(calendarRows(models.BookingStatusRefunded))
+
+	req, _ = http.NewRequest("GET", strings.TrimPrefix(response.Data.URL, "http://localhost:4000"), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "STATUS:CANCELLED\r\n")
+
+	req, _ = http.NewRequest("GET", "/api/v1/calendar/1.2.forged.ics", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestResetCalendarFeedURL_RevokesOldURL(t *testing.T) {
+	os.Setenv("JWT_SECRET", "test_secret_key")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	router := setupTestRouter()
+	router.POST("/me/calendar/reset", withUser(1), ResetCalendarFeedURL)
+	router.GET("/api/v1/calendar/:token", GetCalendarFeed)
+
+	mock.ExpectQuery("UPDATE users SET calendar_token_version = calendar_token_version \\+ 1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"calendar_token_version"}).AddRow(3))
+
+	req, _ := http.NewRequest("POST", "/me/calendar/reset", nil)
+	req.Host = "localhost:4000"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.CalendarFeed `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "http://localhost:4000/api/v1/calendar/"+utils.GenerateCalendarToken(1, 3)+".ics", response.Data.URL)
+
+	// The URL handed out before the reset no longer works
+	expectCalendarTokenVersion(mock, 3)
+
+	req, _ = http.NewRequest("GET", "/api/v1/calendar/"+utils.GenerateCalendarToken(1, 2)+".ics", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
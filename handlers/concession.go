@@ -0,0 +1,479 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+const concessionItemColumns = `
+        id, theater_id, name, COALESCE(description, ''), price, stock, is_active, created_at, updated_at`
+
+// GetTheaterConcessions godoc
+//
+//	@Summary		List concessions of a theater
+//	@Description	List the food and drinks a theater currently sells, with the stock left
+//	@Tags			concessions
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int												true	"Theater ID"
+//	@Success		200	{object}	models.Response{data=[]models.ConcessionItem}	"Concessions fetched successfully"
+//	@Failure		400	{object}	models.Response									"Invalid ID"
+//	@Failure		401	{object}	models.Response									"Unauthorized"
+//	@Failure		500	{object}	models.Response									"Internal server error"
+//	@Router			/theaters/{id}/concessions [get]
+func GetTheaterConcessions(c *gin.Context) {
+	theaterID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid theater ID", err))
+		return
+	}
+
+	listConcessionItems(c, `
+        SELECT `+concessionItemColumns+` FROM concession_items
+        WHERE theater_id = $1 AND is_active = true
+        ORDER BY name
+    `, theaterID)
+}
+
+// GetConcessionItems godoc
+//
+//	@Summary		List concession items
+//	@Description	List concession items of every theater, or of one theater with theater_id, including inactive ones (Admin only)
+//	@Tags			concessions
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			theater_id	query		int												false	"Theater ID"
+//	@Success		200			{object}	models.Response{data=[]models.ConcessionItem}	"Concessions fetched successfully"
+//	@Failure		400			{object}	models.Response									"Invalid theater ID"
+//	@Failure		401			{object}	models.Response									"Unauthorized"
+//	@Failure		500			{object}	models.Response									"Internal server error"
+//	@Router			/concessions [get]
+func GetConcessionItems(c *gin.Context) {
+	theaterID := 0
+	if value := c.Query("theater_id"); value != "" {
+		var err error
+		if theaterID, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid theater ID", err))
+			return
+		}
+	}
+
+	listConcessionItems(c, `
+        SELECT `+concessionItemColumns+` FROM concession_items
+        WHERE $1 = 0 OR theater_id = $1
+        ORDER BY theater_id, name
+    `, theaterID)
+}
+
+func listConcessionItems(c *gin.Context, query string, args ...interface{}) {
+	rows, err := config.DB.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer rows.Close()
+
+	items := []models.ConcessionItem{}
+	for rows.Next() {
+		item, err := scanConcessionItem(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+			return
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Concessions fetched successfully", items))
+}
+
+// CreateConcessionItem godoc
+//
+//	@Summary		Create concession item
+//	@Description	Add a food or drink item to a theater's concession catalog (Admin only)
+//	@Tags			concessions
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id					path		int											true	"Theater ID"
+//	@Param			concessionRequest	body		models.ConcessionItemRequest				true	"Concession item"
+//	@Success		201					{object}	models.Response{data=models.ConcessionItem}	"Concession item created successfully"
+//	@Failure		400					{object}	models.Response								"Invalid request"
+//	@Failure		401					{object}	models.Response								"Unauthorized"
+//	@Failure		404					{object}	models.Response								"Theater not found"
+//	@Failure		409					{object}	models.Response								"Theater already sells an item with this name"
+//	@Failure		500					{object}	models.Response								"Internal server error"
+//	@Router			/theaters/{id}/concessions [post]
+func CreateConcessionItem(c *gin.Context) {
+	theaterID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid theater ID", err))
+		return
+	}
+
+	var req models.ConcessionItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	item, err := scanConcessionItem(config.DB.QueryRow(`
+        INSERT INTO concession_items (theater_id, name, description, price, stock, is_active)
+        VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+        RETURNING `+concessionItemColumns,
+		theaterID, req.Name, req.Description, req.Price, req.Stock, concessionItemActive(req)))
+	if err != nil {
+		if isPQError(err, "23503") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Theater not found", nil))
+		} else if isPQError(err, "23505") {
+			c.JSON(http.StatusConflict, models.ErrorResponse("Theater already sells an item with this name", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create concession item", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse("Concession item created successfully", item))
+}
+
+// UpdateConcessionItem godoc
+//
+//	@Summary		Replace concession item
+//	@Description	Replace the name, price, stock count and availability of a concession item. Orders already placed keep their price. (Admin only)
+//	@Tags			concessions
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id					path		int											true	"Concession item ID"
+//	@Param			concessionRequest	body		models.ConcessionItemRequest				true	"Concession item"
+//	@Success		200					{object}	models.Response{data=models.ConcessionItem}	"Concession item updated successfully"
+//	@Failure		400					{object}	models.Response								"Invalid request"
+//	@Failure		401					{object}	models.Response								"Unauthorized"
+//	@Failure		404					{object}	models.Response								"Concession item not found"
+//	@Failure		409					{object}	models.Response								"Theater already sells an item with this name"
+//	@Failure		500					{object}	models.Response								"Internal server error"
+//	@Router			/concessions/{id} [put]
+func UpdateConcessionItem(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid concession item ID", err))
+		return
+	}
+
+	var req models.ConcessionItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	item, err := scanConcessionItem(config.DB.QueryRow(`
+        UPDATE concession_items SET
+            name = $1, description = NULLIF($2, ''), price = $3, stock = $4, is_active = $5, updated_at = NOW()
+        WHERE id = $6
+        RETURNING `+concessionItemColumns,
+		req.Name, req.Description, req.Price, req.Stock, concessionItemActive(req), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Concession item not found", nil))
+		} else if isPQError(err, "23505") {
+			c.JSON(http.StatusConflict, models.ErrorResponse("Theater already sells an item with this name", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update concession item", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Concession item updated successfully", item))
+}
+
+// DeleteConcessionItem godoc
+//
+//	@Summary		Delete concession item
+//	@Description	Delete a concession item that was never ordered. Ordered items are deactivated instead. (Admin only)
+//	@Tags			concessions
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Concession item ID"
+//	@Success		200	{object}	models.Response	"Concession item deleted successfully"
+//	@Failure		400	{object}	models.Response	"Invalid ID"
+//	@Failure		401	{object}	models.Response	"Unauthorized"
+//	@Failure		404	{object}	models.Response	"Concession item not found"
+//	@Failure		409	{object}	models.Response	"Concession item has been ordered"
+//	@Failure		500	{object}	models.Response	"Internal server error"
+//	@Router			/concessions/{id} [delete]
+func DeleteConcessionItem(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid concession item ID", err))
+		return
+	}
+
+	result, err := config.DB.Exec("DELETE FROM concession_items WHERE id = $1", id)
+	if err != nil {
+		if isPQError(err, "23503") {
+			c.JSON(http.StatusConflict, models.ErrorResponse("Concession item has been ordered, deactivate it instead", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to delete concession item", err))
+		}
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Concession item not found", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Concession item deleted successfully", nil))
+}
+
+// AddBookingConcessions godoc
+//
+//	@Summary		Add concessions to a booking
+//	@Description	Order food and drinks from the screening's theater with a pending booking of the current user. The items are added to the booking's line items and total, their stock is reserved, and the booking gets a pickup code to collect them with.
+//	@Tags			bookings
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id					path		int												true	"Booking ID"
+//	@Param			concessionsRequest	body		models.AddConcessionsRequest					true	"Concession items"
+//	@Success		200					{object}	models.Response{data=models.AddedConcessions}	"Concessions added successfully"
+//	@Failure		400					{object}	models.Response									"Invalid request or booking is not pending"
+//	@Failure		401					{object}	models.Response									"Unauthorized"
+//	@Failure		404					{object}	models.Response									"Booking or concession item not found"
+//	@Failure		409					{object}	models.Response									"Not enough stock"
+//	@Failure		500					{object}	models.Response									"Internal server error"
+//	@Router			/bookings/{id}/concessions [post]
+func AddBookingConcessions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid booking ID", err))
+		return
+	}
+
+	var req models.AddConcessionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	// The same item listed twice is one order of the combined quantity
+	quantities := map[int]int{}
+	var itemIDs pq.Int64Array
+	for _, order := range req.Items {
+		if quantities[order.ConcessionID] == 0 {
+			itemIDs = append(itemIDs, int64(order.ConcessionID))
+		}
+		quantities[order.ConcessionID] += order.Quantity
+	}
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i] < itemIDs[j] })
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	var (
+		userID         int
+		status         string
+		giftCardAmount models.Money
+		theaterID      int
+	)
+	err = tx.QueryRow(`
+        SELECT b.user_id, b.status, b.gift_card_amount, s.theater_id
+        FROM bookings b JOIN screenings s ON s.id = b.screening_id
+        WHERE b.id = $1
+        FOR UPDATE OF b
+    `, id).Scan(&userID, &status, &giftCardAmount, &theaterID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	if userID != c.GetInt("user_id") {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		return
+	}
+
+	if status != models.BookingStatusPending {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Concessions can only be added to pending bookings", nil))
+		return
+	}
+
+	// Locking the items in id order keeps two bookings from selling the same
+	// stock and from deadlocking on each other
+	rows, err := tx.Query(`
+        SELECT `+concessionItemColumns+` FROM concession_items
+        WHERE id = ANY($1) AND theater_id = $2
+        ORDER BY id
+        FOR UPDATE
+    `, itemIDs, theaterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	found := map[int]models.ConcessionItem{}
+	for rows.Next() {
+		item, err := scanConcessionItem(rows)
+		if err != nil {
+			rows.Close()
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+			return
+		}
+		found[item.ID] = item
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	var orders []models.ConcessionOrder
+	for _, itemID := range itemIDs {
+		item, ok := found[int(itemID)]
+		if !ok {
+			c.JSON(http.StatusNotFound, models.ErrorResponse(fmt.Sprintf("Concession item %d is not sold at this theater", itemID), nil))
+			return
+		}
+		if !item.IsActive {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse(fmt.Sprintf("%s is no longer available", item.Name), nil))
+			return
+		}
+
+		quantity := quantities[item.ID]
+		if item.Stock < quantity {
+			c.JSON(http.StatusConflict, models.ErrorResponse(fmt.Sprintf("Only %d %s left", item.Stock, item.Name), nil))
+			return
+		}
+
+		orders = append(orders, models.ConcessionOrder{
+			ItemID:    item.ID,
+			Name:      item.Name,
+			Quantity:  quantity,
+			UnitPrice: item.Price,
+		})
+	}
+
+	for _, order := range orders {
+		_, err := tx.Exec(`
+            UPDATE concession_items SET stock = stock - $1, updated_at = NOW() WHERE id = $2
+        `, order.Quantity, order.ItemID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to reserve concessions", err))
+			return
+		}
+
+		_, err = tx.Exec(`
+            INSERT INTO booking_concessions (booking_id, concession_item_id, quantity, unit_price)
+            VALUES ($1, $2, $3, $4)
+        `, id, order.ItemID, order.Quantity, order.UnitPrice)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to add concessions", err))
+			return
+		}
+	}
+
+	items, err := bookingLineItems(tx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	charges, err := theaterCharges(tx, theaterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to load theater charges", err))
+		return
+	}
+
+	lines := utils.ConcessionLineItems(items, orders, charges)
+	if err := insertLineItems(tx, id, lines); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to add concessions", err))
+		return
+	}
+	items = append(items, lines...)
+
+	pickupCode, err := utils.GeneratePickupCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate pickup code", err))
+		return
+	}
+
+	added := models.AddedConcessions{
+		BookingID:   id,
+		TotalAmount: utils.LineItemsTotal(items),
+		LineItems:   items,
+	}
+	added.AmountDue = added.TotalAmount.Sub(giftCardAmount)
+
+	// A booking keeps the pickup code of its first order
+	err = tx.QueryRow(`
+        UPDATE bookings SET total_amount = $1, pickup_code = COALESCE(pickup_code, $2), updated_at = NOW()
+        WHERE id = $3
+        RETURNING pickup_code
+    `, added.TotalAmount, pickupCode, id).Scan(&added.PickupCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update booking", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to commit transaction", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Concessions added successfully", added))
+}
+
+// restockConcessions puts the concessions ordered with bookings back in stock
+// once the bookings no longer hold them, or takes them out again when delta
+// is negative
+func restockConcessions(db execer, bookingIDs []int, delta int) error {
+	ids := make(pq.Int64Array, len(bookingIDs))
+	for i, id := range bookingIDs {
+		ids[i] = int64(id)
+	}
+
+	_, err := db.Exec(`
+        UPDATE concession_items c
+        SET stock = c.stock + $1 * o.quantity, updated_at = NOW()
+        FROM (
+            SELECT concession_item_id, SUM(quantity) AS quantity
+            FROM booking_concessions
+            WHERE booking_id = ANY($2)
+            GROUP BY concession_item_id
+        ) o
+        WHERE c.id = o.concession_item_id
+    `, delta, ids)
+	return err
+}
+
+func scanConcessionItem(row rowScanner) (models.ConcessionItem, error) {
+	var item models.ConcessionItem
+	err := row.Scan(
+		&item.ID, &item.TheaterID, &item.Name, &item.Description, &item.Price, &item.Stock, &item.IsActive,
+		&item.CreatedAt, &item.UpdatedAt,
+	)
+	return item, err
+}
+
+func concessionItemActive(req models.ConcessionItemRequest) bool {
+	if req.IsActive != nil {
+		return *req.IsActive
+	}
+	return true
+}
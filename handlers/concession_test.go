@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var concessionItemRowColumns = []string{
+	"id", "theater_id", "name", "description", "price", "stock", "is_active", "created_at", "updated_at",
+}
+
+// expectRestockConcessions expects the concessions of bookings, given as a
+// PostgreSQL array literal, to be put back in stock or taken out again
+func expectRestockConcessions(mock sqlmock.Sqlmock, bookingIDs string, delta int) {
+	mock.ExpectExec("UPDATE concession_items c").
+		WithArgs(delta, bookingIDs).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectConcessionBooking expects booking 5 of user 1, pending at theater 1,
+// and the concession items 1 and 3 locked with the given stock
+func expectConcessionBooking(mock sqlmock.Sqlmock, popcornStock int) {
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT b.user_id, b.status, b.gift_card_amount, s.theater_id").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "status", "gift_card_amount", "theater_id"}).
+			AddRow(1, models.BookingStatusPending, 20000.0, 1))
+	mock.ExpectQuery("FROM concession_items").
+		WithArgs("{1,3}", 1).
+		WillReturnRows(sqlmock.NewRows(concessionItemRowColumns).
+			AddRow(1, 1, "Popcorn Regular", "", 45000.0, popcornStock, true, now, now).
+			AddRow(3, 1, "Mineral Water", "", 15000.0, 300, true, now, now))
+}
+
+func newAddConcessionsRequest() *http.Request {
+	body, _ := json.Marshal(models.AddConcessionsRequest{Items: []models.ConcessionOrderRequest{
+		{ConcessionID: 3, Quantity: 1},
+		{ConcessionID: 1, Quantity: 1},
+		{ConcessionID: 1, Quantity: 1},
+	}})
+	req, _ := http.NewRequest("POST", "/bookings/5/concessions", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestAddBookingConcessions_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	expectConcessionBooking(mock, 200)
+	mock.ExpectExec("UPDATE concession_items SET stock = stock - \\$1").
+		WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO booking_concessions").
+		WithArgs(5, 1, 2, "45000.00").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE concession_items SET stock = stock - \\$1").
+		WithArgs(1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO booking_concessions").
+		WithArgs(5, 3, 1, "15000.00").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectQuery("SELECT kind, description, quantity, unit_price, amount FROM booking_line_items").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "description", "quantity", "unit_price", "amount"}).
+			AddRow(models.LineItemTicket, "Adult ticket", 2, 45500.0, 91000.0).
+			AddRow(models.LineItemFee, "Convenience fee", 2, 4000.0, 8000.0).
+			AddRow(models.LineItemTax, "PPN 11%", 1, 10890.0, 10890.0))
+	mock.ExpectQuery("SELECT ppn_rate, convenience_fee, fee_taxable FROM theater_charges").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ppn_rate", "convenience_fee", "fee_taxable"}).AddRow(11.0, 4000.0, true))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemConcession, "Popcorn Regular", 2, "45000.00", "90000.00").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemConcession, "Mineral Water", 1, "15000.00", "15000.00").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemTax, "PPN 11% adjustment", 1, "11550.00", "11550.00").
+		WillReturnResult(sqlmock.NewResult(6, 1))
+	mock.ExpectQuery("UPDATE bookings SET total_amount = \\$1, pickup_code = COALESCE\\(pickup_code, \\$2\\)").
+		WithArgs("226440.00", sqlmock.AnyArg(), 5).
+		WillReturnRows(sqlmock.NewRows([]string{"pickup_code"}).AddRow("K7Q2MX"))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/concessions", withUser(1), AddBookingConcessions)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newAddConcessionsRequest())
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.AddedConcessions `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "K7Q2MX", response.Data.PickupCode)
+	assert.Equal(t, rupiah(226440), response.Data.TotalAmount)
+	assert.Equal(t, rupiah(206440), response.Data.AmountDue)
+	assert.Len(t, response.Data.LineItems, 6)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddBookingConcessions_OutOfStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	expectConcessionBooking(mock, 1)
+	mock.ExpectRollback()
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/concessions", withUser(1), AddBookingConcessions)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newAddConcessionsRequest())
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "Only 1 Popcorn Regular left")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateConcessionItem_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO concession_items").
+		WithArgs(1, "Nachos", "", "35000.00", 50, true).
+		WillReturnRows(sqlmock.NewRows(concessionItemRowColumns).
+			AddRow(7, 1, "Nachos", "", 35000.0, 50, true, now, now))
+
+	router := setupTestRouter()
+	router.POST("/theaters/:id/concessions", CreateConcessionItem)
+
+	body, _ := json.Marshal(models.ConcessionItemRequest{Name: "Nachos", Price: rupiah(35000), Stock: 50})
+	req, _ := http.NewRequest("POST", "/theaters/1/concessions", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response struct {
+		Data models.ConcessionItem `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, 50, response.Data.Stock)
+	assert.True(t, response.Data.IsActive)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTicketPDF godoc
+//
+//	@Summary		Download printable tickets
+//	@Description	Download a PDF with one page per seat including the signed QR code. Show times are printed in the theater's time zone.
+//	@Tags			bookings
+//	@Produce		application/pdf
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Booking ID"
+//	@Success		200	{file}		file			"Ticket PDF"
+//	@Failure		400	{object}	models.Response	"Booking is not paid"
+//	@Failure		401	{object}	models.Response	"Unauthorized"
+//	@Failure		404	{object}	models.Response	"Booking not found"
+//	@Failure		500	{object}	models.Response	"Internal server error"
+//	@Router			/bookings/{id}/ticket.pdf [get]
+func GetTicketPDF(c *gin.Context) {
+	doc, screeningID, ok := loadBookingDocument(c)
+	if !ok {
+		return
+	}
+
+	if doc.Status != models.BookingStatusPaid {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Tickets are only available for paid bookings", nil))
+		return
+	}
+
+	tickets, err := bookingTickets(doc.BookingID, screeningID, doc.PickupCode, doc.EndTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate tickets", err))
+		return
+	}
+
+	pdf, err := utils.RenderTicketPDF(doc, tickets, config.TheaterLocation(doc.Timezone))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to render PDF", err))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="ticket-%d.pdf"`, doc.BookingID))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// GetReceiptPDF godoc
+//
+//	@Summary		Download receipt
+//	@Description	Download a PDF receipt with the price breakdown of a paid or refunded booking. Show and payment times are printed in the theater's time zone.
+//	@Tags			bookings
+//	@Produce		application/pdf
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Booking ID"
+//	@Success		200	{file}		file			"Receipt PDF"
+//	@Failure		400	{object}	models.Response	"Booking is not paid"
+//	@Failure		401	{object}	models.Response	"Unauthorized"
+//	@Failure		404	{object}	models.Response	"Booking not found"
+//	@Failure		500	{object}	models.Response	"Internal server error"
+//	@Router			/bookings/{id}/receipt.pdf [get]
+func GetReceiptPDF(c *gin.Context) {
+	doc, _, ok := loadBookingDocument(c)
+	if !ok {
+		return
+	}
+
+	if doc.Status != models.BookingStatusPaid && doc.Status != models.BookingStatusRefunded {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Receipts are only available for paid bookings", nil))
+		return
+	}
+
+	pdf, err := utils.RenderReceiptPDF(doc, config.TheaterLocation(doc.Timezone))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to render PDF", err))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="receipt-%d.pdf"`, doc.BookingID))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// loadBookingDocument loads the booking in the :id path parameter for the
+// current user and writes the error response itself when it fails
+func loadBookingDocument(c *gin.Context) (models.BookingDocument, int, bool) {
+	var doc models.BookingDocument
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid booking ID", err))
+		return doc, 0, false
+	}
+
+	var (
+		userID      int
+		screeningID int
+	)
+	err = config.DB.QueryRow(`
+        SELECT b.id, b.user_id, b.screening_id, b.status, b.total_amount,
+               COALESCE(b.payment_reference, ''), COALESCE(b.pickup_code, ''), b.paid_at, u.full_name, u.email,
+               m.title, t.name, t.address, t.timezone, h.name, s.show_time, s.end_time
+        FROM bookings b
+        JOIN users u ON u.id = b.user_id
+        JOIN screenings s ON s.id = b.screening_id
+        JOIN movies m ON m.id = s.movie_id
+        JOIN theaters t ON t.id = s.theater_id
+        JOIN halls h ON h.id = s.hall_id
+        WHERE b.id = $1
+    `, id).Scan(
+		&doc.BookingID, &userID, &screeningID, &doc.Status, &doc.Total,
+		&doc.PaymentReference, &doc.PickupCode, &doc.PaidAt, &doc.CustomerName, &doc.CustomerEmail,
+		&doc.MovieTitle, &doc.TheaterName, &doc.TheaterAddress, &doc.Timezone, &doc.HallName, &doc.ShowTime, &doc.EndTime,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return doc, 0, false
+	}
+
+	if userID != c.GetInt("user_id") {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		return doc, 0, false
+	}
+
+	rows, err := config.DB.Query("SELECT seat_label FROM booking_seats WHERE booking_id = $1 ORDER BY seat_label", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return doc, 0, false
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seat string
+		if err := rows.Scan(&seat); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+			return doc, 0, false
+		}
+		doc.Seats = append(doc.Seats, seat)
+	}
+	rows.Close()
+
+	doc.LineItems, err = bookingLineItems(config.DB, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return doc, 0, false
+	}
+
+	return doc, screeningID, true
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectBookingDocument(mock sqlmock.Sqlmock, userID int, status string) {
+	showTime := time.Now().Add(24 * time.Hour)
+	paidAt := time.Now()
+
+	mock.ExpectQuery("SELECT b.id, b.user_id, b.screening_id, b.status, b.total_amount").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "screening_id", "status", "total_amount", "payment_reference", "pickup_code", "paid_at",
+			"full_name", "email", "title", "theater", "address", "timezone", "hall", "show_time", "end_time",
+		}).AddRow(
+			1, userID, 2, status, 150000.0, "PAY-1", "K7Q2MX", paidAt,
+			"Admin User", "admin@cinema.com", "The Batman", "CGV Pacific Place", "Jl. Jend. Sudirman", "Asia/Jakarta", "Studio 1",
+			showTime, showTime.Add(3*time.Hour),
+		))
+	mock.ExpectQuery("SELECT seat_label FROM booking_seats WHERE booking_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"seat_label"}).AddRow("B1").AddRow("B2"))
+	mock.ExpectQuery("SELECT kind, description, quantity, unit_price, amount FROM booking_line_items").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "description", "quantity", "unit_price", "amount"}).
+			AddRow(models.LineItemTicket, "Adult ticket (3D)", 2, 75000.0, 150000.0))
+}
+
+func TestGetTicketPDF_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	expectBookingDocument(mock, 1, models.BookingStatusPaid)
+	mock.ExpectQuery("SELECT id, seat_label FROM booking_seats").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seat_label"}).AddRow(10, "B1").AddRow(11, "B2"))
+
+	router := setupTestRouter()
+	router.GET("/bookings/:id/ticket.pdf", withUser(1), GetTicketPDF)
+
+	req, _ := http.NewRequest("GET", "/bookings/1/ticket.pdf", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF")))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetReceiptPDF_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	expectBookingDocument(mock, 1, models.BookingStatusRefunded)
+
+	router := setupTestRouter()
+	router.GET("/bookings/:id/receipt.pdf", withUser(1), GetReceiptPDF)
+
+	req, _ := http.NewRequest("GET", "/bookings/1/receipt.pdf", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF")))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetReceiptPDF_OtherUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("SELECT b.id, b.user_id, b.screening_id").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "screening_id", "status", "total_amount", "payment_reference", "pickup_code", "paid_at",
+			"full_name", "email", "title", "theater", "address", "timezone", "hall", "show_time", "end_time",
+		}).AddRow(
+			1, 2, 2, models.BookingStatusPaid, 150000.0, "PAY-1", "", nil,
+			"Other User", "other@cinema.com", "The Batman", "CGV Pacific Place", "Jl. Jend. Sudirman", "Asia/Jakarta", "Studio 1",
+			time.Now(), time.Now(),
+		))
+
+	router := setupTestRouter()
+	router.GET("/bookings/:id/receipt.pdf", withUser(1), GetReceiptPDF)
+
+	req, _ := http.NewRequest("GET", "/bookings/1/receipt.pdf", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
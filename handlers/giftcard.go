@@ -0,0 +1,565 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+const giftCardColumns = `
+        id, code, initial_balance, balance, COALESCE(recipient_email, ''),
+        COALESCE(TO_CHAR(expires_on, 'YYYY-MM-DD'), ''), is_active, COALESCE(issued_by, 0), created_at, updated_at`
+
+// IssueGiftCard godoc
+//
+//	@Summary		Issue gift card
+//	@Description	Issue a gift card with a generated code and record the opening balance in its ledger (Admin only)
+//	@Tags			gift-cards
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			giftCardRequest	body		models.IssueGiftCardRequest				true	"Gift card"
+//	@Success		201				{object}	models.Response{data=models.GiftCard}	"Gift card issued successfully"
+//	@Failure		400				{object}	models.Response							"Invalid request"
+//	@Failure		401				{object}	models.Response							"Unauthorized"
+//	@Failure		500				{object}	models.Response							"Internal server error"
+//	@Router			/gift-cards [post]
+func IssueGiftCard(c *gin.Context) {
+	var req models.IssueGiftCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	// A clash with an existing code is unlikely but would abort the
+	// transaction, so clashes are skipped with ON CONFLICT and retried
+	var card models.GiftCard
+	for attempt := 0; ; attempt++ {
+		code, err := utils.GenerateGiftCardCode()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate gift card code", err))
+			return
+		}
+
+		card, err = scanGiftCard(tx.QueryRow(`
+            INSERT INTO gift_cards (code, initial_balance, balance, recipient_email, expires_on, issued_by)
+            VALUES ($1, $2, $2, NULLIF($3, ''), NULLIF($4, '')::DATE, $5)
+            ON CONFLICT (code) DO NOTHING
+            RETURNING `+giftCardColumns, code, req.Amount, req.RecipientEmail, req.ExpiresOn, c.GetInt("user_id")))
+		if err == nil {
+			break
+		}
+		if err != sql.ErrNoRows || attempt == 4 {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to issue gift card", err))
+			return
+		}
+	}
+
+	entry, err := addGiftCardTransaction(tx, card.ID, nil, models.GiftCardIssue, req.Amount, card.Balance, "Issued")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to issue gift card", err))
+		return
+	}
+	card.Transactions = []models.GiftCardTransaction{entry}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to commit transaction", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse("Gift card issued successfully", card))
+}
+
+// GetGiftCards godoc
+//
+//	@Summary		List gift cards
+//	@Description	List all gift cards, newest first (Admin only)
+//	@Tags			gift-cards
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	models.Response{data=[]models.GiftCard}	"Gift cards fetched successfully"
+//	@Failure		401	{object}	models.Response							"Unauthorized"
+//	@Failure		500	{object}	models.Response							"Internal server error"
+//	@Router			/gift-cards [get]
+func GetGiftCards(c *gin.Context) {
+	rows, err := config.DB.Query("SELECT " + giftCardColumns + " FROM gift_cards ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer rows.Close()
+
+	cards := []models.GiftCard{}
+	for rows.Next() {
+		card, err := scanGiftCard(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+			return
+		}
+		cards = append(cards, card)
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Gift cards fetched successfully", cards))
+}
+
+// GetGiftCard godoc
+//
+//	@Summary		Get gift card
+//	@Description	Get a gift card with every debit and credit in its ledger (Admin only)
+//	@Tags			gift-cards
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int										true	"Gift card ID"
+//	@Success		200	{object}	models.Response{data=models.GiftCard}	"Gift card fetched successfully"
+//	@Failure		400	{object}	models.Response							"Invalid ID"
+//	@Failure		401	{object}	models.Response							"Unauthorized"
+//	@Failure		404	{object}	models.Response							"Gift card not found"
+//	@Failure		500	{object}	models.Response							"Internal server error"
+//	@Router			/gift-cards/{id} [get]
+func GetGiftCard(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid gift card ID", err))
+		return
+	}
+
+	card, err := scanGiftCard(config.DB.QueryRow("SELECT "+giftCardColumns+" FROM gift_cards WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Gift card not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	rows, err := config.DB.Query(`
+        SELECT id, booking_id, kind, amount, balance_after, COALESCE(note, ''), created_at
+        FROM gift_card_transactions
+        WHERE gift_card_id = $1
+        ORDER BY id
+    `, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer rows.Close()
+
+	card.Transactions = []models.GiftCardTransaction{}
+	for rows.Next() {
+		var (
+			entry     models.GiftCardTransaction
+			bookingID sql.NullInt64
+		)
+		if err := rows.Scan(&entry.ID, &bookingID, &entry.Kind, &entry.Amount, &entry.BalanceAfter, &entry.Note, &entry.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+			return
+		}
+		if bookingID.Valid {
+			id := int(bookingID.Int64)
+			entry.BookingID = &id
+		}
+		card.Transactions = append(card.Transactions, entry)
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Gift card fetched successfully", card))
+}
+
+// GetGiftCardBalance godoc
+//
+//	@Summary		Check gift card balance
+//	@Description	Look up the balance of a gift card by its code. The code is sent in the body so it stays out of access logs.
+//	@Tags			gift-cards
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			codeRequest	body		models.GiftCardCodeRequest						true	"Gift card code"
+//	@Success		200			{object}	models.Response{data=models.GiftCardBalance}	"Gift card balance fetched successfully"
+//	@Failure		400			{object}	models.Response								"Invalid request"
+//	@Failure		401			{object}	models.Response								"Unauthorized"
+//	@Failure		404			{object}	models.Response								"Gift card not found"
+//	@Failure		500			{object}	models.Response								"Internal server error"
+//	@Router			/gift-cards/balance [post]
+func GetGiftCardBalance(c *gin.Context) {
+	var req models.GiftCardCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	card, err := scanGiftCard(config.DB.QueryRow(
+		"SELECT "+giftCardColumns+" FROM gift_cards WHERE code = $1", utils.NormalizeGiftCardCode(req.Code),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Gift card not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Gift card balance fetched successfully", models.GiftCardBalance{
+		Code:      card.Code,
+		Balance:   card.Balance,
+		ExpiresOn: card.ExpiresOn,
+		IsActive:  card.IsActive && !giftCardExpired(card),
+	}))
+}
+
+// ApplyGiftCard godoc
+//
+//	@Summary		Pay with gift card
+//	@Description	Pay part or all of a pending booking of the current user from a gift card. Without an amount the card covers as much as it can; whatever is left is paid through the payment gateway. A booking the cards cover in full is paid at once.
+//	@Tags			bookings
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id					path		int												true	"Booking ID"
+//	@Param			giftCardRequest		body		models.ApplyGiftCardRequest						true	"Gift card"
+//	@Success		200					{object}	models.Response{data=models.AppliedGiftCard}	"Gift card applied successfully"
+//	@Failure		400					{object}	models.Response									"Gift card cannot be used"
+//	@Failure		401					{object}	models.Response									"Unauthorized"
+//	@Failure		404					{object}	models.Response									"Booking or gift card not found"
+//	@Failure		409					{object}	models.Response									"Booking is already paid"
+//	@Failure		500					{object}	models.Response									"Internal server error"
+//	@Router			/bookings/{id}/apply-gift-card [post]
+func ApplyGiftCard(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid booking ID", err))
+		return
+	}
+
+	var req models.ApplyGiftCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	var (
+		userID         int
+		status         string
+		totalAmount    models.Money
+		giftCardAmount models.Money
+	)
+	err = tx.QueryRow(`
+        SELECT user_id, status, total_amount, gift_card_amount FROM bookings WHERE id = $1 FOR UPDATE
+    `, id).Scan(&userID, &status, &totalAmount, &giftCardAmount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	if userID != c.GetInt("user_id") {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		return
+	}
+
+	if status != models.BookingStatusPending {
+		c.JSON(http.StatusConflict, models.ErrorResponse("Gift cards can only be used for pending bookings", nil))
+		return
+	}
+
+	due := totalAmount.Sub(giftCardAmount)
+	if due.Amount <= 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse("Booking is already fully paid", nil))
+		return
+	}
+
+	// Locking the card keeps two bookings from spending the same balance
+	card, err := scanGiftCard(tx.QueryRow(
+		"SELECT "+giftCardColumns+" FROM gift_cards WHERE code = $1 FOR UPDATE", utils.NormalizeGiftCardCode(req.Code),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Gift card not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	if !card.IsActive || giftCardExpired(card) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Gift card is no longer valid", nil))
+		return
+	}
+
+	amount := card.Balance
+	if req.Amount != nil {
+		if req.Amount.Amount > card.Balance.Amount {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Gift card balance is too low", nil))
+			return
+		}
+		amount = *req.Amount
+	}
+	if amount.Amount > due.Amount {
+		amount = due
+	}
+	if amount.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Gift card has no balance left", nil))
+		return
+	}
+
+	card.Balance = card.Balance.Sub(amount)
+	_, err = tx.Exec("UPDATE gift_cards SET balance = $1, updated_at = NOW() WHERE id = $2", card.Balance, card.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to redeem gift card", err))
+		return
+	}
+
+	note := fmt.Sprintf("Booking #%d", id)
+	if _, err := addGiftCardTransaction(tx, card.ID, &id, models.GiftCardRedeem, amount.Neg(), card.Balance, note); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to redeem gift card", err))
+		return
+	}
+
+	applied := models.AppliedGiftCard{
+		BookingID:      id,
+		Code:           card.Code,
+		Amount:         amount,
+		CardBalance:    card.Balance,
+		GiftCardAmount: giftCardAmount.Add(amount),
+		AmountDue:      due.Sub(amount),
+		Status:         status,
+	}
+
+	// Nothing is left for the payment gateway to collect
+	if applied.AmountDue.IsZero() {
+		applied.Status = models.BookingStatusPaid
+		_, err = tx.Exec(`
+            UPDATE bookings SET gift_card_amount = $1, status = $2, paid_at = NOW(), updated_at = NOW()
+            WHERE id = $3
+        `, applied.GiftCardAmount, applied.Status, id)
+		if err == nil {
+			err = earnLoyaltyPoints(tx, id)
+		}
+	} else {
+		_, err = tx.Exec(`
+            UPDATE bookings SET gift_card_amount = $1, updated_at = NOW() WHERE id = $2
+        `, applied.GiftCardAmount, id)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update booking", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to commit transaction", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Gift card applied successfully", applied))
+}
+
+// returnGiftCardValue credits what a booking took from gift cards back to
+// them, up to limit, starting with the card used first. It returns the
+// amount credited; the rest of a refund goes through the payment gateway.
+func returnGiftCardValue(tx *sql.Tx, bookingID int, limit models.Money, note string) (models.Money, error) {
+	returned := models.Money{Currency: limit.Currency}
+
+	rows, err := tx.Query(`
+        SELECT gift_card_id, -SUM(amount)
+        FROM gift_card_transactions
+        WHERE booking_id = $1
+        GROUP BY gift_card_id
+        HAVING SUM(amount) < 0
+        ORDER BY MIN(id)
+    `, bookingID)
+	if err != nil {
+		return returned, err
+	}
+
+	type redemption struct {
+		cardID int
+		amount models.Money
+	}
+	var redeemed []redemption
+	for rows.Next() {
+		var r redemption
+		if err := rows.Scan(&r.cardID, &r.amount); err != nil {
+			rows.Close()
+			return returned, err
+		}
+		redeemed = append(redeemed, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return returned, err
+	}
+
+	for _, r := range redeemed {
+		credit := r.amount
+		if remaining := limit.Sub(returned); credit.Amount > remaining.Amount {
+			credit = remaining
+		}
+		if credit.Amount <= 0 {
+			break
+		}
+
+		var balance models.Money
+		err := tx.QueryRow(`
+            UPDATE gift_cards SET balance = balance + $1, updated_at = NOW()
+            WHERE id = $2
+            RETURNING balance
+        `, credit, r.cardID).Scan(&balance)
+		if err != nil {
+			return returned, err
+		}
+
+		if _, err := addGiftCardTransaction(tx, r.cardID, &bookingID, models.GiftCardRefund, credit, balance, note); err != nil {
+			return returned, err
+		}
+		returned = returned.Add(credit)
+	}
+
+	return returned, nil
+}
+
+// retakeGiftCardValue debits the gift cards of a failed booking again for
+// what was returned to them when its payment failed. Each card is owed what
+// the booking redeemed from it before its first return. It reports false,
+// without debiting anything, when a card no longer has the balance.
+func retakeGiftCardValue(tx *sql.Tx, bookingID int, note string) (bool, error) {
+	rows, err := tx.Query(`
+        SELECT t.gift_card_id,
+               SUM(t.amount) - COALESCE(SUM(t.amount) FILTER (
+                   WHERE t.kind = 'redeem' AND (r.first_refund IS NULL OR t.id < r.first_refund)
+               ), 0)
+        FROM gift_card_transactions t CROSS JOIN (
+            SELECT MIN(id) AS first_refund FROM gift_card_transactions WHERE booking_id = $1 AND kind = 'refund'
+        ) r
+        WHERE t.booking_id = $1
+        GROUP BY t.gift_card_id
+        ORDER BY t.gift_card_id
+    `, bookingID)
+	if err != nil {
+		return false, err
+	}
+
+	owed := map[int]models.Money{}
+	var cardIDs []int64
+	for rows.Next() {
+		var (
+			cardID int
+			amount models.Money
+		)
+		if err := rows.Scan(&cardID, &amount); err != nil {
+			rows.Close()
+			return false, err
+		}
+		if amount.Amount > 0 {
+			owed[cardID] = amount
+			cardIDs = append(cardIDs, int64(cardID))
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	if len(cardIDs) == 0 {
+		return true, nil
+	}
+
+	// Locking the cards in id order keeps bookings from spending the same
+	// balance and from deadlocking on each other
+	rows, err = tx.Query(`
+        SELECT id, balance FROM gift_cards WHERE id = ANY($1) ORDER BY id FOR UPDATE
+    `, pq.Array(cardIDs))
+	if err != nil {
+		return false, err
+	}
+
+	balances := map[int]models.Money{}
+	for rows.Next() {
+		var (
+			cardID  int
+			balance models.Money
+		)
+		if err := rows.Scan(&cardID, &balance); err != nil {
+			rows.Close()
+			return false, err
+		}
+		balances[cardID] = balance
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	for _, id := range cardIDs {
+		if balances[int(id)].Amount < owed[int(id)].Amount {
+			return false, nil
+		}
+	}
+
+	for _, id := range cardIDs {
+		cardID := int(id)
+		balance := balances[cardID].Sub(owed[cardID])
+		if _, err := tx.Exec("UPDATE gift_cards SET balance = $1, updated_at = NOW() WHERE id = $2", balance, cardID); err != nil {
+			return false, err
+		}
+		if _, err := addGiftCardTransaction(tx, cardID, &bookingID, models.GiftCardRedeem, owed[cardID].Neg(), balance, note); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func addGiftCardTransaction(tx *sql.Tx, cardID int, bookingID *int, kind string, amount, balanceAfter models.Money, note string) (models.GiftCardTransaction, error) {
+	entry := models.GiftCardTransaction{
+		BookingID:    bookingID,
+		Kind:         kind,
+		Amount:       amount,
+		BalanceAfter: balanceAfter,
+		Note:         note,
+	}
+	err := tx.QueryRow(`
+        INSERT INTO gift_card_transactions (gift_card_id, booking_id, kind, amount, balance_after, note)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `, cardID, bookingID, kind, amount, balanceAfter, note).Scan(&entry.ID, &entry.CreatedAt)
+	return entry, err
+}
+
+func scanGiftCard(row rowScanner) (models.GiftCard, error) {
+	var card models.GiftCard
+	err := row.Scan(
+		&card.ID, &card.Code, &card.InitialBalance, &card.Balance, &card.RecipientEmail, &card.ExpiresOn,
+		&card.IsActive, &card.IssuedBy, &card.CreatedAt, &card.UpdatedAt,
+	)
+	return card, err
+}
+
+// giftCardExpired reports whether the card's last day has passed in the
+// cinema's time zone
+func giftCardExpired(card models.GiftCard) bool {
+	return card.ExpiresOn != "" && time.Now().In(config.Location()).Format("2006-01-02") > card.ExpiresOn
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var giftCardRowColumns = []string{
+	"id", "code", "initial_balance", "balance", "recipient_email", "expires_on", "is_active", "issued_by",
+	"created_at", "updated_at",
+}
+
+// expectGiftCardPayment expects booking 5 of user 1, pending with 100.000 to
+// pay, and gift card 2 locked with balance left on it
+func expectGiftCardPayment(mock sqlmock.Sqlmock, balance float64) {
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, status, total_amount, gift_card_amount FROM bookings").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "status", "total_amount", "gift_card_amount"}).
+			AddRow(1, models.BookingStatusPending, 100000.0, 0.0))
+	mock.ExpectQuery("FROM gift_cards WHERE code = \\$1 FOR UPDATE").
+		WithArgs("GC7K-Q2MX-9TRD-4HWP").
+		WillReturnRows(sqlmock.NewRows(giftCardRowColumns).
+			AddRow(2, "GC7K-Q2MX-9TRD-4HWP", 200000.0, balance, "", "", true, 1, now, now))
+}
+
+func newApplyGiftCardRequest(code string) *http.Request {
+	body, _ := json.Marshal(models.ApplyGiftCardRequest{Code: code})
+	req, _ := http.NewRequest("POST", "/bookings/5/apply-gift-card", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestIssueGiftCard_RetriesCodeClash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO gift_cards").
+		WithArgs(sqlmock.AnyArg(), "250000.00", "friend@example.com", "", 1).
+		WillReturnRows(sqlmock.NewRows(giftCardRowColumns))
+	mock.ExpectQuery("INSERT INTO gift_cards").
+		WithArgs(sqlmock.AnyArg(), "250000.00", "friend@example.com", "", 1).
+		WillReturnRows(sqlmock.NewRows(giftCardRowColumns).
+			AddRow(2, "GC7K-Q2MX-9TRD-4HWP", 250000.0, 250000.0, "friend@example.com", "", true, 1, now, now))
+	mock.ExpectQuery("INSERT INTO gift_card_transactions").
+		WithArgs(2, nil, models.GiftCardIssue, "250000.00", "250000.00", "Issued").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, now))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/gift-cards", withUser(1), IssueGiftCard)
+
+	body, _ := json.Marshal(models.IssueGiftCardRequest{Amount: rupiah(250000), RecipientEmail: "friend@example.com"})
+	req, _ := http.NewRequest("POST", "/gift-cards", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response struct {
+		Data models.GiftCard `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, rupiah(250000), response.Data.Balance)
+	assert.Len(t, response.Data.Transactions, 1)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestApplyGiftCard_PartialPayment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	expectGiftCardPayment(mock, 60000)
+	mock.ExpectExec("UPDATE gift_cards SET balance = \\$1").
+		WithArgs("0.00", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO gift_card_transactions").
+		WithArgs(2, 5, models.GiftCardRedeem, "-60000.00", "0.00", "Booking #5").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, now))
+	mock.ExpectExec("UPDATE bookings SET gift_card_amount = \\$1, updated_at = NOW\\(\\) WHERE id = \\$2").
+		WithArgs("60000.00", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/apply-gift-card", withUser(1), ApplyGiftCard)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newApplyGiftCardRequest("gc7kq2mx9trd4hwp"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.AppliedGiftCard `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, rupiah(60000), response.Data.Amount)
+	assert.Equal(t, rupiah(40000), response.Data.AmountDue)
+	assert.Equal(t, models.BookingStatusPending, response.Data.Status)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestApplyGiftCard_RejectsOtherCurrency(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/bookings/:id/apply-gift-card", withUser(1), ApplyGiftCard)
+
+	body := `{"code": "GC-ABCD-EFGH-JKLM", "amount": {"amount": "1", "currency": "USD"}}`
+	req, _ := http.NewRequest("POST", "/bookings/5/apply-gift-card", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestApplyGiftCard_CoversBooking(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	expectGiftCardPayment(mock, 150000)
+	mock.ExpectExec("UPDATE gift_cards SET balance = \\$1").
+		WithArgs("50000.00", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO gift_card_transactions").
+		WithArgs(2, 5, models.GiftCardRedeem, "-100000.00", "50000.00", "Booking #5").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, now))
+	mock.ExpectExec("UPDATE bookings SET gift_card_amount = \\$1, status = \\$2, paid_at = NOW\\(\\)").
+		WithArgs("100000.00", models.BookingStatusPaid, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT user_id, total_amount FROM bookings").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "total_amount"}).AddRow(1, 100000.0))
+	mock.ExpectExec("INSERT INTO loyalty_transactions").
+		WithArgs(1, 5, models.LoyaltyEarn, 10, 365, "Booking #5").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/apply-gift-card", withUser(1), ApplyGiftCard)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newApplyGiftCardRequest("GC7K-Q2MX-9TRD-4HWP"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.AppliedGiftCard `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, rupiah(50000), response.Data.CardBalance)
+	assert.True(t, response.Data.AmountDue.IsZero())
+	assert.Equal(t, models.BookingStatusPaid, response.Data.Status)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRefundBooking_ReturnsGiftCardValueFirst(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+	gateway := &fakeGateway{refundID: "RF-1"}
+	utils.Gateway = gateway
+
+	now := time.Now()
+	expectRefundBooking(mock, 30)
+	mock.ExpectQuery("INSERT INTO refunds").
+		WithArgs(1, "75000.00", 75, "", models.RefundStatusPending, "PAY-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, now))
+	mock.ExpectQuery("INSERT INTO refund_status_history").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+	mock.ExpectExec("UPDATE bookings SET status = \\$1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE screenings").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRestockConcessions(mock, "{1}", 1)
+	expectLoyaltyPoints(mock, 1, 0, 0)
+	expectGiftCardRedemptions(mock, 1, sqlmock.NewRows([]string{"gift_card_id", "amount"}).AddRow(2, 60000.0))
+	mock.ExpectQuery("UPDATE gift_cards SET balance = balance \\+ \\$1").
+		WithArgs("60000.00", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(60000.0))
+	mock.ExpectQuery("INSERT INTO gift_card_transactions").
+		WithArgs(2, 1, models.GiftCardRefund, "60000.00", "60000.00", "Refund of booking #1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(4, now))
+	expectSettleRefund(mock, 1, models.RefundStatusPending, "60000.00")
+	mock.ExpectCommit()
+	expectFinishRefund(mock, 1, models.RefundStatusCompleted, "RF-1", "Refunded to gift card and by payment gateway")
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/refund", withUser(1), RefundBooking)
+
+	req, _ := http.NewRequest("POST", "/bookings/1/refund", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, gateway.calls)
+	assert.Equal(t, rupiah(15000), gateway.amount)
+
+	var response struct {
+		Data models.Refund `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, rupiah(60000), response.Data.GiftCardAmount)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
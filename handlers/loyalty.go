@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLoyalty godoc
+//
+//	@Summary		Get loyalty points
+//	@Description	Get the current user's loyalty points balance, the points expiring within 30 days and every credit and debit, newest first
+//	@Tags			loyalty
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	models.Response{data=models.LoyaltyAccount}	"Loyalty points fetched successfully"
+//	@Failure		401	{object}	models.Response								"Unauthorized"
+//	@Failure		500	{object}	models.Response								"Internal server error"
+//	@Router			/me/loyalty [get]
+func GetLoyalty(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var (
+		account models.LoyaltyAccount
+		err     error
+	)
+	account.Points, account.ExpiringSoon, err = loyaltyBalance(config.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	account.Value = config.LoadLoyaltyRates().PointsValue(account.Points)
+
+	rows, err := config.DB.Query(`
+        SELECT id, booking_id, kind, points, expires_at, COALESCE(note, ''), created_at
+        FROM loyalty_transactions
+        WHERE user_id = $1
+        ORDER BY created_at DESC, id DESC
+    `, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer rows.Close()
+
+	account.History = []models.LoyaltyTransaction{}
+	for rows.Next() {
+		var entry models.LoyaltyTransaction
+		err := rows.Scan(&entry.ID, &entry.BookingID, &entry.Kind, &entry.Points, &entry.ExpiresAt, &entry.Note, &entry.CreatedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Error scanning loyalty transaction", err))
+			return
+		}
+		account.History = append(account.History, entry)
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Loyalty points fetched successfully", account))
+}
+
+// RedeemLoyaltyPoints godoc
+//
+//	@Summary		Redeem loyalty points
+//	@Description	Spend loyalty points of the current user as a discount on one of their pending bookings. Points are taken from the lots expiring first, and no more are spent than the tickets are worth. Points are redeemed after any promo code and before gift cards.
+//	@Tags			bookings
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		int											true	"Booking ID"
+//	@Param			pointsRequest	body		models.RedeemPointsRequest					true	"Points to redeem"
+//	@Success		200				{object}	models.Response{data=models.RedeemedPoints}	"Loyalty points redeemed successfully"
+//	@Failure		400				{object}	models.Response								"Points cannot be redeemed"
+//	@Failure		401				{object}	models.Response								"Unauthorized"
+//	@Failure		404				{object}	models.Response								"Booking not found"
+//	@Failure		409				{object}	models.Response								"Points already redeemed or after a gift card"
+//	@Failure		500				{object}	models.Response								"Internal server error"
+//	@Router			/bookings/{id}/redeem-points [post]
+func RedeemLoyaltyPoints(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid booking ID", err))
+		return
+	}
+
+	var req models.RedeemPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	var (
+		userID         int
+		status         string
+		giftCardAmount models.Money
+		loyaltyPoints  int
+		theaterID      int
+	)
+	err = tx.QueryRow(`
+        SELECT b.user_id, b.status, b.gift_card_amount, b.loyalty_points, s.theater_id
+        FROM bookings b JOIN screenings s ON s.id = b.screening_id
+        WHERE b.id = $1
+        FOR UPDATE OF b
+    `, id).Scan(&userID, &status, &giftCardAmount, &loyaltyPoints, &theaterID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	if userID != c.GetInt("user_id") {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		return
+	}
+
+	if status != models.BookingStatusPending {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Loyalty points can only be redeemed on pending bookings", nil))
+		return
+	}
+
+	// The discount could leave less to pay than the gift cards already cover
+	if giftCardAmount.Amount > 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse("Loyalty points must be redeemed before gift cards", nil))
+		return
+	}
+
+	if loyaltyPoints > 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse("Loyalty points have already been redeemed on this booking", nil))
+		return
+	}
+
+	items, err := bookingLineItems(tx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	rates := config.LoadLoyaltyRates()
+	points := rates.RedeemablePoints(req.Points, utils.PromoSubtotal(items))
+	if points == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Booking is too small to redeem loyalty points", nil))
+		return
+	}
+
+	spent, err := spendLoyaltyPoints(tx, userID, points, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to redeem loyalty points", err))
+		return
+	}
+	if spent < points {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse(fmt.Sprintf("Not enough loyalty points, %d available", spent), nil))
+		return
+	}
+
+	note := fmt.Sprintf("Booking #%d", id)
+	if err := addLoyaltyTransaction(tx, userID, &id, models.LoyaltyRedeem, -points, 0, note); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to redeem loyalty points", err))
+		return
+	}
+
+	charges, err := theaterCharges(tx, theaterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to load theater charges", err))
+		return
+	}
+
+	discount := rates.PointsValue(points)
+	lines := utils.DiscountLineItems(items, fmt.Sprintf("Loyalty points (%d)", points), discount, charges)
+	if err := insertLineItems(tx, id, lines); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to redeem loyalty points", err))
+		return
+	}
+	items = append(items, lines...)
+
+	redeemed := models.RedeemedPoints{
+		BookingID:   id,
+		Points:      points,
+		Discount:    discount,
+		TotalAmount: utils.LineItemsTotal(items),
+		LineItems:   items,
+	}
+
+	_, err = tx.Exec(`
+        UPDATE bookings SET total_amount = $1, loyalty_points = $2, updated_at = NOW() WHERE id = $3
+    `, redeemed.TotalAmount, points, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update booking", err))
+		return
+	}
+
+	redeemed.Balance, _, err = loyaltyBalance(tx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to commit transaction", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Loyalty points redeemed successfully", redeemed))
+}
+
+// StartLoyaltyExpiry writes off expired loyalty points now and then every
+// interval in the background
+func StartLoyaltyExpiry(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			users, err := expireLoyaltyPoints()
+			if err != nil {
+				log.Printf("Failed to expire loyalty points: %v", err)
+			} else if users > 0 {
+				log.Printf("Expired loyalty points of %d users", users)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// expireLoyaltyPoints closes every lot past its expiry and records one expire
+// entry per user for what was left of them. It returns the number of users
+// who lost points.
+func expireLoyaltyPoints() (int64, error) {
+	// Locking the lots makes a concurrent redemption either finish first, so
+	// the lot's new remaining is what expires, or wait and find it closed
+	result, err := config.DB.Exec(`
+        WITH expired AS (
+            UPDATE loyalty_transactions t SET remaining = 0
+            FROM (
+                SELECT id, remaining FROM loyalty_transactions
+                WHERE remaining > 0 AND expires_at <= NOW()
+                FOR UPDATE
+            ) lot
+            WHERE t.id = lot.id
+            RETURNING t.user_id, lot.remaining
+        )
+        INSERT INTO loyalty_transactions (user_id, kind, points, note)
+        SELECT user_id, $1, -SUM(remaining), 'Points expired'
+        FROM expired
+        GROUP BY user_id
+    `, models.LoyaltyExpire)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// earnLoyaltyPoints credits the points a booking earns once it is paid
+func earnLoyaltyPoints(tx *sql.Tx, bookingID int) error {
+	var (
+		userID      int
+		totalAmount models.Money
+	)
+	err := tx.QueryRow("SELECT user_id, total_amount FROM bookings WHERE id = $1", bookingID).Scan(&userID, &totalAmount)
+	if err != nil {
+		return err
+	}
+
+	rates := config.LoadLoyaltyRates()
+	points := rates.PointsEarned(totalAmount)
+	if points == 0 {
+		return nil
+	}
+
+	return addLoyaltyTransaction(tx, userID, &bookingID, models.LoyaltyEarn, points, rates.ExpiryDays, fmt.Sprintf("Booking #%d", bookingID))
+}
+
+// reverseLoyaltyPoints undoes the points of a booking that is refunded or
+// cancelled: the points it earned are taken back, as far as they haven't
+// been spent already, and percentage of the points redeemed on it are given
+// back as a new lot
+func reverseLoyaltyPoints(tx *sql.Tx, bookingID, percentage int, note string) error {
+	var userID, redeemed, earned int
+	err := tx.QueryRow(`
+        SELECT b.user_id, b.loyalty_points,
+               COALESCE((SELECT points FROM loyalty_transactions WHERE booking_id = b.id AND kind = 'earn'), 0)
+        FROM bookings b
+        WHERE b.id = $1
+    `, bookingID).Scan(&userID, &redeemed, &earned)
+	if err != nil {
+		return err
+	}
+
+	if earned > 0 {
+		taken, err := spendLoyaltyPoints(tx, userID, earned, bookingID)
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			if err := addLoyaltyTransaction(tx, userID, &bookingID, models.LoyaltyReversal, -taken, 0, note); err != nil {
+				return err
+			}
+		}
+	}
+
+	if restored := redeemed * percentage / 100; restored > 0 {
+		expiryDays := config.LoadLoyaltyRates().ExpiryDays
+		if err := addLoyaltyTransaction(tx, userID, &bookingID, models.LoyaltyReversal, restored, expiryDays, note); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// retakeLoyaltyPoints redeems the points of a failed booking again after they
+// were given back when its payment failed. It reports false, without taking
+// anything, when the customer no longer has enough points.
+func retakeLoyaltyPoints(tx *sql.Tx, bookingID int, note string) (bool, error) {
+	var userID, owed int
+	err := tx.QueryRow(`
+        SELECT b.user_id,
+               b.loyalty_points + COALESCE((
+                   SELECT SUM(points) FROM loyalty_transactions
+                   WHERE booking_id = b.id AND (kind = 'redeem' OR (kind = 'reversal' AND points > 0))
+               ), 0)
+        FROM bookings b
+        WHERE b.id = $1
+    `, bookingID).Scan(&userID, &owed)
+	if err != nil {
+		return false, err
+	}
+	if owed <= 0 {
+		return true, nil
+	}
+
+	balance, _, err := loyaltyBalance(tx, userID)
+	if err != nil {
+		return false, err
+	}
+	if balance < owed {
+		return false, nil
+	}
+
+	spent, err := spendLoyaltyPoints(tx, userID, owed, bookingID)
+	if err != nil {
+		return false, err
+	}
+	if spent < owed {
+		return false, fmt.Errorf("only %d of %d loyalty points left", spent, owed)
+	}
+
+	return true, addLoyaltyTransaction(tx, userID, &bookingID, models.LoyaltyRedeem, -owed, 0, note)
+}
+
+// spendLoyaltyPoints takes up to points from the user's unexpired lots, those
+// earned by bookingID first and then the ones expiring soonest. It returns
+// how many points it took; the caller records the debit.
+func spendLoyaltyPoints(tx *sql.Tx, userID, points, bookingID int) (int, error) {
+	rows, err := tx.Query(`
+        SELECT id, remaining FROM loyalty_transactions
+        WHERE user_id = $1 AND remaining > 0 AND expires_at > NOW()
+        ORDER BY booking_id = $2 DESC NULLS LAST, expires_at, id
+        FOR UPDATE
+    `, userID, bookingID)
+	if err != nil {
+		return 0, err
+	}
+
+	type lot struct {
+		id        int
+		remaining int
+	}
+	var lots []lot
+	for rows.Next() {
+		var l lot
+		if err := rows.Scan(&l.id, &l.remaining); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	taken := 0
+	for _, l := range lots {
+		take := l.remaining
+		if left := points - taken; take > left {
+			take = left
+		}
+		if take <= 0 {
+			break
+		}
+
+		if _, err := tx.Exec("UPDATE loyalty_transactions SET remaining = remaining - $1 WHERE id = $2", take, l.id); err != nil {
+			return taken, err
+		}
+		taken += take
+	}
+
+	return taken, nil
+}
+
+// addLoyaltyTransaction records a ledger entry. Credits open a lot valid for
+// expiryDays; debits pass 0.
+func addLoyaltyTransaction(tx *sql.Tx, userID int, bookingID *int, kind string, points, expiryDays int, note string) error {
+	_, err := tx.Exec(`
+        INSERT INTO loyalty_transactions (user_id, booking_id, kind, points, remaining, expires_at, note)
+        VALUES ($1, $2, $3, $4, GREATEST($4, 0), NOW() + NULLIF($5::INTEGER, 0) * INTERVAL '1 day', $6)
+    `, userID, bookingID, kind, points, expiryDays, note)
+	return err
+}
+
+// loyaltyBalance returns the unexpired points of a user and how many of them
+// expire within 30 days
+func loyaltyBalance(q queryer, userID int) (int, int, error) {
+	var points, expiringSoon int
+	err := q.QueryRow(`
+        SELECT COALESCE(SUM(remaining), 0),
+               COALESCE(SUM(remaining) FILTER (WHERE expires_at <= NOW() + INTERVAL '30 days'), 0)
+        FROM loyalty_transactions
+        WHERE user_id = $1 AND remaining > 0 AND expires_at > NOW()
+    `, userID).Scan(&points, &expiringSoon)
+	return points, expiringSoon, err
+}
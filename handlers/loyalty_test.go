@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectPointsBooking expects booking 5 of user 1, pending with no points or
+// gift cards used yet, and its line items
+func expectPointsBooking(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT b.user_id, b.status, b.gift_card_amount, b.loyalty_points, s.theater_id").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "status", "gift_card_amount", "loyalty_points", "theater_id"}).
+			AddRow(1, models.BookingStatusPending, 0.0, 0, 1))
+	mock.ExpectQuery("SELECT kind, description, quantity, unit_price, amount FROM booking_line_items").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "description", "quantity", "unit_price", "amount"}).
+			AddRow(models.LineItemTicket, "Adult ticket", 2, 45500.0, 91000.0).
+			AddRow(models.LineItemFee, "Convenience fee", 2, 4000.0, 8000.0).
+			AddRow(models.LineItemTax, "PPN 11%", 1, 10890.0, 10890.0))
+}
+
+func newRedeemPointsRequest(points int) *http.Request {
+	body, _ := json.Marshal(models.RedeemPointsRequest{Points: points})
+	req, _ := http.NewRequest("POST", "/bookings/5/redeem-points", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestRedeemLoyaltyPoints_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	expectPointsBooking(mock)
+	mock.ExpectQuery("SELECT id, remaining FROM loyalty_transactions").
+		WithArgs(1, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "remaining"}).AddRow(3, 30).AddRow(4, 100))
+	mock.ExpectExec("UPDATE loyalty_transactions SET remaining = remaining - \\$1").
+		WithArgs(30, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE loyalty_transactions SET remaining = remaining - \\$1").
+		WithArgs(20, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO loyalty_transactions").
+		WithArgs(1, 5, models.LoyaltyRedeem, -50, 0, "Booking #5").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectQuery("SELECT ppn_rate, convenience_fee, fee_taxable FROM theater_charges").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ppn_rate", "convenience_fee", "fee_taxable"}).AddRow(11.0, 4000.0, true))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemDiscount, "Loyalty points (50)", 1, "-5000.00", "-5000.00").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemTax, "PPN 11% adjustment", 1, "-550.00", "-550.00").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec("UPDATE bookings SET total_amount = \\$1, loyalty_points = \\$2").
+		WithArgs("104340.00", 50, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(remaining\\), 0\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"points", "expiring_soon"}).AddRow(80, 0))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/redeem-points", withUser(1), RedeemLoyaltyPoints)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRedeemPointsRequest(50))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.RedeemedPoints `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, rupiah(5000), response.Data.Discount)
+	assert.Equal(t, rupiah(104340), response.Data.TotalAmount)
+	assert.Equal(t, 80, response.Data.Balance)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRedeemLoyaltyPoints_NotEnoughPoints(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	expectPointsBooking(mock)
+	mock.ExpectQuery("SELECT id, remaining FROM loyalty_transactions").
+		WithArgs(1, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "remaining"}).AddRow(3, 30))
+	mock.ExpectExec("UPDATE loyalty_transactions SET remaining = remaining - \\$1").
+		WithArgs(30, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/redeem-points", withUser(1), RedeemLoyaltyPoints)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRedeemPointsRequest(50))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetLoyalty_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(remaining\\), 0\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"points", "expiring_soon"}).AddRow(120, 20))
+	mock.ExpectQuery("FROM loyalty_transactions").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "booking_id", "kind", "points", "expires_at", "note", "created_at"}).
+			AddRow(2, nil, models.LoyaltyExpire, -20, nil, "Points expired", now).
+			AddRow(1, 5, models.LoyaltyEarn, 140, now.AddDate(1, 0, 0), "Booking #5", now))
+
+	router := setupTestRouter()
+	router.GET("/me/loyalty", withUser(1), GetLoyalty)
+
+	req, _ := http.NewRequest("GET", "/me/loyalty", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.LoyaltyAccount `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, 120, response.Data.Points)
+	assert.Equal(t, rupiah(12000), response.Data.Value)
+	assert.Len(t, response.Data.History, 2)
+	assert.Nil(t, response.Data.History[0].BookingID)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
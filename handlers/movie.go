@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// Movie columns of a query where movies are aliased m
+const movieColumns = `
+        m.id, m.title, COALESCE(m.description, ''), m.duration, COALESCE(m.genre, '{}'),
+        COALESCE(m.rating, ''), COALESCE(m.director, ''), COALESCE(m."cast", '{}'),
+        COALESCE(TO_CHAR(m.release_date, 'YYYY-MM-DD'), ''), COALESCE(TO_CHAR(m.end_date, 'YYYY-MM-DD'), '')`
+
+// Movie search returns 20 results unless limit asks otherwise
+const (
+	defaultMovieSearchLimit = 20
+	maxMovieSearchLimit     = 50
+)
+
+// movieDest returns the scan destinations of movieColumns
+func movieDest(m *models.Movie) []interface{} {
+	return []interface{}{
+		&m.ID, &m.Title, &m.Description, &m.Duration, (*pq.StringArray)(&m.Genres),
+		&m.Rating, &m.Director, (*pq.StringArray)(&m.Cast), &m.ReleaseDate, &m.EndDate,
+	}
+}
+
+// SearchMovies godoc
+//
+//	@Summary		Search movies
+//	@Description	Full-text search over title, description, director, cast and genre, ignoring accents. Titles also match with small typos. Best matches come first. No login needed.
+//	@Tags			movies
+//	@Produce		json
+//	@Param			q		query		string											true	"Search terms"
+//	@Param			limit	query		int												false	"Maximum results (1-50)"	default(20)
+//	@Success		200		{object}	models.Response{data=[]models.MovieSearchResult}	"Movies fetched successfully"
+//	@Failure		400		{object}	models.Response									"Invalid query parameters"
+//	@Failure		500		{object}	models.Response									"Internal server error"
+//	@Router			/movies/search [get]
+func SearchMovies(c *gin.Context) {
+	term := strings.TrimSpace(c.Query("q"))
+	if term == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Search terms are required", nil))
+		return
+	}
+
+	limit := defaultMovieSearchLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxMovieSearchLimit {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Limit must be between 1 and 50", err))
+			return
+		}
+	}
+
+	// A movie matches on its full-text document or, for misspelt titles, on
+	// trigram word similarity. Both scores add up so exact hits rank first.
+	rows, err := config.DB.Query(`
+        WITH search AS (
+            SELECT websearch_to_tsquery('simple', immutable_unaccent($1)) AS query,
+                   immutable_unaccent(LOWER($1)) AS term
+        ), scored AS (
+            SELECT m.*,
+                   ts_rank(movie_search_document(m.title, m.description, m.director, m."cast", m.genre), search.query) +
+                   word_similarity(search.term, immutable_unaccent(LOWER(m.title))) AS score
+            FROM movies m, search
+            WHERE movie_search_document(m.title, m.description, m.director, m."cast", m.genre) @@ search.query
+               OR search.term <% immutable_unaccent(LOWER(m.title))
+        )
+        SELECT `+movieColumns+`, m.score
+        FROM scored m
+        ORDER BY m.score DESC, m.title, m.id
+        LIMIT $2
+    `, term, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to search movies", err))
+		return
+	}
+	defer rows.Close()
+
+	results := []models.MovieSearchResult{}
+	for rows.Next() {
+		var result models.MovieSearchResult
+		if err := rows.Scan(append(movieDest(&result.Movie), &result.Score)...); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to scan movie", err))
+			return
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to search movies", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Movies fetched successfully", results))
+}
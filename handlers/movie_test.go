@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchMovies_Ranked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("websearch_to_tsquery\\('simple', immutable_unaccent\\(\\$1\\)\\)").
+		WithArgs("pengabdi setan", 5).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "title", "description", "duration", "genre", "rating", "director", "cast",
+			"release_date", "end_date", "score",
+		}).
+			AddRow(3, "Pengabdi Setan 2: Communion", "", 119, "{Horror}", "17+", "Joko Anwar", "{Tara Basro}", "2022-08-04", "", 1.42).
+			AddRow(4, "Pengabdi Setan", "", 107, "{Horror}", "17+", "Joko Anwar", "{Tara Basro}", "2017-09-28", "", 0.98))
+
+	router := setupTestRouter()
+	router.GET("/movies/search", SearchMovies)
+
+	req, _ := http.NewRequest("GET", "/movies/search?q=+pengabdi+setan+&limit=5", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []models.MovieSearchResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, 3, response.Data[0].ID)
+	assert.Equal(t, []string{"Tara Basro"}, response.Data[0].Cast)
+	assert.Equal(t, 1.42, response.Data[0].Score)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSearchMovies_InvalidQuery(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/movies/search", SearchMovies)
+
+	for _, query := range []string{"q=", "q=+++", "q=batman&limit=0", "q=batman&limit=51"} {
+		req, _ := http.NewRequest("GET", "/movies/search?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
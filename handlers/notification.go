@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/utils"
+	"log"
+)
+
+// notifyUser stores a notification for the user and hands it to the
+// configured notifier. Failures are logged and never fail the request.
+func notifyUser(userID int, subject, message string) {
+	_, err := config.DB.Exec(`
+        INSERT INTO notifications (user_id, subject, message)
+        VALUES ($1, $2, $3)
+    `, userID, subject, message)
+	if err != nil {
+		log.Printf("Failed to store notification for user %d: %v", userID, err)
+	}
+
+	if err := utils.Notifications.Notify(userID, subject, message); err != nil {
+		log.Printf("Failed to notify user %d: %v", userID, err)
+	}
+}
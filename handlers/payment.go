@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentWebhook godoc
+//
+//	@Summary		Receive payment gateway webhook
+//	@Description	Verify the HMAC signature of a payment event and apply it to the related booking. Events are deduplicated by event_id and may arrive in any order; a failure older than the last applied event is ignored. A payment that arrives after its booking was cancelled, refunded or lost its seats is refunded, and so is a second payment for a paid booking.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Signature	header		string																	true	"Hex encoded HMAC-SHA256 of the request body"
+//	@Param			event		body		models.PaymentWebhookEvent												true	"Payment event"
+//	@Success		200			{object}	models.Response{data=object{booking_id=int,status=string,applied=bool}}	"Event processed successfully"
+//	@Failure		400			{object}	models.Response															"Invalid request"
+//	@Failure		401			{object}	models.Response															"Invalid signature"
+//	@Failure		404			{object}	models.Response															"Booking not found"
+//	@Failure		500			{object}	models.Response															"Internal server error"
+//	@Router			/webhooks/payments [post]
+func PaymentWebhook(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	if !utils.VerifySignature(payload, c.GetHeader("X-Signature"), os.Getenv("PAYMENT_WEBHOOK_SECRET")) {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid signature", nil))
+		return
+	}
+
+	var event models.PaymentWebhookEvent
+	c.Request.Body = io.NopCloser(bytes.NewReader(payload))
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	var (
+		status           string
+		screeningID      int
+		totalAmount      models.Money
+		giftCardAmount   models.Money
+		loyaltyPoints    int
+		paymentReference string
+		paymentEventAt   sql.NullTime
+	)
+	err = tx.QueryRow(`
+        SELECT status, screening_id, total_amount, gift_card_amount, loyalty_points, COALESCE(payment_reference, ''), payment_event_at
+        FROM bookings WHERE id = $1
+        FOR UPDATE
+    `, event.BookingID).Scan(&status, &screeningID, &totalAmount, &giftCardAmount, &loyaltyPoints, &paymentReference, &paymentEventAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	// Record the event before applying it so a retried delivery is a no-op
+	result, err := tx.Exec(`
+        INSERT INTO payment_events (event_id, booking_id, event_type, occurred_at, payload)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (event_id) DO NOTHING
+    `, event.EventID, event.BookingID, event.Type, event.OccurredAt, string(payload))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to record payment event", err))
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusOK, models.SuccessResponse("Event already processed", gin.H{
+			"booking_id": event.BookingID,
+			"applied":    false,
+		}))
+		return
+	}
+
+	nextStatus, applied := nextPaymentStatus(status, event.Type)
+
+	// A failure older than the last event applied to the booking is stale. A
+	// success is never dropped for being late since the money was taken; it
+	// either pays the booking or is refunded below.
+	if staleFailure(event, paymentEventAt) {
+		nextStatus, applied = status, false
+	}
+
+	// A failed booking gave its seats, concessions, gift card value and loyalty
+	// points back, so a late payment only revives it if it can take them all
+	// again
+	var conflict string
+	if applied && seatHoldDelta(status, nextStatus) < 0 {
+		conflict, err = reviveConflict(tx, event.BookingID, screeningID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+			return
+		}
+		if conflict == "" && giftCardAmount.Amount > 0 {
+			retaken, err := retakeGiftCardValue(tx, event.BookingID, fmt.Sprintf("Booking #%d", event.BookingID))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to redeem gift card", err))
+				return
+			}
+			if !retaken {
+				conflict = "gift card balance was spent"
+			}
+		}
+		if conflict == "" && loyaltyPoints > 0 {
+			retaken, err := retakeLoyaltyPoints(tx, event.BookingID, fmt.Sprintf("Booking #%d", event.BookingID))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to redeem loyalty points", err))
+				return
+			}
+			if !retaken {
+				conflict = "loyalty points were spent"
+			}
+		}
+	} else if lateCapture(status, event.Type, paymentReference, event.PaymentReference) && totalAmount.Sub(giftCardAmount).Amount > 0 {
+		conflict = "booking was already " + status
+	}
+
+	if conflict != "" {
+		captured := totalAmount.Sub(giftCardAmount)
+		reason := "Payment received too late: " + conflict
+		message := "Late payment refunded"
+		if status == models.BookingStatusPaid {
+			reason = "Duplicate payment: booking was already paid with " + paymentReference
+			message = "Duplicate payment refunded"
+		}
+		refund, err := refundLateCapture(tx, event.BookingID, captured, reason, event.PaymentReference)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to refund late payment", err))
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to commit transaction", err))
+			return
+		}
+
+		if err := sendRefund(&refund, event.PaymentReference); err != nil {
+			log.Printf("Failed to send refund %d of booking %d: %v", refund.ID, event.BookingID, err)
+			message += ", the payment gateway refund will be retried"
+		}
+
+		c.JSON(http.StatusOK, models.SuccessResponse(message, gin.H{
+			"booking_id": event.BookingID,
+			"status":     status,
+			"applied":    false,
+			"refund":     refund,
+		}))
+		return
+	}
+
+	if applied {
+		_, err = tx.Exec(`
+            UPDATE bookings
+            SET status = $1, payment_reference = $2, payment_event_at = GREATEST(payment_event_at, $3),
+                paid_at = CASE WHEN $1 = 'paid' THEN NOW() ELSE paid_at END,
+                updated_at = NOW()
+            WHERE id = $4
+        `, nextStatus, event.PaymentReference, event.OccurredAt, event.BookingID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update booking", err))
+			return
+		}
+
+		if nextStatus == models.BookingStatusPaid {
+			if err := earnLoyaltyPoints(tx, event.BookingID); err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to credit loyalty points", err))
+				return
+			}
+		}
+
+		// A failed booking holds nothing, so its gift card value and loyalty
+		// points go back
+		if nextStatus == models.BookingStatusFailed && (giftCardAmount.Amount > 0 || loyaltyPoints > 0) {
+			b := models.Booking{ID: event.BookingID, GiftCardAmount: giftCardAmount, LoyaltyPoints: loyaltyPoints}
+			if err := releaseBookingCredits(tx, b, fmt.Sprintf("Payment of booking #%d failed", event.BookingID)); err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to return gift card value and loyalty points", err))
+				return
+			}
+		}
+
+		if delta := seatHoldDelta(status, nextStatus); delta != 0 {
+			_, err = tx.Exec(`
+                UPDATE screenings
+                SET available_seats = available_seats + $1 * (SELECT COUNT(*) FROM booking_seats WHERE booking_id = $2),
+                    updated_at = NOW()
+                WHERE id = (SELECT screening_id FROM bookings WHERE id = $2)
+            `, delta, event.BookingID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update seats", err))
+				return
+			}
+
+			if err := restockConcessions(tx, []int{event.BookingID}, delta); err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update concession stock", err))
+				return
+			}
+		}
+
+		_, err = tx.Exec("UPDATE payment_events SET applied = true WHERE event_id = $1", event.EventID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to record payment event", err))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to commit transaction", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Event processed successfully", gin.H{
+		"booking_id": event.BookingID,
+		"status":     nextStatus,
+		"applied":    applied,
+	}))
+}
+
+// nextPaymentStatus returns the booking status after applying a payment event
+// and whether the event changes anything. A successful payment always wins over
+// a failure, so the final status does not depend on the order events arrive in.
+func nextPaymentStatus(current, eventType string) (string, bool) {
+	switch eventType {
+	case models.PaymentEventSucceeded:
+		if current == models.BookingStatusPending || current == models.BookingStatusFailed {
+			return models.BookingStatusPaid, true
+		}
+	case models.PaymentEventFailed:
+		if current == models.BookingStatusPending {
+			return models.BookingStatusFailed, true
+		}
+	}
+	return current, false
+}
+
+// seatHoldDelta returns +1 when a transition releases the booking's seats back
+// to the screening and -1 when it takes them again.
+func seatHoldDelta(from, to string) int {
+	switch {
+	case to == models.BookingStatusFailed:
+		return 1
+	case from == models.BookingStatusFailed && to == models.BookingStatusPaid:
+		return -1
+	}
+	return 0
+}
+
+// staleFailure reports whether a failed payment event happened before the last
+// event applied to the booking
+func staleFailure(event models.PaymentWebhookEvent, lastEventAt sql.NullTime) bool {
+	return event.Type == models.PaymentEventFailed && lastEventAt.Valid && event.OccurredAt.Before(lastEventAt.Time)
+}
+
+// lateCapture reports whether a successful payment arrived for a booking that
+// no longer takes one. A paid or refunded booking only counts when the payment
+// is not the one it was paid with, so a second capture is refunded too.
+func lateCapture(current, eventType, bookingReference, eventReference string) bool {
+	if eventType != models.PaymentEventSucceeded {
+		return false
+	}
+	switch current {
+	case models.BookingStatusCancelled:
+		return true
+	case models.BookingStatusPaid, models.BookingStatusRefunded:
+		return eventReference != bookingReference
+	}
+	return false
+}
+
+// reviveConflict locks the screening and the concessions of a failed booking
+// and returns why the booking cannot take its seats and items again, or ""
+// when it can
+func reviveConflict(tx *sql.Tx, bookingID, screeningID int) (string, error) {
+	var availableSeats int
+	err := tx.QueryRow("SELECT available_seats FROM screenings WHERE id = $1 FOR UPDATE", screeningID).Scan(&availableSeats)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := tx.Query("SELECT seat_label FROM booking_seats WHERE booking_id = $1 ORDER BY seat_label", bookingID)
+	if err != nil {
+		return "", err
+	}
+	var labels []string
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			rows.Close()
+			return "", err
+		}
+		labels = append(labels, label)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if availableSeats < len(labels) {
+		return "not enough seats available", nil
+	}
+
+	taken, err := takenSeats(tx, screeningID, labels)
+	if err != nil {
+		return "", err
+	}
+	if len(taken) > 0 {
+		return "seats already booked: " + strings.Join(taken, ", "), nil
+	}
+
+	// Locking the items in id order matches AddBookingConcessions
+	rows, err = tx.Query(`
+        SELECT c.name
+        FROM concession_items c JOIN (
+            SELECT concession_item_id, SUM(quantity) AS quantity
+            FROM booking_concessions
+            WHERE booking_id = $1
+            GROUP BY concession_item_id
+        ) o ON o.concession_item_id = c.id
+        WHERE c.stock < o.quantity
+        ORDER BY c.id
+        FOR UPDATE OF c
+    `, bookingID)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var soldOut []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", err
+		}
+		soldOut = append(soldOut, name)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if len(soldOut) > 0 {
+		return "sold out: " + strings.Join(soldOut, ", "), nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWebhookRequest(t *testing.T, event models.PaymentWebhookEvent, secret string) *http.Request {
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Error marshalling event: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", "/webhooks/payments", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", utils.SignPayload(body, secret))
+	return req
+}
+
+// expectWebhookBooking expects booking 1 of screening 1 to be locked. It cost
+// 89,688 after redeeming 100 loyalty points, and 20,000 of it came from a
+// gift card. A booking with a payment reference got its last payment event an
+// hour ago.
+func expectWebhookBooking(mock sqlmock.Sqlmock, status, paymentReference string) {
+	var paymentEventAt interface{}
+	if paymentReference != "" {
+		paymentEventAt = time.Now().Add(-time.Hour)
+	}
+	mock.ExpectQuery("SELECT status, screening_id, total_amount, gift_card_amount, loyalty_points, COALESCE\\(payment_reference, ''\\), payment_event_at FROM bookings WHERE id = \\$1 FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "screening_id", "total_amount", "gift_card_amount", "loyalty_points", "payment_reference", "payment_event_at"}).
+			AddRow(status, 1, 89688.0, 20000.0, 100, paymentReference, paymentEventAt))
+}
+
+func TestPaymentWebhook_Paid(t *testing.T) {
+	os.Setenv("PAYMENT_WEBHOOK_SECRET", "webhook_secret")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectBegin()
+	expectWebhookBooking(mock, models.BookingStatusPending, "")
+	mock.ExpectExec("INSERT INTO payment_events").
+		WithArgs("evt_1", 1, models.PaymentEventSucceeded, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE bookings").
+		WithArgs(models.BookingStatusPaid, "PAY-1", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT user_id, total_amount FROM bookings").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "total_amount"}).AddRow(1, 89688.0))
+	mock.ExpectExec("INSERT INTO loyalty_transactions").
+		WithArgs(1, 1, models.LoyaltyEarn, 8, 365, "Booking #1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE payment_events SET applied = true WHERE event_id = \\$1").
+		WithArgs("evt_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/webhooks/payments", PaymentWebhook)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newWebhookRequest(t, models.PaymentWebhookEvent{
+		EventID:          "evt_1",
+		Type:             models.PaymentEventSucceeded,
+		BookingID:        1,
+		PaymentReference: "PAY-1",
+		OccurredAt:       time.Now(),
+	}, "webhook_secret"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.True(t, response.Success)
+	assert.Equal(t, "Event processed successfully", response.Message)
+	assert.Equal(t, models.BookingStatusPaid, response.Data.(map[string]interface{})["status"])
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPaymentWebhook_FailedReleasesSeatsAndCredits(t *testing.T) {
+	os.Setenv("PAYMENT_WEBHOOK_SECRET", "webhook_secret")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectBegin()
+	expectWebhookBooking(mock, models.BookingStatusPending, "")
+	mock.ExpectExec("INSERT INTO payment_events").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE bookings").
+		WithArgs(models.BookingStatusFailed, "", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectGiftCardRedemptions(mock, 1, sqlmock.NewRows([]string{"gift_card_id", "amount"}).AddRow(2, 20000.0))
+	mock.ExpectQuery("UPDATE gift_cards SET balance = balance \\+ \\$1").
+		WithArgs("20000.00", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(35000.0))
+	mock.ExpectQuery("INSERT INTO gift_card_transactions").
+		WithArgs(2, 1, models.GiftCardRefund, "20000.00", "35000.00", "Payment of booking #1 failed").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(4, time.Now()))
+	expectLoyaltyPoints(mock, 1, 100, 0)
+	mock.ExpectExec("INSERT INTO loyalty_transactions").
+		WithArgs(1, 1, models.LoyaltyReversal, 100, 365, "Payment of booking #1 failed").
+		WillReturnResult(sqlmock.NewResult(8, 1))
+	mock.ExpectExec("UPDATE screenings").
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRestockConcessions(mock, "{1}", 1)
+	mock.ExpectExec("UPDATE payment_events SET applied = true").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/webhooks/payments", PaymentWebhook)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newWebhookRequest(t, models.PaymentWebhookEvent{
+		EventID:    "evt_2",
+		Type:       models.PaymentEventFailed,
+		BookingID:  1,
+		OccurredAt: time.Now(),
+	}, "webhook_secret"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPaymentWebhook_Duplicate(t *testing.T) {
+	os.Setenv("PAYMENT_WEBHOOK_SECRET", "webhook_secret")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectBegin()
+	expectWebhookBooking(mock, models.BookingStatusPaid, "PAY-1")
+	mock.ExpectExec("INSERT INTO payment_events").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	router := setupTestRouter()
+	router.POST("/webhooks/payments", PaymentWebhook)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newWebhookRequest(t, models.PaymentWebhookEvent{
+		EventID:    "evt_1",
+		Type:       models.PaymentEventSucceeded,
+		BookingID:  1,
+		OccurredAt: time.Now(),
+	}, "webhook_secret"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.True(t, response.Success)
+	assert.Equal(t, "Event already processed", response.Message)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPaymentWebhook_UnknownBooking(t *testing.T) {
+	os.Setenv("PAYMENT_WEBHOOK_SECRET", "webhook_secret")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status, screening_id").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "screening_id", "total_amount", "gift_card_amount", "loyalty_points", "payment_reference"}))
+	mock.ExpectRollback()
+
+	router := setupTestRouter()
+	router.POST("/webhooks/payments", PaymentWebhook)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newWebhookRequest(t, models.PaymentWebhookEvent{
+		EventID:    "evt_1",
+		Type:       models.PaymentEventSucceeded,
+		BookingID:  1,
+		OccurredAt: time.Now(),
+	}, "webhook_secret"))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPaymentWebhook_LateSuccessRefundedWhenSeatsTaken(t *testing.T) {
+	os.Setenv("PAYMENT_WEBHOOK_SECRET", "webhook_secret")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+	gateway := &fakeGateway{refundID: "RF-1"}
+	utils.Gateway = gateway
+
+	now := time.Now()
+	reason := "Payment received too late: seats already booked: A2"
+	mock.ExpectBegin()
+	expectWebhookBooking(mock, models.BookingStatusFailed, "")
+	mock.ExpectExec("INSERT INTO payment_events").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT available_seats FROM screenings WHERE id = \\$1 FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"available_seats"}).AddRow(40))
+	mock.ExpectQuery("SELECT seat_label FROM booking_seats WHERE booking_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"seat_label"}).AddRow("A1").AddRow("A2"))
+	mock.ExpectQuery("SELECT bs.seat_label").
+		WithArgs(1, `{"A1","A2"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"seat_label"}).AddRow("A2"))
+	mock.ExpectQuery("INSERT INTO refunds").
+		WithArgs(1, "69688.00", 100, reason, models.RefundStatusPending, "PAY-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, now))
+	mock.ExpectQuery("INSERT INTO refund_status_history").
+		WithArgs(3, models.RefundStatusPending, reason).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+	mock.ExpectCommit()
+	expectFinishRefund(mock, 3, models.RefundStatusCompleted, "RF-1", "Refunded by payment gateway")
+
+	router := setupTestRouter()
+	router.POST("/webhooks/payments", PaymentWebhook)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newWebhookRequest(t, models.PaymentWebhookEvent{
+		EventID:          "evt_3",
+		Type:             models.PaymentEventSucceeded,
+		BookingID:        1,
+		PaymentReference: "PAY-2",
+		OccurredAt:       now,
+	}, "webhook_secret"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, gateway.calls)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "Late payment refunded", response.Message)
+	assert.Equal(t, models.BookingStatusFailed, response.Data.(map[string]interface{})["status"])
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPaymentWebhook_DuplicateCaptureRefunded(t *testing.T) {
+	os.Setenv("PAYMENT_WEBHOOK_SECRET", "webhook_secret")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+	gateway := &fakeGateway{refundID: "RF-2"}
+	utils.Gateway = gateway
+
+	now := time.Now()
+	reason := "Duplicate payment: booking was already paid with PAY-1"
+	mock.ExpectBegin()
+	expectWebhookBooking(mock, models.BookingStatusPaid, "PAY-1")
+	mock.ExpectExec("INSERT INTO payment_events").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO refunds").
+		WithArgs(1, "69688.00", 100, reason, models.RefundStatusPending, "PAY-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, now))
+	mock.ExpectQuery("INSERT INTO refund_status_history").
+		WithArgs(5, models.RefundStatusPending, reason).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+	mock.ExpectCommit()
+	expectFinishRefund(mock, 5, models.RefundStatusCompleted, "RF-2", "Refunded by payment gateway")
+
+	router := setupTestRouter()
+	router.POST("/webhooks/payments", PaymentWebhook)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newWebhookRequest(t, models.PaymentWebhookEvent{
+		EventID:          "evt_4",
+		Type:             models.PaymentEventSucceeded,
+		BookingID:        1,
+		PaymentReference: "PAY-2",
+		OccurredAt:       now,
+	}, "webhook_secret"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, gateway.calls)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "Duplicate payment refunded", response.Message)
+	assert.Equal(t, models.BookingStatusPaid, response.Data.(map[string]interface{})["status"])
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestLateCapture(t *testing.T) {
+	assert.True(t, lateCapture(models.BookingStatusCancelled, models.PaymentEventSucceeded, "", "PAY-1"))
+	assert.True(t, lateCapture(models.BookingStatusRefunded, models.PaymentEventSucceeded, "PAY-1", "PAY-2"))
+	assert.True(t, lateCapture(models.BookingStatusPaid, models.PaymentEventSucceeded, "PAY-1", "PAY-2"))
+	assert.False(t, lateCapture(models.BookingStatusPaid, models.PaymentEventSucceeded, "PAY-1", "PAY-1"))
+	assert.False(t, lateCapture(models.BookingStatusRefunded, models.PaymentEventSucceeded, "PAY-1", "PAY-1"))
+	assert.False(t, lateCapture(models.BookingStatusCancelled, models.PaymentEventFailed, "", "PAY-1"))
+}
+
+func TestStaleFailure(t *testing.T) {
+	lastEventAt := sql.NullTime{Time: time.Now(), Valid: true}
+	failed := models.PaymentWebhookEvent{Type: models.PaymentEventFailed, OccurredAt: lastEventAt.Time.Add(-time.Minute)}
+	succeeded := models.PaymentWebhookEvent{Type: models.PaymentEventSucceeded, OccurredAt: failed.OccurredAt}
+
+	assert.True(t, staleFailure(failed, lastEventAt))
+	assert.False(t, staleFailure(succeeded, lastEventAt))
+	assert.False(t, staleFailure(failed, sql.NullTime{}))
+
+	failed.OccurredAt = lastEventAt.Time.Add(time.Minute)
+	assert.False(t, staleFailure(failed, lastEventAt))
+}
+
+func TestPaymentWebhook_InvalidSignature(t *testing.T) {
+	os.Setenv("PAYMENT_WEBHOOK_SECRET", "webhook_secret")
+
+	router := setupTestRouter()
+	router.POST("/webhooks/payments", PaymentWebhook)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newWebhookRequest(t, models.PaymentWebhookEvent{
+		EventID:    "evt_1",
+		Type:       models.PaymentEventSucceeded,
+		BookingID:  1,
+		OccurredAt: time.Now(),
+	}, "wrong_secret"))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.False(t, response.Success)
+	assert.Equal(t, "Invalid signature", response.Message)
+}
+
+func TestNextPaymentStatus_OrderIndependent(t *testing.T) {
+	events := []string{models.PaymentEventFailed, models.PaymentEventSucceeded}
+
+	for _, order := range [][]string{events, {events[1], events[0]}} {
+		status := models.BookingStatusPending
+		for _, event := range order {
+			status, _ = nextPaymentStatus(status, event)
+		}
+		assert.Equal(t, models.BookingStatusPaid, status)
+	}
+
+	_, applied := nextPaymentStatus(models.BookingStatusPaid, models.PaymentEventFailed)
+	assert.False(t, applied)
+	assert.Equal(t, 0, seatHoldDelta(models.BookingStatusPending, models.BookingStatusPaid))
+	assert.Equal(t, -1, seatHoldDelta(models.BookingStatusFailed, models.BookingStatusPaid))
+}
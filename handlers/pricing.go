@@ -0,0 +1,427 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// GetScreeningPricing godoc
+//
+//	@Summary		Get screening price tiers
+//	@Description	Get the ticket category prices set on a screening. Categories without a tier fall back to the theater defaults, then to the screening price.
+//	@Tags			pricing
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int											true	"Screening ID"
+//	@Success		200	{object}	models.Response{data=models.ScreeningPricing}	"Price tiers fetched successfully"
+//	@Failure		400	{object}	models.Response								"Invalid ID"
+//	@Failure		401	{object}	models.Response								"Unauthorized"
+//	@Failure		500	{object}	models.Response								"Internal server error"
+//	@Router			/screenings/{id}/price-tiers [get]
+func GetScreeningPricing(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid screening ID", err))
+		return
+	}
+
+	tiers, err := priceTiers("screening_id", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Price tiers fetched successfully", models.ScreeningPricing{Tiers: tiers}))
+}
+
+// UpdateScreeningPricing godoc
+//
+//	@Summary		Replace screening price tiers
+//	@Description	Replace the ticket category prices of a screening (Admin only)
+//	@Tags			pricing
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		int						true	"Screening ID"
+//	@Param			pricingRequest	body		models.ScreeningPricing	true	"Price tiers"
+//	@Success		200				{object}	models.Response			"Price tiers updated successfully"
+//	@Failure		400				{object}	models.Response			"Invalid request"
+//	@Failure		401				{object}	models.Response			"Unauthorized"
+//	@Failure		404				{object}	models.Response			"Screening not found"
+//	@Failure		500				{object}	models.Response			"Internal server error"
+//	@Router			/screenings/{id}/price-tiers [put]
+func UpdateScreeningPricing(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid screening ID", err))
+		return
+	}
+
+	var req models.ScreeningPricing
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	var exists bool
+	if err := config.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM screenings WHERE id = $1)", id).Scan(&exists); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Screening not found", nil))
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	if err := replacePriceTiers(tx, "screening_id", id, req.Tiers); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update price tiers", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to commit transaction", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Price tiers updated successfully", nil))
+}
+
+// GetTheaterPricing godoc
+//
+//	@Summary		Get theater pricing
+//	@Description	Get the default ticket category prices and seat type surcharges of a theater
+//	@Tags			pricing
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int											true	"Theater ID"
+//	@Success		200	{object}	models.Response{data=models.TheaterPricing}	"Theater pricing fetched successfully"
+//	@Failure		400	{object}	models.Response								"Invalid ID"
+//	@Failure		401	{object}	models.Response								"Unauthorized"
+//	@Failure		500	{object}	models.Response								"Internal server error"
+//	@Router			/theaters/{id}/pricing [get]
+func GetTheaterPricing(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid theater ID", err))
+		return
+	}
+
+	tiers, err := priceTiers("theater_id", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	rows, err := config.DB.Query("SELECT seat_type, amount FROM seat_surcharges WHERE theater_id = $1 ORDER BY seat_type", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer rows.Close()
+
+	pricing := models.TheaterPricing{Tiers: tiers, SeatSurcharges: []models.SeatSurcharge{}}
+	for rows.Next() {
+		var s models.SeatSurcharge
+		if err := rows.Scan(&s.SeatType, &s.Amount); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+			return
+		}
+		pricing.SeatSurcharges = append(pricing.SeatSurcharges, s)
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Theater pricing fetched successfully", pricing))
+}
+
+// UpdateTheaterPricing godoc
+//
+//	@Summary		Replace theater pricing
+//	@Description	Replace the default ticket category prices and seat type surcharges of a theater (Admin only)
+//	@Tags			pricing
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		int						true	"Theater ID"
+//	@Param			pricingRequest	body		models.TheaterPricing	true	"Theater pricing"
+//	@Success		200				{object}	models.Response			"Theater pricing updated successfully"
+//	@Failure		400				{object}	models.Response			"Invalid request"
+//	@Failure		401				{object}	models.Response			"Unauthorized"
+//	@Failure		404				{object}	models.Response			"Theater not found"
+//	@Failure		500				{object}	models.Response			"Internal server error"
+//	@Router			/theaters/{id}/pricing [put]
+func UpdateTheaterPricing(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid theater ID", err))
+		return
+	}
+
+	var req models.TheaterPricing
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	var exists bool
+	if err := config.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM theaters WHERE id = $1)", id).Scan(&exists); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Theater not found", nil))
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	if err := replacePriceTiers(tx, "theater_id", id, req.Tiers); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update theater pricing", err))
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM seat_surcharges WHERE theater_id = $1", id); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update theater pricing", err))
+		return
+	}
+
+	for _, s := range req.SeatSurcharges {
+		_, err := tx.Exec("INSERT INTO seat_surcharges (theater_id, seat_type, amount) VALUES ($1, $2, $3)", id, s.SeatType, s.Amount)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update theater pricing", err))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to commit transaction", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Theater pricing updated successfully", nil))
+}
+
+// GetTheaterCharges godoc
+//
+//	@Summary		Get theater tax and fees
+//	@Description	Get the PPN rate and per-ticket convenience fee added to bookings at a theater. Theaters without their own settings use PPN_RATE and no fee.
+//	@Tags			pricing
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int												true	"Theater ID"
+//	@Success		200	{object}	models.Response{data=models.TheaterCharges}	"Theater charges fetched successfully"
+//	@Failure		400	{object}	models.Response								"Invalid ID"
+//	@Failure		401	{object}	models.Response								"Unauthorized"
+//	@Failure		500	{object}	models.Response								"Internal server error"
+//	@Router			/theaters/{id}/charges [get]
+func GetTheaterCharges(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid theater ID", err))
+		return
+	}
+
+	charges, err := theaterCharges(config.DB, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Theater charges fetched successfully", charges))
+}
+
+// UpdateTheaterCharges godoc
+//
+//	@Summary		Update theater tax and fees
+//	@Description	Set the PPN rate and per-ticket convenience fee of a theater. Existing bookings keep the charges they were created with. (Admin only)
+//	@Tags			pricing
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		int						true	"Theater ID"
+//	@Param			chargesRequest	body		models.TheaterCharges	true	"Theater charges"
+//	@Success		200				{object}	models.Response			"Theater charges updated successfully"
+//	@Failure		400				{object}	models.Response			"Invalid request"
+//	@Failure		401				{object}	models.Response			"Unauthorized"
+//	@Failure		404				{object}	models.Response			"Theater not found"
+//	@Failure		500				{object}	models.Response			"Internal server error"
+//	@Router			/theaters/{id}/charges [put]
+func UpdateTheaterCharges(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid theater ID", err))
+		return
+	}
+
+	var req models.TheaterCharges
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	var exists bool
+	if err := config.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM theaters WHERE id = $1)", id).Scan(&exists); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Theater not found", nil))
+		return
+	}
+
+	_, err = config.DB.Exec(`
+        INSERT INTO theater_charges (theater_id, ppn_rate, convenience_fee, fee_taxable)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (theater_id) DO UPDATE
+        SET ppn_rate = EXCLUDED.ppn_rate, convenience_fee = EXCLUDED.convenience_fee,
+            fee_taxable = EXCLUDED.fee_taxable, updated_at = NOW()
+    `, id, req.PPNRate, req.ConvenienceFee, req.FeeTaxable)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update theater charges", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Theater charges updated successfully", nil))
+}
+
+// priceTiers lists the tiers owned by a theater or a screening. owner is
+// either "theater_id" or "screening_id", never user input.
+func priceTiers(owner string, id int) ([]models.PriceTier, error) {
+	rows, err := config.DB.Query(`
+        SELECT category, price, COALESCE(price_3d, 0)
+        FROM price_tiers WHERE `+owner+` = $1
+        ORDER BY category
+    `, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tiers := []models.PriceTier{}
+	for rows.Next() {
+		var t models.PriceTier
+		if err := rows.Scan(&t.Category, &t.Price, &t.Price3D); err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, t)
+	}
+
+	return tiers, rows.Err()
+}
+
+func replacePriceTiers(tx *sql.Tx, owner string, id int, tiers []models.PriceTier) error {
+	if _, err := tx.Exec("DELETE FROM price_tiers WHERE "+owner+" = $1", id); err != nil {
+		return err
+	}
+
+	for _, t := range tiers {
+		_, err := tx.Exec(`
+            INSERT INTO price_tiers (`+owner+`, category, price, price_3d)
+            VALUES ($1, $2, $3, NULLIF($4::DECIMAL, 0))
+        `, id, t.Category, t.Price, t.Price3D)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadPriceList collects the screening price, the theater default tiers with
+// the screening's own tiers on top, and the theater's seat surcharges
+func loadPriceList(q queryer, screeningID int) (utils.PriceList, error) {
+	list := utils.PriceList{
+		Tiers:      map[string]models.PriceTier{},
+		Surcharges: map[string]models.Money{},
+	}
+
+	var theaterID int
+	err := q.QueryRow(`
+        SELECT price, COALESCE(price_3d, 0), is_3d, theater_id
+        FROM screenings WHERE id = $1
+    `, screeningID).Scan(&list.Price, &list.Price3D, &list.Is3D, &theaterID)
+	if err != nil {
+		return list, err
+	}
+
+	// Screening tiers sort last so they replace the theater defaults
+	rows, err := q.Query(`
+        SELECT category, price, COALESCE(price_3d, 0)
+        FROM price_tiers
+        WHERE theater_id = $1 OR screening_id = $2
+        ORDER BY screening_id NULLS FIRST
+    `, theaterID, screeningID)
+	if err != nil {
+		return list, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t models.PriceTier
+		if err := rows.Scan(&t.Category, &t.Price, &t.Price3D); err != nil {
+			return list, err
+		}
+		list.Tiers[t.Category] = t
+	}
+	rows.Close()
+
+	rows, err = q.Query("SELECT seat_type, amount FROM seat_surcharges WHERE theater_id = $1", theaterID)
+	if err != nil {
+		return list, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seatType string
+		var amount models.Money
+		if err := rows.Scan(&seatType, &amount); err != nil {
+			return list, err
+		}
+		list.Surcharges[seatType] = amount
+	}
+
+	return list, rows.Err()
+}
+
+// theaterCharges returns the tax and fees of a theater, falling back to
+// PPN_RATE without a fee when the theater has no settings of its own
+func theaterCharges(q queryer, theaterID int) (models.TheaterCharges, error) {
+	charges := models.TheaterCharges{ConvenienceFee: models.NewMoney(0), FeeTaxable: true}
+
+	err := q.QueryRow(`
+        SELECT ppn_rate, convenience_fee, fee_taxable FROM theater_charges WHERE theater_id = $1
+    `, theaterID).Scan(&charges.PPNRate, &charges.ConvenienceFee, &charges.FeeTaxable)
+	if err == sql.ErrNoRows {
+		charges.PPNRate = config.PPNRate()
+		return charges, nil
+	}
+
+	return charges, err
+}
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+const pricingRuleColumns = `
+        id, name, theater_id, priority, adjustment_type, adjustment_value, exclusive, days_of_week,
+        COALESCE(TO_CHAR(start_time, 'HH24:MI'), ''), COALESCE(TO_CHAR(end_time, 'HH24:MI'), ''),
+        holidays_only, COALESCE(premiere_days, 0), COALESCE(min_occupancy, 0),
+        COALESCE(TO_CHAR(valid_from, 'YYYY-MM-DD'), ''), COALESCE(TO_CHAR(valid_until, 'YYYY-MM-DD'), ''),
+        is_active, created_at, updated_at`
+
+// GetPricingRules godoc
+//
+//	@Summary		List pricing rules
+//	@Description	List all dynamic pricing rules, highest priority first (Admin only)
+//	@Tags			pricing
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	models.Response{data=[]models.PricingRule}	"Pricing rules fetched successfully"
+//	@Failure		401	{object}	models.Response								"Unauthorized"
+//	@Failure		500	{object}	models.Response								"Internal server error"
+//	@Router			/pricing-rules [get]
+func GetPricingRules(c *gin.Context) {
+	rows, err := config.DB.Query("SELECT " + pricingRuleColumns + " FROM pricing_rules ORDER BY priority DESC, id")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer rows.Close()
+
+	rules := []models.PricingRule{}
+	for rows.Next() {
+		rule, err := scanPricingRule(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+			return
+		}
+		rules = append(rules, rule)
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Pricing rules fetched successfully", rules))
+}
+
+// CreatePricingRule godoc
+//
+//	@Summary		Create pricing rule
+//	@Description	Create a dynamic pricing rule (Admin only)
+//	@Tags			pricing
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			ruleRequest	body		models.PricingRuleRequest					true	"Pricing rule"
+//	@Success		201			{object}	models.Response{data=models.PricingRule}	"Pricing rule created successfully"
+//	@Failure		400			{object}	models.Response								"Invalid request"
+//	@Failure		401			{object}	models.Response								"Unauthorized"
+//	@Failure		500			{object}	models.Response								"Internal server error"
+//	@Router			/pricing-rules [post]
+func CreatePricingRule(c *gin.Context) {
+	var req models.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	rule, err := scanPricingRule(config.DB.QueryRow(`
+        INSERT INTO pricing_rules (
+            name, theater_id, priority, adjustment_type, adjustment_value, exclusive, days_of_week,
+            start_time, end_time, holidays_only, premiere_days, min_occupancy, valid_from, valid_until, is_active
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::TIME, NULLIF($9, '')::TIME, $10,
+                NULLIF($11, 0), NULLIF($12, 0), NULLIF($13, '')::DATE, NULLIF($14, '')::DATE, $15)
+        RETURNING `+pricingRuleColumns, pricingRuleArgs(req)...))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create pricing rule", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse("Pricing rule created successfully", rule))
+}
+
+// UpdatePricingRule godoc
+//
+//	@Summary		Replace pricing rule
+//	@Description	Replace every field of a dynamic pricing rule (Admin only)
+//	@Tags			pricing
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		int											true	"Pricing rule ID"
+//	@Param			ruleRequest	body		models.PricingRuleRequest					true	"Pricing rule"
+//	@Success		200			{object}	models.Response{data=models.PricingRule}	"Pricing rule updated successfully"
+//	@Failure		400			{object}	models.Response								"Invalid request"
+//	@Failure		401			{object}	models.Response								"Unauthorized"
+//	@Failure		404			{object}	models.Response								"Pricing rule not found"
+//	@Failure		500			{object}	models.Response								"Internal server error"
+//	@Router			/pricing-rules/{id} [put]
+func UpdatePricingRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid pricing rule ID", err))
+		return
+	}
+
+	var req models.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	rule, err := scanPricingRule(config.DB.QueryRow(`
+        UPDATE pricing_rules SET
+            name = $1, theater_id = $2, priority = $3, adjustment_type = $4, adjustment_value = $5,
+            exclusive = $6, days_of_week = $7, start_time = NULLIF($8, '')::TIME, end_time = NULLIF($9, '')::TIME,
+            holidays_only = $10, premiere_days = NULLIF($11, 0), min_occupancy = NULLIF($12, 0),
+            valid_from = NULLIF($13, '')::DATE, valid_until = NULLIF($14, '')::DATE, is_active = $15,
+            updated_at = NOW()
+        WHERE id = $16
+        RETURNING `+pricingRuleColumns, append(pricingRuleArgs(req), id)...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Pricing rule not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update pricing rule", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Pricing rule updated successfully", rule))
+}
+
+// DeletePricingRule godoc
+//
+//	@Summary		Delete pricing rule
+//	@Description	Delete a dynamic pricing rule. Bookings already priced keep their line items. (Admin only)
+//	@Tags			pricing
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Pricing rule ID"
+//	@Success		200	{object}	models.Response	"Pricing rule deleted successfully"
+//	@Failure		400	{object}	models.Response	"Invalid ID"
+//	@Failure		401	{object}	models.Response	"Unauthorized"
+//	@Failure		404	{object}	models.Response	"Pricing rule not found"
+//	@Failure		500	{object}	models.Response	"Internal server error"
+//	@Router			/pricing-rules/{id} [delete]
+func DeletePricingRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid pricing rule ID", err))
+		return
+	}
+
+	result, err := config.DB.Exec("DELETE FROM pricing_rules WHERE id = $1", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to delete pricing rule", err))
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Pricing rule not found", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Pricing rule deleted successfully", nil))
+}
+
+// PreviewScreeningPrice godoc
+//
+//	@Summary		Preview dynamic price
+//	@Description	Evaluate the pricing rules for one ticket of a screening and explain which rules applied. show_time and occupancy override the screening's own values to try other scenarios. (Admin only)
+//	@Tags			pricing
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		int										true	"Screening ID"
+//	@Param			category	query		string									false	"Ticket category"	default(adult)
+//	@Param			show_time	query		string									false	"Show time to evaluate instead, local to the theater without an offset"
+//	@Param			occupancy	query		int										false	"Occupancy percentage to evaluate instead"
+//	@Success		200			{object}	models.Response{data=models.PricePreview}	"Price preview generated successfully"
+//	@Failure		400			{object}	models.Response							"Invalid request"
+//	@Failure		401			{object}	models.Response							"Unauthorized"
+//	@Failure		404			{object}	models.Response							"Screening not found"
+//	@Failure		500			{object}	models.Response							"Internal server error"
+//	@Router			/screenings/{id}/price-preview [get]
+func PreviewScreeningPrice(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid screening ID", err))
+		return
+	}
+
+	category := c.DefaultQuery("category", models.TicketCategoryAdult)
+	if !validTicketCategory(category) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid ticket category", nil))
+		return
+	}
+
+	var showTime *models.LocalTime
+	if value := c.Query("show_time"); value != "" {
+		t, err := models.ParseLocalTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid show_time", err))
+			return
+		}
+		showTime = &t
+	}
+
+	ctx, err := loadPricingContext(config.DB, id, showTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Screening not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	if value := c.Query("occupancy"); value != "" {
+		occupancy, err := strconv.Atoi(value)
+		if err != nil || occupancy < 0 || occupancy > 100 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Occupancy must be between 0 and 100", err))
+			return
+		}
+		ctx.Occupancy = occupancy
+	}
+
+	rules, err := loadPricingRules(config.DB, ctx.TheaterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to load pricing rules", err))
+		return
+	}
+
+	priceList, err := loadPriceList(config.DB, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to load prices", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Price preview generated successfully", models.PricePreview{
+		ScreeningID:     id,
+		Category:        category,
+		ShowTime:        ctx.ShowTime,
+		Holiday:         ctx.Holiday,
+		Occupancy:       ctx.Occupancy,
+		PriceEvaluation: utils.EvaluatePricingRules(rules, ctx, priceList.UnitPrice(category), 1),
+	}))
+}
+
+// dynamicPricing evaluates the pricing rules of a screening over the price of
+// quantity tickets
+func dynamicPricing(q queryer, screeningID int, base models.Money, quantity int) (models.PriceEvaluation, error) {
+	ctx, err := loadPricingContext(q, screeningID, nil)
+	if err != nil {
+		return models.PriceEvaluation{}, err
+	}
+
+	rules, err := loadPricingRules(q, ctx.TheaterID)
+	if err != nil {
+		return models.PriceEvaluation{}, err
+	}
+
+	return utils.EvaluatePricingRules(rules, ctx, base, quantity), nil
+}
+
+// loadPricingContext gathers the facts pricing rules match on. A non-nil
+// showTime replaces the screening's show time.
+func loadPricingContext(q queryer, screeningID int, showTime *models.LocalTime) (models.PricingContext, error) {
+	var (
+		ctx            models.PricingContext
+		scheduled      time.Time
+		timezone       string
+		capacity       int
+		availableSeats int
+	)
+	err := q.QueryRow(`
+        SELECT s.theater_id, t.timezone, s.show_time, m.release_date, h.capacity, s.available_seats
+        FROM screenings s
+        JOIN theaters t ON t.id = s.theater_id
+        JOIN movies m ON m.id = s.movie_id
+        JOIN halls h ON h.id = s.hall_id
+        WHERE s.id = $1
+    `, screeningID).Scan(&ctx.TheaterID, &timezone, &scheduled, &ctx.ReleaseDate, &capacity, &availableSeats)
+	if err != nil {
+		return ctx, err
+	}
+
+	loc := config.TheaterLocation(timezone)
+	if showTime != nil {
+		scheduled = showTime.In(loc)
+	}
+	ctx.ShowTime = scheduled.In(loc)
+
+	if capacity > 0 {
+		ctx.Occupancy = (capacity - availableSeats) * 100 / capacity
+	}
+
+	err = q.QueryRow(
+		"SELECT COALESCE(MAX(name), '') FROM public_holidays WHERE holiday_date = $1",
+		ctx.ShowTime.Format("2006-01-02"),
+	).Scan(&ctx.Holiday)
+
+	return ctx, err
+}
+
+// loadPricingRules returns the active rules that apply to a theater
+func loadPricingRules(q queryer, theaterID int) ([]models.PricingRule, error) {
+	rows, err := q.Query(`
+        SELECT `+pricingRuleColumns+`
+        FROM pricing_rules
+        WHERE is_active AND (theater_id IS NULL OR theater_id = $1)
+        ORDER BY priority DESC, id
+    `, theaterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.PricingRule
+	for rows.Next() {
+		rule, err := scanPricingRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func scanPricingRule(row rowScanner) (models.PricingRule, error) {
+	var (
+		rule       models.PricingRule
+		theaterID  sql.NullInt64
+		daysOfWeek pq.Int64Array
+	)
+	err := row.Scan(
+		&rule.ID, &rule.Name, &theaterID, &rule.Priority, &rule.AdjustmentType, &rule.AdjustmentValue,
+		&rule.Exclusive, &daysOfWeek, &rule.StartTime, &rule.EndTime, &rule.HolidaysOnly, &rule.PremiereDays,
+		&rule.MinOccupancy, &rule.ValidFrom, &rule.ValidUntil, &rule.IsActive, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return rule, err
+	}
+
+	if theaterID.Valid {
+		id := int(theaterID.Int64)
+		rule.TheaterID = &id
+	}
+	for _, day := range daysOfWeek {
+		rule.DaysOfWeek = append(rule.DaysOfWeek, int(day))
+	}
+
+	return rule, nil
+}
+
+func pricingRuleArgs(req models.PricingRuleRequest) []interface{} {
+	var daysOfWeek pq.Int64Array
+	for _, day := range req.DaysOfWeek {
+		daysOfWeek = append(daysOfWeek, int64(day))
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	return []interface{}{
+		req.Name, req.TheaterID, req.Priority, req.AdjustmentType, req.AdjustmentValue, req.Exclusive, daysOfWeek,
+		req.StartTime, req.EndTime, req.HolidaysOnly, req.PremiereDays, req.MinOccupancy, req.ValidFrom, req.ValidUntil,
+		isActive,
+	}
+}
+
+func validTicketCategory(category string) bool {
+	for _, c := range models.TicketCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
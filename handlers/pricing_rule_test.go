@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var pricingRuleRowColumns = []string{
+	"id", "name", "theater_id", "priority", "adjustment_type", "adjustment_value", "exclusive", "days_of_week",
+	"start_time", "end_time", "holidays_only", "premiere_days", "min_occupancy", "valid_from", "valid_until",
+	"is_active", "created_at", "updated_at",
+}
+
+// expectPricingRules expects the pricing context of screening 1, shown on a
+// Saturday evening in Jakarta at 40% occupancy, followed by rules
+func expectPricingRules(mock sqlmock.Sqlmock, rules *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT s.theater_id, t.timezone, s.show_time, m.release_date, h.capacity, s.available_seats").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"theater_id", "timezone", "show_time", "release_date", "capacity", "available_seats"}).
+			AddRow(1, "Asia/Jakarta", time.Date(2025, 12, 27, 12, 0, 0, 0, time.UTC), time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC), 100, 60))
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(name\\), ''\\) FROM public_holidays").
+		WithArgs("2025-12-27").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(""))
+	mock.ExpectQuery("FROM pricing_rules WHERE is_active").
+		WithArgs(1).
+		WillReturnRows(rules)
+}
+
+func TestPreviewScreeningPrice_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	rules := sqlmock.NewRows(pricingRuleRowColumns).
+		AddRow(2, "Weekend", nil, 50, "percent", 10.0, false, "{0,6}", "", "", false, 0, 0, "", "", true, now, now).
+		AddRow(4, "Prime time", nil, 30, "fixed", 5000.0, false, nil, "18:00", "22:00", false, 0, 0, "", "", true, now, now).
+		AddRow(6, "High demand", nil, 10, "percent", 10.0, false, nil, "", "", false, 0, 80, "", "", true, now, now)
+	expectPricingRules(mock, rules)
+	expectPriceList(mock)
+
+	router := setupTestRouter()
+	router.GET("/screenings/:id/price-preview", PreviewScreeningPrice)
+
+	req, _ := http.NewRequest("GET", "/screenings/1/price-preview?category=child", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.PricePreview `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, 40, response.Data.Occupancy)
+	assert.Equal(t, rupiah(30000), response.Data.BasePrice)
+	assert.Equal(t, rupiah(38000), response.Data.FinalPrice)
+	if assert.Len(t, response.Data.Applied, 2) {
+		assert.Equal(t, "Weekend", response.Data.Applied[0].Name)
+		assert.Equal(t, "show is on a Saturday", response.Data.Applied[0].Reason)
+		assert.Equal(t, "Prime time", response.Data.Applied[1].Name)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPreviewScreeningPrice_OccupancyOverride(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	expectPricingRules(mock, sqlmock.NewRows(pricingRuleRowColumns).
+		AddRow(6, "High demand", nil, 10, "percent", 10.0, false, nil, "", "", false, 0, 80, "", "", true, now, now))
+	expectPriceList(mock)
+
+	router := setupTestRouter()
+	router.GET("/screenings/:id/price-preview", PreviewScreeningPrice)
+
+	req, _ := http.NewRequest("GET", "/screenings/1/price-preview?occupancy=90", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.PricePreview `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, 90, response.Data.Occupancy)
+	assert.Equal(t, rupiah(55000), response.Data.FinalPrice)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPreviewScreeningPrice_InvalidCategory(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/screenings/:id/price-preview", PreviewScreeningPrice)
+
+	req, _ := http.NewRequest("GET", "/screenings/1/price-preview?category=infant", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreatePricingRule_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO pricing_rules").
+		WithArgs("Weekend", nil, 50, "percent", "10.00", false, sqlmock.AnyArg(), "", "", false, 0, 0, "", "", true).
+		WillReturnRows(sqlmock.NewRows(pricingRuleRowColumns).
+			AddRow(7, "Weekend", nil, 50, "percent", 10.0, false, "{0,6}", "", "", false, 0, 0, "", "", true, now, now))
+
+	router := setupTestRouter()
+	router.POST("/pricing-rules", CreatePricingRule)
+
+	body, _ := json.Marshal(models.PricingRuleRequest{
+		Name:            "Weekend",
+		Priority:        50,
+		AdjustmentType:  models.AdjustmentPercent,
+		AdjustmentValue: models.NewDecimal(10),
+		DaysOfWeek:      []int{0, 6},
+	})
+	req, _ := http.NewRequest("POST", "/pricing-rules", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response struct {
+		Data models.PricingRule `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, 7, response.Data.ID)
+	assert.Equal(t, []int{0, 6}, response.Data.DaysOfWeek)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreatePricingRule_InvalidTime(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/pricing-rules", CreatePricingRule)
+
+	body, _ := json.Marshal(models.PricingRuleRequest{
+		Name:            "Prime time",
+		AdjustmentType:  models.AdjustmentFixed,
+		AdjustmentValue: models.NewDecimal(5000),
+		StartTime:       "6pm",
+	})
+	req, _ := http.NewRequest("POST", "/pricing-rules", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeletePricingRule_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectExec("DELETE FROM pricing_rules WHERE id = \\$1").
+		WithArgs(99).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	router := setupTestRouter()
+	router.DELETE("/pricing-rules/:id", DeletePricingRule)
+
+	req, _ := http.NewRequest("DELETE", "/pricing-rules/99", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
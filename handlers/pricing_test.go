@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPriceList_ScreeningOverridesTheater(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectPriceList(mock)
+
+	list, err := loadPriceList(db, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, rupiah(30000), list.Tiers["child"].Price)
+	assert.Equal(t, rupiah(50000), list.Tiers["adult"].Price)
+	assert.Equal(t, rupiah(25000), list.Surcharges["vip"])
+}
+
+func TestUpdateTheaterPricing_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM theaters WHERE id = \\$1\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM price_tiers WHERE theater_id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("INSERT INTO price_tiers \\(theater_id, category, price, price_3d\\)").
+		WithArgs(1, "senior", "30000.00", "0.00").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE FROM seat_surcharges WHERE theater_id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO seat_surcharges").
+		WithArgs(1, "vip", "20000.00").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.PUT("/theaters/:id/pricing", UpdateTheaterPricing)
+
+	body, _ := json.Marshal(models.TheaterPricing{
+		Tiers:          []models.PriceTier{{Category: "senior", Price: rupiah(30000)}},
+		SeatSurcharges: []models.SeatSurcharge{{SeatType: "vip", Amount: rupiah(20000)}},
+	})
+	req, _ := http.NewRequest("PUT", "/theaters/1/pricing", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateScreeningPricing_InvalidCategory(t *testing.T) {
+	router := setupTestRouter()
+	router.PUT("/screenings/:id/price-tiers", UpdateScreeningPricing)
+
+	body, _ := json.Marshal(models.ScreeningPricing{
+		Tiers: []models.PriceTier{{Category: "infant", Price: rupiah(10000)}},
+	})
+	req, _ := http.NewRequest("PUT", "/screenings/1/price-tiers", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateTheaterPricing_DuplicateCategory(t *testing.T) {
+	router := setupTestRouter()
+	router.PUT("/theaters/:id/pricing", UpdateTheaterPricing)
+
+	body, _ := json.Marshal(models.TheaterPricing{
+		Tiers: []models.PriceTier{
+			{Category: "adult", Price: rupiah(50000)},
+			{Category: "adult", Price: rupiah(45000)},
+		},
+	})
+	req, _ := http.NewRequest("PUT", "/theaters/1/pricing", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTheaterCharges_FallsBackToPPNRate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	t.Setenv("PPN_RATE", "12")
+	mock.ExpectQuery("SELECT ppn_rate, convenience_fee, fee_taxable FROM theater_charges").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"ppn_rate", "convenience_fee", "fee_taxable"}))
+
+	charges, err := theaterCharges(db, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.NewDecimal(12), charges.PPNRate)
+	assert.True(t, charges.ConvenienceFee.IsZero())
+}
+
+func TestUpdateTheaterCharges_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM theaters WHERE id = \\$1\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec("INSERT INTO theater_charges").
+		WithArgs(1, "12.00", "5000.00", false).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	router := setupTestRouter()
+	router.PUT("/theaters/:id/charges", UpdateTheaterCharges)
+
+	body, _ := json.Marshal(models.TheaterCharges{PPNRate: models.NewDecimal(12), ConvenienceFee: rupiah(5000)})
+	req, _ := http.NewRequest("PUT", "/theaters/1/charges", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
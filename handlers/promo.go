@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+const promoCodeColumns = `
+        id, code, COALESCE(description, ''), discount_type, discount_value, max_discount, min_purchase,
+        COALESCE(TO_CHAR(valid_from, 'YYYY-MM-DD'), ''), COALESCE(TO_CHAR(valid_until, 'YYYY-MM-DD'), ''),
+        COALESCE(max_redemptions, 0), COALESCE(max_per_user, 0), movie_ids, theater_ids,
+        (SELECT COUNT(*) FROM promo_redemptions r JOIN bookings b ON b.id = r.booking_id
+         WHERE r.promo_code_id = promo_codes.id AND b.status IN ('pending', 'paid')),
+        is_active, created_at, updated_at`
+
+// GetPromoCodes godoc
+//
+//	@Summary		List promo codes
+//	@Description	List all promo codes with how often they have been redeemed (Admin only)
+//	@Tags			promos
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	models.Response{data=[]models.PromoCode}	"Promo codes fetched successfully"
+//	@Failure		401	{object}	models.Response							"Unauthorized"
+//	@Failure		500	{object}	models.Response							"Internal server error"
+//	@Router			/promo-codes [get]
+func GetPromoCodes(c *gin.Context) {
+	rows, err := config.DB.Query("SELECT " + promoCodeColumns + " FROM promo_codes ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer rows.Close()
+
+	promos := []models.PromoCode{}
+	for rows.Next() {
+		promo, err := scanPromoCode(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+			return
+		}
+		promos = append(promos, promo)
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Promo codes fetched successfully", promos))
+}
+
+// CreatePromoCode godoc
+//
+//	@Summary		Create promo code
+//	@Description	Create a promo code. Codes are case-insensitive. (Admin only)
+//	@Tags			promos
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			promoRequest	body		models.PromoCodeRequest					true	"Promo code"
+//	@Success		201				{object}	models.Response{data=models.PromoCode}	"Promo code created successfully"
+//	@Failure		400				{object}	models.Response							"Invalid request"
+//	@Failure		401				{object}	models.Response							"Unauthorized"
+//	@Failure		409				{object}	models.Response							"Promo code already exists"
+//	@Failure		500				{object}	models.Response							"Internal server error"
+//	@Router			/promo-codes [post]
+func CreatePromoCode(c *gin.Context) {
+	var req models.PromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	promo, err := scanPromoCode(config.DB.QueryRow(`
+        INSERT INTO promo_codes (
+            code, description, discount_type, discount_value, max_discount, min_purchase, valid_from, valid_until,
+            max_redemptions, max_per_user, movie_ids, theater_ids, is_active
+        )
+        VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, NULLIF($7, '')::DATE, NULLIF($8, '')::DATE,
+                NULLIF($9, 0), NULLIF($10, 0), $11, $12, $13)
+        RETURNING `+promoCodeColumns, promoCodeArgs(req)...))
+	if err != nil {
+		if isPQError(err, "23505") {
+			c.JSON(http.StatusConflict, models.ErrorResponse("Promo code already exists", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create promo code", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse("Promo code created successfully", promo))
+}
+
+// UpdatePromoCode godoc
+//
+//	@Summary		Replace promo code
+//	@Description	Replace every field of a promo code. Discounts already applied to bookings are kept. (Admin only)
+//	@Tags			promos
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		int										true	"Promo code ID"
+//	@Param			promoRequest	body		models.PromoCodeRequest					true	"Promo code"
+//	@Success		200				{object}	models.Response{data=models.PromoCode}	"Promo code updated successfully"
+//	@Failure		400				{object}	models.Response							"Invalid request"
+//	@Failure		401				{object}	models.Response							"Unauthorized"
+//	@Failure		404				{object}	models.Response							"Promo code not found"
+//	@Failure		409				{object}	models.Response							"Promo code already exists"
+//	@Failure		500				{object}	models.Response							"Internal server error"
+//	@Router			/promo-codes/{id} [put]
+func UpdatePromoCode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid promo code ID", err))
+		return
+	}
+
+	var req models.PromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	promo, err := scanPromoCode(config.DB.QueryRow(`
+        UPDATE promo_codes SET
+            code = $1, description = NULLIF($2, ''), discount_type = $3, discount_value = $4, max_discount = $5,
+            min_purchase = $6, valid_from = NULLIF($7, '')::DATE, valid_until = NULLIF($8, '')::DATE,
+            max_redemptions = NULLIF($9, 0), max_per_user = NULLIF($10, 0), movie_ids = $11, theater_ids = $12,
+            is_active = $13, updated_at = NOW()
+        WHERE id = $14
+        RETURNING `+promoCodeColumns, append(promoCodeArgs(req), id)...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Promo code not found", nil))
+		} else if isPQError(err, "23505") {
+			c.JSON(http.StatusConflict, models.ErrorResponse("Promo code already exists", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update promo code", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Promo code updated successfully", promo))
+}
+
+// DeletePromoCode godoc
+//
+//	@Summary		Delete promo code
+//	@Description	Delete a promo code that was never redeemed. Redeemed codes are deactivated instead. (Admin only)
+//	@Tags			promos
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Promo code ID"
+//	@Success		200	{object}	models.Response	"Promo code deleted successfully"
+//	@Failure		400	{object}	models.Response	"Invalid ID"
+//	@Failure		401	{object}	models.Response	"Unauthorized"
+//	@Failure		404	{object}	models.Response	"Promo code not found"
+//	@Failure		409	{object}	models.Response	"Promo code has been redeemed"
+//	@Failure		500	{object}	models.Response	"Internal server error"
+//	@Router			/promo-codes/{id} [delete]
+func DeletePromoCode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid promo code ID", err))
+		return
+	}
+
+	result, err := config.DB.Exec("DELETE FROM promo_codes WHERE id = $1", id)
+	if err != nil {
+		if isPQError(err, "23503") {
+			c.JSON(http.StatusConflict, models.ErrorResponse("Promo code has been redeemed, deactivate it instead", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to delete promo code", err))
+		}
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Promo code not found", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Promo code deleted successfully", nil))
+}
+
+// ApplyPromo godoc
+//
+//	@Summary		Apply promo code
+//	@Description	Take a promo code's discount off a pending booking of the current user. The discount and the PPN correction are added as new line items. A booking takes one promo code.
+//	@Tags			bookings
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		int											true	"Booking ID"
+//	@Param			promoRequest	body		models.ApplyPromoRequest					true	"Promo code"
+//	@Success		200				{object}	models.Response{data=models.AppliedPromo}	"Promo code applied successfully"
+//	@Failure		400				{object}	models.Response								"Promo code cannot be applied"
+//	@Failure		401				{object}	models.Response								"Unauthorized"
+//	@Failure		404				{object}	models.Response								"Booking or promo code not found"
+//	@Failure		409				{object}	models.Response								"Promo code already applied, fully redeemed or after a gift card or loyalty points"
+//	@Failure		500				{object}	models.Response								"Internal server error"
+//	@Router			/bookings/{id}/apply-promo [post]
+func ApplyPromo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid booking ID", err))
+		return
+	}
+
+	var req models.ApplyPromoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	// Locking the booking keeps two codes from being applied to it at once
+	var (
+		userID         int
+		status         string
+		giftCardAmount models.Money
+		loyaltyPoints  int
+		timezone       string
+		ctx            models.PromoContext
+	)
+	err = tx.QueryRow(`
+        SELECT b.user_id, b.status, b.gift_card_amount, b.loyalty_points, s.movie_id, s.theater_id, t.timezone
+        FROM bookings b
+        JOIN screenings s ON s.id = b.screening_id
+        JOIN theaters t ON t.id = s.theater_id
+        WHERE b.id = $1
+        FOR UPDATE OF b
+    `, id).Scan(&userID, &status, &giftCardAmount, &loyaltyPoints, &ctx.MovieID, &ctx.TheaterID, &timezone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	if userID != c.GetInt("user_id") {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		return
+	}
+
+	if status != models.BookingStatusPending {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Promo codes can only be applied to pending bookings", nil))
+		return
+	}
+
+	// The discount could leave less to pay than the gift cards already cover
+	if giftCardAmount.Amount > 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse("Promo codes must be applied before gift cards", nil))
+		return
+	}
+
+	// Points were capped to the subtotal the promo would now lower
+	if loyaltyPoints > 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse("Promo codes must be applied before loyalty points", nil))
+		return
+	}
+
+	var redeemed bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM promo_redemptions WHERE booking_id = $1)", id).Scan(&redeemed); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	if redeemed {
+		c.JSON(http.StatusConflict, models.ErrorResponse("A promo code has already been applied to this booking", nil))
+		return
+	}
+
+	// Locking the code serializes its redemptions. The code is read again
+	// afterwards so the redemption count includes every transaction that held
+	// the lock before this one.
+	var promoID int
+	err = tx.QueryRow("SELECT id FROM promo_codes WHERE code = $1 FOR UPDATE", code).Scan(&promoID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Promo code not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	promo, err := scanPromoCode(tx.QueryRow("SELECT "+promoCodeColumns+" FROM promo_codes WHERE id = $1", promoID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	items, err := bookingLineItems(tx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	// Validity dates are days at the theater, like the screening's show time
+	ctx.Date = time.Now().In(config.TheaterLocation(timezone)).Format("2006-01-02")
+	ctx.Subtotal = utils.PromoSubtotal(items)
+	if err := utils.CheckPromo(promo, ctx); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Promo code cannot be applied", err))
+		return
+	}
+
+	if promo.MaxRedemptions > 0 && promo.Redemptions >= promo.MaxRedemptions {
+		c.JSON(http.StatusConflict, models.ErrorResponse("Promo code has been fully redeemed", nil))
+		return
+	}
+
+	if promo.MaxPerUser > 0 {
+		var used int
+		err := tx.QueryRow(`
+            SELECT COUNT(*)
+            FROM promo_redemptions r JOIN bookings b ON b.id = r.booking_id
+            WHERE r.promo_code_id = $1 AND r.user_id = $2 AND b.status IN ('pending', 'paid')
+        `, promo.ID, userID).Scan(&used)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+			return
+		}
+		if used >= promo.MaxPerUser {
+			c.JSON(http.StatusConflict, models.ErrorResponse("You have already used this promo code", nil))
+			return
+		}
+	}
+
+	discount := utils.PromoDiscount(promo, ctx.Subtotal)
+	if discount.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Promo code gives no discount on this booking", nil))
+		return
+	}
+
+	charges, err := theaterCharges(tx, ctx.TheaterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to load theater charges", err))
+		return
+	}
+
+	lines := utils.DiscountLineItems(items, "Promo "+promo.Code, discount, charges)
+	if err := insertLineItems(tx, id, lines); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to apply promo code", err))
+		return
+	}
+	items = append(items, lines...)
+
+	applied := models.AppliedPromo{
+		BookingID:   id,
+		Code:        promo.Code,
+		Discount:    discount,
+		TotalAmount: utils.LineItemsTotal(items),
+		LineItems:   items,
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO promo_redemptions (promo_code_id, booking_id, user_id, discount)
+        VALUES ($1, $2, $3, $4)
+    `, promo.ID, id, userID, discount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to apply promo code", err))
+		return
+	}
+
+	_, err = tx.Exec("UPDATE bookings SET total_amount = $1, updated_at = NOW() WHERE id = $2", applied.TotalAmount, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update booking", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to commit transaction", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Promo code applied successfully", applied))
+}
+
+func scanPromoCode(row rowScanner) (models.PromoCode, error) {
+	var (
+		promo      models.PromoCode
+		movieIDs   pq.Int64Array
+		theaterIDs pq.Int64Array
+	)
+	err := row.Scan(
+		&promo.ID, &promo.Code, &promo.Description, &promo.DiscountType, &promo.DiscountValue, &promo.MaxDiscount,
+		&promo.MinPurchase, &promo.ValidFrom, &promo.ValidUntil, &promo.MaxRedemptions, &promo.MaxPerUser,
+		&movieIDs, &theaterIDs, &promo.Redemptions, &promo.IsActive, &promo.CreatedAt, &promo.UpdatedAt,
+	)
+	if err != nil {
+		return promo, err
+	}
+
+	for _, id := range movieIDs {
+		promo.MovieIDs = append(promo.MovieIDs, int(id))
+	}
+	for _, id := range theaterIDs {
+		promo.TheaterIDs = append(promo.TheaterIDs, int(id))
+	}
+
+	return promo, nil
+}
+
+func promoCodeArgs(req models.PromoCodeRequest) []interface{} {
+	var movieIDs, theaterIDs pq.Int64Array
+	for _, id := range req.MovieIDs {
+		movieIDs = append(movieIDs, int64(id))
+	}
+	for _, id := range req.TheaterIDs {
+		theaterIDs = append(theaterIDs, int64(id))
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	return []interface{}{
+		strings.ToUpper(req.Code), req.Description, req.DiscountType, req.DiscountValue, req.MaxDiscount,
+		req.MinPurchase, req.ValidFrom, req.ValidUntil, req.MaxRedemptions, req.MaxPerUser, movieIDs, theaterIDs,
+		isActive,
+	}
+}
+
+// isPQError reports whether err is a PostgreSQL error with the given SQLSTATE code
+func isPQError(err error, code string) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && string(pqErr.Code) == code
+}
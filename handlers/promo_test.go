@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var promoCodeRowColumns = []string{
+	"id", "code", "description", "discount_type", "discount_value", "max_discount", "min_purchase", "valid_from",
+	"valid_until", "max_redemptions", "max_per_user", "movie_ids", "theater_ids", "redemptions", "is_active",
+	"created_at", "updated_at",
+}
+
+// expectPendingBooking expects the promo checks of booking 5 of user 1 up to
+// loading NONTON20, which has been redeemed redemptions times
+func expectPendingBooking(mock sqlmock.Sqlmock, redemptions int) {
+	expectPromoBooking(mock, redemptions, "Asia/Jakarta", "")
+}
+
+// expectPromoBooking is expectPendingBooking for a theater in timezone and a
+// NONTON20 valid from validFrom
+func expectPromoBooking(mock sqlmock.Sqlmock, redemptions int, timezone, validFrom string) {
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT b.user_id, b.status, b.gift_card_amount, b.loyalty_points, s.movie_id, s.theater_id, t.timezone").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "status", "gift_card_amount", "loyalty_points", "movie_id", "theater_id", "timezone"}).
+			AddRow(1, models.BookingStatusPending, 0.0, 0, 1, 1, timezone))
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM promo_redemptions WHERE booking_id = \\$1\\)").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("SELECT id FROM promo_codes WHERE code = \\$1 FOR UPDATE").
+		WithArgs("NONTON20").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery("FROM promo_codes WHERE id = \\$1").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(promoCodeRowColumns).
+			AddRow(3, "NONTON20", "", "percent", 20.0, 25000.0, 50000.0, validFrom, "", 1000, 1, nil, "{1}", redemptions, true, now, now))
+	mock.ExpectQuery("SELECT kind, description, quantity, unit_price, amount FROM booking_line_items").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "description", "quantity", "unit_price", "amount"}).
+			AddRow(models.LineItemTicket, "Adult ticket", 2, 45500.0, 91000.0).
+			AddRow(models.LineItemFee, "Convenience fee", 2, 4000.0, 8000.0).
+			AddRow(models.LineItemTax, "PPN 11%", 1, 10890.0, 10890.0))
+}
+
+func newApplyPromoRequest(code string) *http.Request {
+	body, _ := json.Marshal(models.ApplyPromoRequest{Code: code})
+	req, _ := http.NewRequest("POST", "/bookings/5/apply-promo", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestApplyPromo_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	expectPendingBooking(mock, 41)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\)").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT ppn_rate, convenience_fee, fee_taxable FROM theater_charges").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ppn_rate", "convenience_fee", "fee_taxable"}).AddRow(11.0, 4000.0, true))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemDiscount, "Promo NONTON20", 1, "-18200.00", "-18200.00").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec("INSERT INTO booking_line_items").
+		WithArgs(5, models.LineItemTax, "PPN 11% adjustment", 1, "-2002.00", "-2002.00").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec("INSERT INTO promo_redemptions").
+		WithArgs(3, 5, 1, "18200.00").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE bookings SET total_amount = \\$1").
+		WithArgs("89688.00", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/apply-promo", withUser(1), ApplyPromo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newApplyPromoRequest(" nonton20 "))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.AppliedPromo `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, rupiah(18200), response.Data.Discount)
+	assert.Equal(t, rupiah(89688), response.Data.TotalAmount)
+	assert.Len(t, response.Data.LineItems, 5)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestApplyPromo_FullyRedeemed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	expectPendingBooking(mock, 1000)
+	mock.ExpectRollback()
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/apply-promo", withUser(1), ApplyPromo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newApplyPromoRequest("NONTON20"))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestApplyPromo_ValidFromIsTheaterDate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	// These zones are 25 hours apart, so the code has started in the app's
+	// zone but not yet at the theater
+	os.Setenv("APP_TIMEZONE", "Pacific/Kiritimati")
+	defer os.Unsetenv("APP_TIMEZONE")
+	validFrom := time.Now().In(config.Location()).Format("2006-01-02")
+
+	expectPromoBooking(mock, 1000, "Pacific/Pago_Pago", validFrom)
+	mock.ExpectRollback()
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/apply-promo", withUser(1), ApplyPromo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newApplyPromoRequest("NONTON20"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreatePromoCode_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO promo_codes").
+		WithArgs("HEMAT10K", "", "fixed", "10000.00", nil, "0.00", "", "2025-12-31", 0, 3, nil, sqlmock.AnyArg(), true).
+		WillReturnRows(sqlmock.NewRows(promoCodeRowColumns).
+			AddRow(4, "HEMAT10K", "", "fixed", 10000.0, nil, 0.0, "", "2025-12-31", 0, 3, nil, "{1,2}", 0, true, now, now))
+
+	router := setupTestRouter()
+	router.POST("/promo-codes", CreatePromoCode)
+
+	body, _ := json.Marshal(models.PromoCodeRequest{
+		Code:          "hemat10k",
+		DiscountType:  models.AdjustmentFixed,
+		DiscountValue: models.NewDecimal(10000),
+		ValidUntil:    "2025-12-31",
+		MaxPerUser:    3,
+		TheaterIDs:    []int{1, 2},
+	})
+	req, _ := http.NewRequest("POST", "/promo-codes", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response struct {
+		Data models.PromoCode `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "HEMAT10K", response.Data.Code)
+	assert.Nil(t, response.Data.MaxDiscount)
+	assert.Equal(t, []int{1, 2}, response.Data.TheaterIDs)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreatePromoCode_InvalidDiscount(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/promo-codes", CreatePromoCode)
+
+	body, _ := json.Marshal(models.PromoCodeRequest{
+		Code:          "GRATIS",
+		DiscountType:  models.AdjustmentFixed,
+		DiscountValue: models.NewDecimal(-5000),
+	})
+	req, _ := http.NewRequest("POST", "/promo-codes", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
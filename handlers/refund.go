@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errGatewayRefund = errors.New("payment gateway refund failed")
+
+// RefundBooking godoc
+//
+//	@Summary		Refund a booking
+//	@Description	Refund a paid booking according to the refund policy, release its seats and return the money through the payment gateway. If the gateway fails, the booking is still refunded and the gateway refund is retried in the background.
+//	@Tags			bookings
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		int									true	"Booking ID"
+//	@Param			refundRequest	body		models.RefundRequest				false	"Refund reason"
+//	@Success		200				{object}	models.Response{data=models.Refund}	"Booking refunded successfully"
+//	@Success		202				{object}	models.Response{data=models.Refund}	"Booking refunded, the payment gateway refund will be retried"
+//	@Failure		400				{object}	models.Response						"Booking cannot be refunded"
+//	@Failure		401				{object}	models.Response						"Unauthorized"
+//	@Failure		404				{object}	models.Response						"Booking not found"
+//	@Failure		500				{object}	models.Response						"Internal server error"
+//	@Router			/bookings/{id}/refund [post]
+func RefundBooking(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid booking ID", err))
+		return
+	}
+
+	var req models.RefundRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+			return
+		}
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	var (
+		userID           int
+		status           string
+		totalAmount      models.Money
+		paymentReference string
+		hoursBeforeShow  float64
+	)
+	err = tx.QueryRow(`
+        SELECT b.user_id, b.status, b.total_amount, COALESCE(b.payment_reference, ''),
+               EXTRACT(EPOCH FROM (s.show_time - NOW())) / 3600
+        FROM bookings b JOIN screenings s ON s.id = b.screening_id
+        WHERE b.id = $1
+        FOR UPDATE OF b
+    `, id).Scan(&userID, &status, &totalAmount, &paymentReference, &hoursBeforeShow)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	if userID != c.GetInt("user_id") {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		return
+	}
+
+	if status != models.BookingStatusPaid {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Only paid bookings can be refunded", nil))
+		return
+	}
+
+	percentage := config.LoadRefundPolicy().Percentage(hoursBeforeShow)
+	if percentage == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Refund window has closed for this booking", nil))
+		return
+	}
+
+	amount := refundAmount(totalAmount, percentage)
+	refund, err := refundBooking(tx, id, amount, percentage, req.Reason, paymentReference)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to refund booking", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to commit transaction", err))
+		return
+	}
+
+	// The refund is on record before the gateway is asked, so if this fails
+	// the retry job sends it again rather than the customer refunding twice
+	if err := sendRefund(&refund, paymentReference); err != nil {
+		log.Printf("Failed to send refund %d of booking %d: %v", refund.ID, id, err)
+		c.JSON(http.StatusAccepted, models.SuccessResponse("Booking refunded, the payment gateway refund will be retried", refund))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Booking refunded successfully", refund))
+}
+
+// refundAmount returns percentage of total, rounded half away from zero to
+// the nearest minor unit
+func refundAmount(total models.Money, percentage int) models.Money {
+	return total.Percent(models.NewDecimal(int64(percentage)))
+}
+
+// refundBooking records a refund for a paid booking, marks the booking as
+// refunded, releases its seats and concessions, reverses its loyalty points
+// and credits the gift cards it was paid with. The rest is left pending for
+// sendRefund, which the caller runs once this is committed; a refund made
+// entirely to gift cards is completed here.
+func refundBooking(tx *sql.Tx, bookingID int, amount models.Money, percentage int, reason, paymentReference string) (models.Refund, error) {
+	refund := models.Refund{
+		BookingID:  bookingID,
+		Amount:     amount,
+		Percentage: percentage,
+		Reason:     reason,
+		Status:     models.RefundStatusPending,
+	}
+
+	err := tx.QueryRow(`
+        INSERT INTO refunds (booking_id, amount, percentage, reason, status, payment_reference)
+        VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+        RETURNING id, created_at
+    `, bookingID, amount, percentage, reason, refund.Status, paymentReference).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return refund, err
+	}
+
+	if err := addRefundHistory(tx, &refund, models.RefundStatusPending, reason); err != nil {
+		return refund, err
+	}
+
+	_, err = tx.Exec("UPDATE bookings SET status = $1, updated_at = NOW() WHERE id = $2", models.BookingStatusRefunded, bookingID)
+	if err != nil {
+		return refund, err
+	}
+
+	_, err = tx.Exec(`
+        UPDATE screenings
+        SET available_seats = available_seats + (SELECT COUNT(*) FROM booking_seats WHERE booking_id = $1),
+            updated_at = NOW()
+        WHERE id = (SELECT screening_id FROM bookings WHERE id = $1)
+    `, bookingID)
+	if err != nil {
+		return refund, err
+	}
+
+	if err := restockConcessions(tx, []int{bookingID}, 1); err != nil {
+		return refund, err
+	}
+
+	note := fmt.Sprintf("Refund of booking #%d", bookingID)
+	if err := reverseLoyaltyPoints(tx, bookingID, percentage, note); err != nil {
+		return refund, err
+	}
+
+	refund.GiftCardAmount, err = returnGiftCardValue(tx, bookingID, amount, note)
+	if err != nil {
+		return refund, err
+	}
+
+	if amount.Sub(refund.GiftCardAmount).Amount <= 0 {
+		refund.Status = models.RefundStatusCompleted
+	}
+	err = tx.QueryRow(`
+        UPDATE refunds SET status = $1, gift_card_amount = $2, updated_at = NOW()
+        WHERE id = $3
+        RETURNING updated_at
+    `, refund.Status, refund.GiftCardAmount, refund.ID).Scan(&refund.UpdatedAt)
+	if err != nil {
+		return refund, err
+	}
+
+	if refund.Status == models.RefundStatusCompleted {
+		if err := addRefundHistory(tx, &refund, models.RefundStatusCompleted, "Refunded to gift card"); err != nil {
+			return refund, err
+		}
+	}
+
+	return refund, nil
+}
+
+// refundLateCapture records a pending refund of a payment captured for a
+// booking that can no longer be honoured, such as one cancelled in the
+// meantime or whose seats were sold after its payment failed. The booking
+// itself is left as it is, and the caller runs sendRefund once this is
+// committed.
+func refundLateCapture(tx *sql.Tx, bookingID int, amount models.Money, reason, paymentReference string) (models.Refund, error) {
+	refund := models.Refund{
+		BookingID:      bookingID,
+		Amount:         amount,
+		GiftCardAmount: models.Money{Currency: amount.Currency},
+		Percentage:     100,
+		Reason:         reason,
+		Status:         models.RefundStatusPending,
+	}
+
+	err := tx.QueryRow(`
+        INSERT INTO refunds (booking_id, amount, percentage, reason, status, payment_reference)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `, bookingID, amount, refund.Percentage, reason, refund.Status, paymentReference).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return refund, err
+	}
+
+	if err := addRefundHistory(tx, &refund, models.RefundStatusPending, reason); err != nil {
+		return refund, err
+	}
+
+	return refund, nil
+}
+
+// sendRefund asks the payment gateway for the part of a committed refund that
+// did not go back to gift cards and records the outcome. The refund ID is the
+// idempotency key, so sending a refund again never pays it out twice.
+func sendRefund(refund *models.Refund, paymentReference string) error {
+	if refund.Status == models.RefundStatusCompleted {
+		return nil
+	}
+
+	key := fmt.Sprintf("refund-%d", refund.ID)
+	gatewayReference, err := utils.Gateway.Refund(paymentReference, refund.Amount.Sub(refund.GiftCardAmount), key)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", errGatewayRefund, err)
+		if recordErr := finishRefund(refund, models.RefundStatusFailed, "", err.Error()); recordErr != nil {
+			log.Printf("Failed to record failed refund %d: %v", refund.ID, recordErr)
+		}
+		return err
+	}
+
+	note := "Refunded by payment gateway"
+	if !refund.GiftCardAmount.IsZero() {
+		note = "Refunded to gift card and by payment gateway"
+	}
+	return finishRefund(refund, models.RefundStatusCompleted, gatewayReference, note)
+}
+
+// finishRefund records the gateway's answer for a refund. A refund already
+// completed, by a retry that got there first, is left as it is.
+func finishRefund(refund *models.Refund, status, gatewayReference, note string) error {
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+        UPDATE refunds SET status = $1, gateway_reference = COALESCE(NULLIF($2, ''), gateway_reference), updated_at = NOW()
+        WHERE id = $3 AND status <> $4
+        RETURNING updated_at
+    `, status, gatewayReference, refund.ID, models.RefundStatusCompleted).Scan(&refund.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	refund.Status = status
+	if gatewayReference != "" {
+		refund.GatewayReference = gatewayReference
+	}
+	if err := addRefundHistory(tx, refund, status, note); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// StartRefundRetry sends refunds the payment gateway has not confirmed again
+// now and then every interval in the background
+func StartRefundRetry(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			completed, err := retryRefunds(interval)
+			if err != nil {
+				log.Printf("Failed to retry refunds: %v", err)
+			} else if completed > 0 {
+				log.Printf("Completed %d refunds on retry", completed)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// retryRefunds sends every pending or failed refund to the gateway again. A
+// refund touched within the last interval is left for the next run, since a
+// request may still be sending it. It returns the number of refunds completed.
+func retryRefunds(interval time.Duration) (int, error) {
+	rows, err := config.DB.Query(`
+        SELECT id, booking_id, amount, gift_card_amount, percentage, COALESCE(reason, ''), status, payment_reference, created_at
+        FROM refunds
+        WHERE status IN ($1, $2) AND payment_reference IS NOT NULL
+          AND updated_at <= NOW() - $3 * INTERVAL '1 second'
+        ORDER BY id
+    `, models.RefundStatusPending, models.RefundStatusFailed, interval.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		refunds    []models.Refund
+		references []string
+	)
+	for rows.Next() {
+		var (
+			refund           models.Refund
+			paymentReference string
+		)
+		err := rows.Scan(&refund.ID, &refund.BookingID, &refund.Amount, &refund.GiftCardAmount, &refund.Percentage,
+			&refund.Reason, &refund.Status, &paymentReference, &refund.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		refunds = append(refunds, refund)
+		references = append(references, paymentReference)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	for i := range refunds {
+		if err := sendRefund(&refunds[i], references[i]); err != nil {
+			log.Printf("Failed to send refund %d of booking %d: %v", refunds[i].ID, refunds[i].BookingID, err)
+			continue
+		}
+		completed++
+	}
+
+	return completed, nil
+}
+
+func addRefundHistory(tx *sql.Tx, refund *models.Refund, status, note string) error {
+	entry := models.RefundStatusHistory{Status: status, Note: note}
+	err := tx.QueryRow(`
+        INSERT INTO refund_status_history (refund_id, status, note)
+        VALUES ($1, $2, $3)
+        RETURNING created_at
+    `, refund.ID, status, note).Scan(&entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	refund.History = append(refund.History, entry)
+	return nil
+}
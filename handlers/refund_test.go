@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeGateway struct {
+	refundID string
+	err      error
+	calls    int
+	amount   models.Money
+	key      string
+}
+
+func (g *fakeGateway) Refund(paymentReference string, amount models.Money, idempotencyKey string) (string, error) {
+	g.calls++
+	g.amount = amount
+	g.key = idempotencyKey
+	return g.refundID, g.err
+}
+
+func withUser(userID int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	}
+}
+
+func expectRefundBooking(mock sqlmock.Sqlmock, hoursBeforeShow float64) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT b.user_id, b.status, b.total_amount").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "status", "total_amount", "payment_reference", "hours"}).
+			AddRow(1, models.BookingStatusPaid, 100000.0, "PAY-1", hoursBeforeShow))
+}
+
+// expectGiftCardRedemptions expects the lookup of what a booking took from
+// gift cards
+func expectGiftCardRedemptions(mock sqlmock.Sqlmock, bookingID int, rows *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT gift_card_id, -SUM\\(amount\\)").
+		WithArgs(bookingID).
+		WillReturnRows(rows)
+}
+
+// expectLoyaltyPoints expects the lookup of the points a booking redeemed and
+// earned
+func expectLoyaltyPoints(mock sqlmock.Sqlmock, bookingID, redeemed, earned int) {
+	mock.ExpectQuery("SELECT b.user_id, b.loyalty_points").
+		WithArgs(bookingID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "loyalty_points", "earned"}).AddRow(1, redeemed, earned))
+}
+
+// expectSettleRefund expects the end of the refund transaction, which leaves
+// the refund pending for the gateway unless gift cards covered it
+func expectSettleRefund(mock sqlmock.Sqlmock, refundID int, status, giftCardAmount string) {
+	mock.ExpectQuery("UPDATE refunds SET status = \\$1, gift_card_amount = \\$2").
+		WithArgs(status, giftCardAmount, refundID).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+}
+
+// expectFinishRefund expects the gateway's answer to be recorded after the
+// refund was committed
+func expectFinishRefund(mock sqlmock.Sqlmock, refundID int, status, gatewayReference, note string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE refunds SET status = \\$1, gateway_reference = COALESCE").
+		WithArgs(status, gatewayReference, refundID, models.RefundStatusCompleted).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	mock.ExpectQuery("INSERT INTO refund_status_history").
+		WithArgs(refundID, status, note).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+}
+
+func TestRefundBooking_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+	gateway := &fakeGateway{refundID: "RF-1"}
+	utils.Gateway = gateway
+
+	now := time.Now()
+	expectRefundBooking(mock, 30)
+	mock.ExpectQuery("INSERT INTO refunds").
+		WithArgs(1, "75000.00", 75, "Cannot attend", models.RefundStatusPending, "PAY-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, now))
+	mock.ExpectQuery("INSERT INTO refund_status_history").
+		WithArgs(1, models.RefundStatusPending, "Cannot attend").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+	mock.ExpectExec("UPDATE bookings SET status = \\$1").
+		WithArgs(models.BookingStatusRefunded, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE screenings").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRestockConcessions(mock, "{1}", 1)
+	expectLoyaltyPoints(mock, 1, 0, 10)
+	mock.ExpectQuery("SELECT id, remaining FROM loyalty_transactions").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "remaining"}).AddRow(6, 4).AddRow(2, 30))
+	mock.ExpectExec("UPDATE loyalty_transactions SET remaining = remaining - \\$1").
+		WithArgs(4, 6).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE loyalty_transactions SET remaining = remaining - \\$1").
+		WithArgs(6, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO loyalty_transactions").
+		WithArgs(1, 1, models.LoyaltyReversal, -10, 0, "Refund of booking #1").
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	expectGiftCardRedemptions(mock, 1, sqlmock.NewRows([]string{"gift_card_id", "amount"}))
+	expectSettleRefund(mock, 1, models.RefundStatusPending, "0.00")
+	mock.ExpectCommit()
+	expectFinishRefund(mock, 1, models.RefundStatusCompleted, "RF-1", "Refunded by payment gateway")
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/refund", withUser(1), RefundBooking)
+
+	body, _ := json.Marshal(models.RefundRequest{Reason: "Cannot attend"})
+	req, _ := http.NewRequest("POST", "/bookings/1/refund", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, gateway.calls)
+	assert.Equal(t, "refund-1", gateway.key)
+
+	var response struct {
+		Success bool          `json:"success"`
+		Data    models.Refund `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.True(t, response.Success)
+	assert.Equal(t, models.RefundStatusCompleted, response.Data.Status)
+	assert.Equal(t, rupiah(75000), response.Data.Amount)
+	assert.Len(t, response.Data.History, 2)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRefundBooking_GatewayFailureKeepsRefund(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+	utils.Gateway = &fakeGateway{err: errors.New("connection reset")}
+
+	now := time.Now()
+	expectRefundBooking(mock, 72)
+	mock.ExpectQuery("INSERT INTO refunds").
+		WithArgs(1, "100000.00", 100, "", models.RefundStatusPending, "PAY-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, now))
+	mock.ExpectQuery("INSERT INTO refund_status_history").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+	mock.ExpectExec("UPDATE bookings SET status = \\$1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE screenings").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRestockConcessions(mock, "{1}", 1)
+	expectLoyaltyPoints(mock, 1, 0, 0)
+	expectGiftCardRedemptions(mock, 1, sqlmock.NewRows([]string{"gift_card_id", "amount"}))
+	expectSettleRefund(mock, 1, models.RefundStatusPending, "0.00")
+	mock.ExpectCommit()
+	expectFinishRefund(mock, 1, models.RefundStatusFailed, "", "payment gateway refund failed: connection reset")
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/refund", withUser(1), RefundBooking)
+
+	req, _ := http.NewRequest("POST", "/bookings/1/refund", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var response struct {
+		Data models.Refund `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, models.RefundStatusFailed, response.Data.Status)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRetryRefunds_SendsWithSameKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+	gateway := &fakeGateway{refundID: "RF-1"}
+	utils.Gateway = gateway
+
+	mock.ExpectQuery("SELECT id, booking_id, amount, gift_card_amount").
+		WithArgs(models.RefundStatusPending, models.RefundStatusFailed, 900.0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "booking_id", "amount", "gift_card_amount", "percentage", "reason", "status", "payment_reference", "created_at"}).
+			AddRow(4, 1, 75000.0, 60000.0, 100, "", models.RefundStatusFailed, "PAY-1", time.Now()))
+	expectFinishRefund(mock, 4, models.RefundStatusCompleted, "RF-1", "Refunded to gift card and by payment gateway")
+
+	completed, err := retryRefunds(15 * time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, completed)
+	assert.Equal(t, "refund-4", gateway.key)
+	assert.Equal(t, rupiah(15000), gateway.amount)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRefundBooking_WindowClosed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+	gateway := &fakeGateway{}
+	utils.Gateway = gateway
+
+	expectRefundBooking(mock, 1)
+	mock.ExpectRollback()
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/refund", withUser(1), RefundBooking)
+
+	req, _ := http.NewRequest("POST", "/bookings/1/refund", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 0, gateway.calls)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.False(t, response.Success)
+	assert.Equal(t, "Refund window has closed for this booking", response.Message)
+}
+
+func TestRefundBooking_OtherUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	expectRefundBooking(mock, 72)
+	mock.ExpectRollback()
+
+	router := setupTestRouter()
+	router.POST("/bookings/:id/refund", withUser(2), RefundBooking)
+
+	req, _ := http.NewRequest("POST", "/bookings/1/refund", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,579 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+const scheduleColumns = `
+        id, movie_id, theater_id, hall_id, days_of_week, show_times,
+        TO_CHAR(start_date, 'YYYY-MM-DD'), TO_CHAR(end_date, 'YYYY-MM-DD'),
+        price, COALESCE(price_3d, 0), is_3d, is_active, created_at, updated_at`
+
+// A schedule covers at most a year of screenings
+const maxScheduleDays = 366
+
+func scanSchedule(row rowScanner) (models.ScreeningSchedule, error) {
+	var s models.ScreeningSchedule
+	var days pq.Int64Array
+	err := row.Scan(
+		&s.ID, &s.MovieID, &s.TheaterID, &s.HallID, &days, (*pq.StringArray)(&s.ShowTimes),
+		&s.StartDate, &s.EndDate, &s.Price, &s.Price3D, &s.Is3D, &s.IsActive, &s.CreatedAt, &s.UpdatedAt,
+	)
+	for _, d := range days {
+		s.DaysOfWeek = append(s.DaysOfWeek, int(d))
+	}
+	return s, err
+}
+
+func checkScheduleDates(req models.ScreeningScheduleRequest) error {
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return err
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return err
+	}
+	if end.Before(start) {
+		return errors.New("end_date is before start_date")
+	}
+	if end.Sub(start) >= maxScheduleDays*24*time.Hour {
+		return fmt.Errorf("a schedule can cover at most %d days", maxScheduleDays)
+	}
+	return nil
+}
+
+// scheduleFacts is what expanding a schedule needs to know about its movie,
+// hall and theater
+type scheduleFacts struct {
+	movie models.Movie
+	hall  models.Hall
+	loc   *time.Location
+}
+
+// lockScheduleFacts loads the movie, hall and theater of a schedule. The hall
+// row stays locked until the transaction ends, so schedules filling the same
+// hall expand one at a time.
+func lockScheduleFacts(tx queryer, req models.ScreeningScheduleRequest) (scheduleFacts, error) {
+	var f scheduleFacts
+	var timezone string
+	err := tx.QueryRow(`
+        SELECT m.title, m.duration,
+               COALESCE(TO_CHAR(m.release_date, 'YYYY-MM-DD'), ''), COALESCE(TO_CHAR(m.end_date, 'YYYY-MM-DD'), ''),
+               h.name, h.capacity, COALESCE(h.has_3d_capability, false), t.timezone
+        FROM halls h
+        JOIN theaters t ON t.id = h.theater_id
+        JOIN movies m ON m.id = $3
+        WHERE h.id = $1 AND h.theater_id = $2
+        FOR UPDATE OF h
+    `, req.HallID, req.TheaterID, req.MovieID).Scan(
+		&f.movie.Title, &f.movie.Duration, &f.movie.ReleaseDate, &f.movie.EndDate,
+		&f.hall.Name, &f.hall.Capacity, &f.hall.Has3DCapability, &timezone,
+	)
+	f.loc = config.TheaterLocation(timezone)
+	return f, err
+}
+
+// hallSlot is the time a screening occupies its hall
+type hallSlot struct {
+	screeningID int
+	start, end  time.Time
+}
+
+// expandSchedule creates the screenings of a schedule that are still to
+// come. Show times that break the scheduling rules or overlap another
+// screening in the hall are skipped.
+func expandSchedule(tx queryer, schedule models.ScreeningSchedule, f scheduleFacts) ([]models.ScheduleOccurrence, []models.SkippedOccurrence, error) {
+	created := []models.ScheduleOccurrence{}
+	skipped := []models.SkippedOccurrence{}
+
+	showTimes, err := utils.ExpandSchedule(schedule.DaysOfWeek, schedule.ShowTimes, schedule.StartDate, schedule.EndDate, f.loc)
+	if err != nil {
+		return created, skipped, err
+	}
+	now := time.Now()
+	for len(showTimes) > 0 && !showTimes[0].After(now) {
+		showTimes = showTimes[1:]
+	}
+	if len(showTimes) == 0 {
+		return created, skipped, nil
+	}
+	duration := time.Duration(f.movie.Duration) * time.Minute
+
+	rows, err := tx.Query(`
+        SELECT id, show_time, end_time FROM screenings
+        WHERE hall_id = $1 AND is_available = true AND show_time < $3 AND end_time > $2
+        ORDER BY show_time, id
+    `, schedule.HallID, showTimes[0], showTimes[len(showTimes)-1].Add(duration))
+	if err != nil {
+		return created, skipped, err
+	}
+	var taken []hallSlot
+	for rows.Next() {
+		var slot hallSlot
+		if err := rows.Scan(&slot.screeningID, &slot.start, &slot.end); err != nil {
+			rows.Close()
+			return created, skipped, err
+		}
+		taken = append(taken, slot)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return created, skipped, err
+	}
+
+	for _, showTime := range showTimes {
+		endTime := showTime.Add(duration)
+		local := showTime.In(f.loc)
+
+		var reason string
+		if violations := utils.ScreeningViolations(f.movie, f.hall, local.Format("2006-01-02"), schedule.Is3D); len(violations) > 0 {
+			reason = strings.Join(violations, "; ")
+		} else {
+			for _, slot := range taken {
+				if slot.start.Before(endTime) && slot.end.After(showTime) {
+					reason = fmt.Sprintf("Hall is taken by screening #%d", slot.screeningID)
+					break
+				}
+			}
+		}
+		if reason != "" {
+			skipped = append(skipped, models.SkippedOccurrence{ShowTime: showTime.UTC(), ShowTimeLocal: local, Reason: reason})
+			continue
+		}
+
+		var screeningID int
+		err := tx.QueryRow(`
+            INSERT INTO screenings
+            (movie_id, theater_id, hall_id, show_time, end_time, price, price_3d, available_seats, is_3d, is_available, schedule_id)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, true, $10)
+            RETURNING id
+        `, schedule.MovieID, schedule.TheaterID, schedule.HallID, showTime, endTime,
+			schedule.Price, schedule.Price3D, f.hall.Capacity, schedule.Is3D, schedule.ID).Scan(&screeningID)
+		if err != nil {
+			return created, skipped, err
+		}
+
+		taken = append(taken, hallSlot{screeningID: screeningID, start: showTime, end: endTime})
+		created = append(created, models.ScheduleOccurrence{
+			ScreeningID:    screeningID,
+			ShowTime:       showTime.UTC(),
+			ShowTimeLocal:  local,
+			AvailableSeats: f.hall.Capacity,
+			IsAvailable:    true,
+		})
+	}
+
+	return created, skipped, nil
+}
+
+// scheduleOccurrences returns the screenings of a schedule. Series only
+// keeps the upcoming ones that series edits and cancellations apply to.
+func scheduleOccurrences(db queryer, scheduleID int, series bool) ([]models.ScheduleOccurrence, error) {
+	rows, err := db.Query(`
+        SELECT s.id, s.show_time, t.timezone, s.available_seats, s.is_available, s.schedule_exception
+        FROM screenings s
+        JOIN theaters t ON t.id = s.theater_id
+        WHERE s.schedule_id = $1 AND ($2 = false OR (NOT s.schedule_exception AND s.show_time > NOW()))
+        ORDER BY s.show_time, s.id
+    `, scheduleID, series)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	occurrences := []models.ScheduleOccurrence{}
+	for rows.Next() {
+		var o models.ScheduleOccurrence
+		var timezone string
+		if err := rows.Scan(&o.ScreeningID, &o.ShowTime, &timezone, &o.AvailableSeats, &o.IsAvailable, &o.IsException); err != nil {
+			return nil, err
+		}
+		o.ShowTime, o.ShowTimeLocal = o.ShowTime.UTC(), o.ShowTime.In(config.TheaterLocation(timezone))
+		occurrences = append(occurrences, o)
+	}
+	return occurrences, rows.Err()
+}
+
+// CreateScreeningSchedule godoc
+//
+//	@Summary		Create a screening schedule
+//	@Description	Create screenings at the same local times on some weekdays over a date range (Admin only). Show times that have passed are left out; show times outside the movie's run, in 3D in a hall without 3D, or overlapping another screening in the hall are skipped and reported.
+//	@Tags			screening-schedules
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			scheduleRequest	body		models.ScreeningScheduleRequest						true	"Schedule data"
+//	@Success		201				{object}	models.Response{data=models.ScheduleExpansion}	"Schedule created successfully"
+//	@Failure		400				{object}	models.Response										"Invalid request"
+//	@Failure		401				{object}	models.Response										"Unauthorized"
+//	@Failure		500				{object}	models.Response										"Internal server error"
+//	@Router			/screening-schedules [post]
+func CreateScreeningSchedule(c *gin.Context) {
+	var req models.ScreeningScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+	if err := checkScheduleDates(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid date range", err))
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	facts, err := lockScheduleFacts(tx, req)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Movie not found, or hall not found in theater", nil))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	schedule, err := scanSchedule(tx.QueryRow(`
+        INSERT INTO screening_schedules
+        (movie_id, theater_id, hall_id, days_of_week, show_times, start_date, end_date, price, price_3d, is_3d)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING `+scheduleColumns,
+		req.MovieID, req.TheaterID, req.HallID, scheduleDays(req.DaysOfWeek), pq.StringArray(req.ShowTimes),
+		req.StartDate, req.EndDate, req.Price, req.Price3D, req.Is3D))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create schedule", err))
+		return
+	}
+
+	created, skipped, err := expandSchedule(tx, schedule, facts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create screenings", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create schedule", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse("Schedule created successfully", models.ScheduleExpansion{
+		Schedule: schedule,
+		Created:  created,
+		Skipped:  skipped,
+	}))
+}
+
+func scheduleDays(days []int) pq.Int64Array {
+	array := make(pq.Int64Array, len(days))
+	for i, d := range days {
+		array[i] = int64(d)
+	}
+	return array
+}
+
+// GetScreeningSchedules godoc
+//
+//	@Summary		List screening schedules
+//	@Description	List screening schedules of every theater, or of one theater with theater_id, newest first (Admin only)
+//	@Tags			screening-schedules
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			theater_id	query		int													false	"Theater ID"
+//	@Success		200			{object}	models.Response{data=[]models.ScreeningSchedule}	"Schedules fetched successfully"
+//	@Failure		400			{object}	models.Response										"Invalid theater ID"
+//	@Failure		401			{object}	models.Response										"Unauthorized"
+//	@Failure		500			{object}	models.Response										"Internal server error"
+//	@Router			/screening-schedules [get]
+func GetScreeningSchedules(c *gin.Context) {
+	theaterID := 0
+	if value := c.Query("theater_id"); value != "" {
+		var err error
+		if theaterID, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid theater ID", err))
+			return
+		}
+	}
+
+	rows, err := config.DB.Query(`
+        SELECT `+scheduleColumns+` FROM screening_schedules
+        WHERE $1 = 0 OR theater_id = $1
+        ORDER BY start_date DESC, id DESC
+    `, theaterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer rows.Close()
+
+	schedules := []models.ScreeningSchedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to scan schedule", err))
+			return
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Schedules fetched successfully", schedules))
+}
+
+// GetScreeningSchedule godoc
+//
+//	@Summary		Get a screening schedule
+//	@Description	Get a screening schedule with all of its screenings, including ones edited or cancelled on their own (Admin only)
+//	@Tags			screening-schedules
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int												true	"Schedule ID"
+//	@Success		200	{object}	models.Response{data=models.ScreeningSchedule}	"Schedule fetched successfully"
+//	@Failure		400	{object}	models.Response									"Invalid ID"
+//	@Failure		401	{object}	models.Response									"Unauthorized"
+//	@Failure		404	{object}	models.Response									"Schedule not found"
+//	@Failure		500	{object}	models.Response									"Internal server error"
+//	@Router			/screening-schedules/{id} [get]
+func GetScreeningSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid schedule ID", err))
+		return
+	}
+
+	schedule, err := scanSchedule(config.DB.QueryRow("SELECT "+scheduleColumns+" FROM screening_schedules WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Schedule not found", nil))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	schedule.Occurrences, err = scheduleOccurrences(config.DB, id, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to fetch screenings", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Schedule fetched successfully", schedule))
+}
+
+// UpdateScreeningSchedule godoc
+//
+//	@Summary		Replace a screening schedule
+//	@Description	Replace a schedule and regenerate its upcoming screenings (Admin only). Screenings edited or cancelled on their own are left alone, as are ones that already have bookings or their own price tiers; those are listed under kept.
+//	@Tags			screening-schedules
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		int													true	"Schedule ID"
+//	@Param			scheduleRequest	body		models.ScreeningScheduleRequest						true	"Schedule data"
+//	@Success		200				{object}	models.Response{data=models.ScheduleExpansion}	"Schedule updated successfully"
+//	@Failure		400				{object}	models.Response										"Invalid request"
+//	@Failure		401				{object}	models.Response										"Unauthorized"
+//	@Failure		404				{object}	models.Response										"Schedule not found"
+//	@Failure		409				{object}	models.Response										"Schedule has been cancelled"
+//	@Failure		500				{object}	models.Response										"Internal server error"
+//	@Router			/screening-schedules/{id} [put]
+func UpdateScreeningSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid schedule ID", err))
+		return
+	}
+
+	var req models.ScreeningScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+	if err := checkScheduleDates(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid date range", err))
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	var isActive bool
+	err = tx.QueryRow("SELECT is_active FROM screening_schedules WHERE id = $1 FOR UPDATE", id).Scan(&isActive)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Schedule not found", nil))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	if !isActive {
+		c.JSON(http.StatusConflict, models.ErrorResponse("Schedule has been cancelled", nil))
+		return
+	}
+
+	facts, err := lockScheduleFacts(tx, req)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Movie not found, or hall not found in theater", nil))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	schedule, err := scanSchedule(tx.QueryRow(`
+        UPDATE screening_schedules
+        SET movie_id = $1, theater_id = $2, hall_id = $3, days_of_week = $4, show_times = $5,
+            start_date = $6, end_date = $7, price = $8, price_3d = $9, is_3d = $10, updated_at = NOW()
+        WHERE id = $11
+        RETURNING `+scheduleColumns,
+		req.MovieID, req.TheaterID, req.HallID, scheduleDays(req.DaysOfWeek), pq.StringArray(req.ShowTimes),
+		req.StartDate, req.EndDate, req.Price, req.Price3D, req.Is3D, id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update schedule", err))
+		return
+	}
+
+	// Bookings and screening price tiers are deleted with their screening,
+	// so only screenings without either are regenerated
+	_, err = tx.Exec(`
+        DELETE FROM screenings s
+        WHERE s.schedule_id = $1 AND NOT s.schedule_exception AND s.show_time > NOW()
+          AND NOT EXISTS (SELECT 1 FROM bookings b WHERE b.screening_id = s.id)
+          AND NOT EXISTS (SELECT 1 FROM price_tiers p WHERE p.screening_id = s.id)
+    `, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to replace screenings", err))
+		return
+	}
+
+	kept, err := scheduleOccurrences(tx, id, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+
+	created, skipped, err := expandSchedule(tx, schedule, facts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create screenings", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update schedule", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Schedule updated successfully", models.ScheduleExpansion{
+		Schedule: schedule,
+		Created:  created,
+		Skipped:  skipped,
+		Kept:     kept,
+	}))
+}
+
+// CancelScreeningSchedule godoc
+//
+//	@Summary		Cancel a screening schedule
+//	@Description	Stop a schedule and cancel its upcoming screenings like DELETE /screenings/{id} does, refunding paid bookings (Admin only). Screenings edited on their own are not cancelled.
+//	@Tags			screening-schedules
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		int														true	"Schedule ID"
+//	@Param			cancelRequest	body		models.CancelScreeningRequest							false	"Cancellation reason"
+//	@Success		200				{object}	models.Response{data=models.CancelScreeningResult}	"Schedule cancelled successfully"
+//	@Failure		400				{object}	models.Response											"Invalid ID"
+//	@Failure		401				{object}	models.Response											"Unauthorized"
+//	@Failure		404				{object}	models.Response											"Schedule not found"
+//	@Failure		500				{object}	models.Response											"Internal server error"
+//	@Router			/screening-schedules/{id} [delete]
+func CancelScreeningSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid schedule ID", err))
+		return
+	}
+
+	var req models.CancelScreeningRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+			return
+		}
+	}
+
+	result, err := config.DB.Exec("UPDATE screening_schedules SET is_active = false, updated_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to cancel schedule", err))
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Schedule not found", nil))
+		return
+	}
+
+	rows, err := config.DB.Query(`
+        UPDATE screenings
+        SET is_available = false, cancellation_reason = $1, cancelled_at = NOW(), updated_at = NOW()
+        WHERE schedule_id = $2 AND NOT schedule_exception AND is_available = true AND show_time > NOW()
+        RETURNING id
+    `, req.Reason, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to cancel screenings", err))
+		return
+	}
+	var screeningIDs []int
+	for rows.Next() {
+		var screeningID int
+		if err := rows.Scan(&screeningID); err != nil {
+			rows.Close()
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to cancel screenings", err))
+			return
+		}
+		screeningIDs = append(screeningIDs, screeningID)
+	}
+	rows.Close()
+
+	// The screenings are cancelled already, so a failure for one screening
+	// must not stop the bookings of the others from being handled
+	summary := models.CancelScreeningResult{CancelledScreenings: len(screeningIDs)}
+	for _, screeningID := range screeningIDs {
+		s, err := cancelScreeningBookings(screeningID, req.Reason)
+		if err != nil {
+			log.Printf("Failed to cancel bookings of screening %d of schedule %d: %v", screeningID, id, err)
+		}
+		summary.AffectedBookings += s.AffectedBookings
+		summary.RefundedBookings += s.RefundedBookings
+		summary.CancelledBookings += s.CancelledBookings
+		summary.FailedRefunds += s.FailedRefunds
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Schedule cancelled successfully", summary))
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var scheduleColumnNames = []string{
+	"id", "movie_id", "theater_id", "hall_id", "days_of_week", "show_times", "start_date", "end_date",
+	"price", "price_3d", "is_3d", "is_active", "created_at", "updated_at",
+}
+
+func TestCreateScreeningSchedule_SkipsConflicts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	// Mondays 2 and 9 December 2030 at 13:00 and 19:30 in Jakarta. The movie's
+	// run ends on the 8th and the hall is taken on the 2nd from 19:00.
+	loc := config.TheaterLocation("Asia/Jakarta")
+	at := func(day, hour, minute int) time.Time { return time.Date(2030, 12, day, hour, minute, 0, 0, loc) }
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM halls h JOIN theaters t ON t.id = h.theater_id JOIN movies m ON m.id = \\$3 WHERE h.id = \\$1 AND h.theater_id = \\$2 FOR UPDATE OF h").
+		WithArgs(1, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "duration", "release_date", "end_date", "name", "capacity", "has_3d_capability", "timezone"}).
+			AddRow("Avatar", 120, "2030-12-01", "2030-12-08", "Hall 1", 150, false, "Asia/Jakarta"))
+	mock.ExpectQuery("INSERT INTO screening_schedules").
+		WithArgs(1, 1, 1, "{1}", `{"19:30","13:00"}`, "2030-12-02", "2030-12-09", "50000.00", "0.00", false).
+		WillReturnRows(sqlmock.NewRows(scheduleColumnNames).
+			AddRow(5, 1, 1, 1, "{1}", "{19:30,13:00}", "2030-12-02", "2030-12-09", 50000.0, 0.0, false, true, now, now))
+	mock.ExpectQuery("SELECT id, show_time, end_time FROM screenings WHERE hall_id = \\$1 AND is_available = true").
+		WithArgs(1, at(2, 13, 0), at(9, 21, 30)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "show_time", "end_time"}).AddRow(7, at(2, 19, 0), at(2, 21, 0)))
+	mock.ExpectQuery("INSERT INTO screenings").
+		WithArgs(1, 1, 1, at(2, 13, 0), at(2, 15, 0), "50000.00", "0.00", 150, false, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(20))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/screening-schedules", CreateScreeningSchedule)
+
+	body, _ := json.Marshal(models.ScreeningScheduleRequest{
+		MovieID:    1,
+		TheaterID:  1,
+		HallID:     1,
+		DaysOfWeek: []int{1},
+		ShowTimes:  []string{"19:30", "13:00"},
+		StartDate:  "2030-12-02",
+		EndDate:    "2030-12-09",
+		Price:      rupiah(50000),
+	})
+	req, _ := http.NewRequest("POST", "/screening-schedules", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response struct {
+		Data models.ScheduleExpansion `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, []int{1}, response.Data.Schedule.DaysOfWeek)
+	if assert.Len(t, response.Data.Created, 1) {
+		assert.Equal(t, 20, response.Data.Created[0].ScreeningID)
+		assert.True(t, response.Data.Created[0].ShowTime.Equal(at(2, 13, 0)))
+	}
+	if assert.Len(t, response.Data.Skipped, 3) {
+		assert.Equal(t, "Hall is taken by screening #7", response.Data.Skipped[0].Reason)
+		assert.Equal(t, "Avatar ended its run on 2030-12-08", response.Data.Skipped[1].Reason)
+		assert.Equal(t, "Avatar ended its run on 2030-12-08", response.Data.Skipped[2].Reason)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateScreeningSchedule_InvalidDateRange(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/screening-schedules", CreateScreeningSchedule)
+
+	body, _ := json.Marshal(models.ScreeningScheduleRequest{
+		MovieID:    1,
+		TheaterID:  1,
+		HallID:     1,
+		DaysOfWeek: []int{1},
+		ShowTimes:  []string{"13:00"},
+		StartDate:  "2030-12-09",
+		EndDate:    "2030-12-02",
+		Price:      rupiah(50000),
+	})
+	req, _ := http.NewRequest("POST", "/screening-schedules", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "Invalid date range", response.Message)
+}
+
+func TestCancelScreeningSchedule_CancelsUpcomingScreenings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectExec("UPDATE screening_schedules SET is_active = false").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE screenings SET is_available = false, .* WHERE schedule_id = \\$2 AND NOT schedule_exception").
+		WithArgs("Film pulled", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(20).AddRow(21))
+	for _, screeningID := range []int{20, 21} {
+		mock.ExpectBegin()
+		mock.ExpectQuery("UPDATE bookings SET status").
+			WithArgs(models.BookingStatusCancelled, screeningID, models.BookingStatusPending).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "gift_card_amount", "loyalty_points"}))
+		mock.ExpectRollback()
+		mock.ExpectQuery("SELECT id, user_id, total_amount").
+			WithArgs(screeningID, models.BookingStatusPaid).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_amount", "payment_reference"}))
+	}
+
+	router := setupTestRouter()
+	router.DELETE("/screening-schedules/:id", CancelScreeningSchedule)
+
+	req, _ := http.NewRequest("DELETE", "/screening-schedules/5", bytes.NewBufferString(`{"reason": "Film pulled"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.CancelScreeningResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, 2, response.Data.CancelledScreenings)
+	assert.Equal(t, 0, response.Data.AffectedBookings)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
@@ -3,9 +3,14 @@ package handlers
 import (
 	"cinema-ticket-api/config"
 	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
 	"database/sql"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,14 +19,14 @@ import (
 // CreateScreening godoc
 //
 //	@Summary		Create a new screening
-//	@Description	Create a new movie screening (Admin only)
+//	@Description	Create a new movie screening. A show_time without a UTC offset is local time at the theater. The show date must fall within the movie's release_date..end_date and 3D screenings need a 3D-capable hall, unless override is set with a reason, which is recorded in the audit log. (Admin only)
 //	@Tags			screenings
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			screeningRequest	body		models.CreateScreeningRequest			true	"Screening data"
 //	@Success		201					{object}	models.Response{data=object{id=int}}	"Screening created successfully"
-//	@Failure		400					{object}	models.Response							"Invalid request"
+//	@Failure		400					{object}	models.Response							"Invalid request or scheduling rule broken"
 //	@Failure		401					{object}	models.Response							"Unauthorized"
 //	@Failure		500					{object}	models.Response							"Internal server error"
 //	@Router			/screenings [post]
@@ -33,73 +38,330 @@ func CreateScreening(c *gin.Context) {
 	}
 
 	// Calculate end time (show time + movie duration)
-	var movieDuration int
-	err := config.DB.QueryRow("SELECT duration FROM movies WHERE id = $1", req.MovieID).Scan(&movieDuration)
+	var movie models.Movie
+	err := config.DB.QueryRow(`
+        SELECT title, duration, COALESCE(TO_CHAR(release_date, 'YYYY-MM-DD'), ''), COALESCE(TO_CHAR(end_date, 'YYYY-MM-DD'), '')
+        FROM movies WHERE id = $1
+    `, req.MovieID).Scan(&movie.Title, &movie.Duration, &movie.ReleaseDate, &movie.EndDate)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse("Movie not found", err))
 		return
 	}
 
-	endTime := req.ShowTime.Add(time.Duration(movieDuration) * time.Minute)
+	var timezone string
+	err = config.DB.QueryRow("SELECT timezone FROM theaters WHERE id = $1", req.TheaterID).Scan(&timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Theater not found", err))
+		return
+	}
+
+	showTime := req.ShowTime.In(config.TheaterLocation(timezone))
+	endTime := showTime.Add(time.Duration(movie.Duration) * time.Minute)
 
 	// Get hall capacity
-	var hallCapacity int
-	err = config.DB.QueryRow("SELECT capacity FROM halls WHERE id = $1", req.HallID).Scan(&hallCapacity)
+	var hall models.Hall
+	err = config.DB.QueryRow(
+		"SELECT name, capacity, COALESCE(has_3d_capability, false) FROM halls WHERE id = $1", req.HallID,
+	).Scan(&hall.Name, &hall.Capacity, &hall.Has3DCapability)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse("Hall not found", err))
 		return
 	}
 
+	violations := utils.ScreeningViolations(movie, hall, showTime.Format("2006-01-02"), req.Is3D)
+	if len(violations) > 0 && !req.Override {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Screening breaks scheduling rules", errors.New(strings.Join(violations, "; "))))
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
 	var screeningID int
-	err = config.DB.QueryRow(`
+	err = tx.QueryRow(`
         INSERT INTO screenings 
         (movie_id, theater_id, hall_id, show_time, end_time, price, price_3d, available_seats, is_3d, is_available)
         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
         RETURNING id
-    `, req.MovieID, req.TheaterID, req.HallID, req.ShowTime, endTime,
-		req.Price, req.Price3D, hallCapacity, req.Is3D, true).Scan(&screeningID)
+    `, req.MovieID, req.TheaterID, req.HallID, showTime, endTime,
+		req.Price, req.Price3D, hall.Capacity, req.Is3D, true).Scan(&screeningID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create screening", err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.SuccessResponse("Screening created successfully", gin.H{"id": screeningID}))
+	data := gin.H{"id": screeningID}
+	if len(violations) > 0 {
+		entry := screeningOverrideEntry(c.GetInt("user_id"), screeningID, req.OverrideReason, violations)
+		if err := recordAudit(tx, entry); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to record override", err))
+			return
+		}
+		data["overridden_rules"] = violations
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create screening", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse("Screening created successfully", data))
+}
+
+const screeningColumns = `
+        s.id, s.movie_id, s.theater_id, s.hall_id, s.show_time, s.end_time, t.timezone, s.price,
+        s.price_3d, s.available_seats, s.is_3d, s.is_available, s.created_at, s.updated_at`
+
+// screeningInclude is a related record include= can embed in a screening,
+// read through a join in the same query. Theaters are always joined for
+// their time zone.
+type screeningInclude struct {
+	name    string
+	columns string
+	join    string
+	dest    func(s *models.Screening) []interface{}
+}
+
+// screeningIncludes are listed in the order their columns are selected
+var screeningIncludes = []screeningInclude{
+	{
+		name:    "movie",
+		columns: movieColumns,
+		join:    "JOIN movies m ON m.id = s.movie_id",
+		dest: func(s *models.Screening) []interface{} {
+			s.Movie = &models.Movie{}
+			return movieDest(s.Movie)
+		},
+	},
+	{
+		name:    "theater",
+		columns: theaterColumns,
+		dest: func(s *models.Screening) []interface{} {
+			s.Theater = &models.Theater{}
+			return theaterDest(s.Theater)
+		},
+	},
+	{
+		name:    "hall",
+		columns: hallColumns,
+		join:    "JOIN halls h ON h.id = s.hall_id",
+		dest: func(s *models.Screening) []interface{} {
+			s.Hall = &models.Hall{}
+			return hallDest(s.Hall)
+		},
+	},
+}
+
+// parseScreeningIncludes reads a comma separated include parameter such as
+// "movie,hall", ignoring repeats
+func parseScreeningIncludes(value string) ([]screeningInclude, error) {
+	requested := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			requested[name] = true
+		}
+	}
+
+	var includes []screeningInclude
+	for _, include := range screeningIncludes {
+		if requested[include.name] {
+			includes = append(includes, include)
+			delete(requested, include.name)
+		}
+	}
+	for name := range requested {
+		return nil, fmt.Errorf("unknown include %q, expected movie, theater or hall", name)
+	}
+	return includes, nil
+}
+
+// screeningSelect returns the SELECT and FROM of a screening query joining
+// the included records. Screenings are aliased s and their theaters t.
+func screeningSelect(includes []screeningInclude) string {
+	columns := screeningColumns
+	from := "FROM screenings s\n        JOIN theaters t ON t.id = s.theater_id"
+	for _, include := range includes {
+		columns += "," + include.columns
+		if include.join != "" {
+			from += "\n        " + include.join
+		}
+	}
+	return "SELECT " + columns + "\n        " + from
+}
+
+// scanScreening reads a row selected by screeningSelect with the same includes
+func scanScreening(row rowScanner, includes []screeningInclude) (models.Screening, error) {
+	var s models.Screening
+	dest := []interface{}{
+		&s.ID, &s.MovieID, &s.TheaterID, &s.HallID, &s.ShowTime, &s.EndTime, &s.Timezone,
+		&s.Price, &s.Price3D, &s.AvailableSeats, &s.Is3D, &s.IsAvailable,
+		&s.CreatedAt, &s.UpdatedAt,
+	}
+	for _, include := range includes {
+		dest = append(dest, include.dest(&s)...)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return s, err
+	}
+
+	loc := config.TheaterLocation(s.Timezone)
+	s.ShowTime, s.ShowTimeLocal = s.ShowTime.UTC(), s.ShowTime.In(loc)
+	s.EndTime, s.EndTimeLocal = s.EndTime.UTC(), s.EndTime.In(loc)
+	return s, nil
+}
+
+// Screening lists are paged 20 rows at a time unless limit asks otherwise
+const defaultScreeningPageSize = 20
+
+// screeningSort is a column screening lists may be sorted by. cast is the
+// type a cursor value is compared as, value reads it from the last row.
+type screeningSort struct {
+	column string
+	cast   string
+	value  func(s models.Screening) string
+}
+
+var screeningSorts = map[string]screeningSort{
+	"show_time": {"s.show_time", "timestamptz", func(s models.Screening) string {
+		return s.ShowTime.Format(time.RFC3339Nano)
+	}},
+	"price": {"s.price", "numeric", func(s models.Screening) string {
+		return s.Price.String()
+	}},
+	"available_seats": {"s.available_seats", "integer", func(s models.Screening) string {
+		return strconv.Itoa(s.AvailableSeats)
+	}},
+	"created_at": {"s.created_at", "timestamp", func(s models.Screening) string {
+		return s.CreatedAt.Format(time.RFC3339Nano)
+	}},
 }
 
 // GetScreenings godoc
 //
 //	@Summary		Get all screenings
-//	@Description	Get list of all available screenings
+//	@Description	Get a page of screenings, upcoming ones unless date_from is given. Pass pagination.next_cursor as cursor to get the next page.
 //	@Tags			screenings
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Success		200	{object}	models.Response{data=[]models.Screening}	"Screenings fetched successfully"
-//	@Failure		401	{object}	models.Response								"Unauthorized"
-//	@Failure		500	{object}	models.Response								"Internal server error"
+//	@Param			movie_id		query		int											false	"Movie ID"
+//	@Param			theater_id		query		int											false	"Theater ID"
+//	@Param			hall_id			query		int											false	"Hall ID"
+//	@Param			date_from		query		string										false	"First show date at the theater (YYYY-MM-DD)"
+//	@Param			date_to			query		string										false	"Last show date at the theater (YYYY-MM-DD)"
+//	@Param			is_3d			query		bool										false	"Only 3D or only 2D screenings"
+//	@Param			is_available	query		bool										false	"Only available or only unavailable screenings"
+//	@Param			min_price		query		string										false	"Minimum base price"
+//	@Param			max_price		query		string										false	"Maximum base price"
+//	@Param			sort			query		string										false	"Sort field"	Enums(show_time, price, available_seats, created_at)	default(show_time)
+//	@Param			order			query		string										false	"Sort order"	Enums(asc, desc)	default(asc)
+//	@Param			limit			query		int											false	"Page size (1-100)"	default(20)
+//	@Param			cursor			query		string										false	"Cursor from the previous page"
+//	@Param			include			query		string										false	"Related records to embed, comma separated: movie, theater, hall"
+//	@Success		200				{object}	models.Response{data=[]models.Screening}	"Screenings fetched successfully"
+//	@Failure		400				{object}	models.Response								"Invalid query parameters"
+//	@Failure		401				{object}	models.Response								"Unauthorized"
+//	@Failure		500				{object}	models.Response								"Internal server error"
 //	@Router			/screenings [get]
 func GetScreenings(c *gin.Context) {
-	rows, err := config.DB.Query(`
-        SELECT id, movie_id, theater_id, hall_id, show_time, end_time, price, 
-               price_3d, available_seats, is_3d, is_available, created_at, updated_at
-        FROM screenings 
-        WHERE show_time > NOW()
-        ORDER BY show_time
-    `)
+	var filter models.ScreeningFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid query parameters", err))
+		return
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = "show_time"
+	}
+	sort, ok := screeningSorts[filter.Sort]
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid sort field", nil))
+		return
+	}
+	if filter.Order == "" {
+		filter.Order = "asc"
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultScreeningPageSize
+	}
+	includes, err := parseScreeningIncludes(filter.Include)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid include", err))
+		return
+	}
+
+	var q utils.QueryBuilder
+	if filter.DateFrom == "" {
+		q.Where("s.show_time > NOW()")
+	} else {
+		q.Where("s.show_time >= ?::date::timestamp AT TIME ZONE t.timezone", filter.DateFrom)
+	}
+	if filter.DateTo != "" {
+		q.Where("s.show_time < (?::date + 1)::timestamp AT TIME ZONE t.timezone", filter.DateTo)
+	}
+	if filter.MovieID != 0 {
+		q.Where("s.movie_id = ?", filter.MovieID)
+	}
+	if filter.TheaterID != 0 {
+		q.Where("s.theater_id = ?", filter.TheaterID)
+	}
+	if filter.HallID != 0 {
+		q.Where("s.hall_id = ?", filter.HallID)
+	}
+	if filter.Is3D != nil {
+		q.Where("s.is_3d = ?", *filter.Is3D)
+	}
+	if filter.IsAvailable != nil {
+		q.Where("s.is_available = ?", *filter.IsAvailable)
+	}
+	if filter.MinPrice != nil {
+		q.Where("s.price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		q.Where("s.price <= ?", *filter.MaxPrice)
+	}
+
+	// Keyset pagination: rows strictly after the cursor in the sort order,
+	// with the id breaking ties between equal sort values
+	direction, comparison := "ASC", ">"
+	if filter.Order == "desc" {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.Cursor != "" {
+		cursor, err := utils.DecodeCursor(filter.Cursor)
+		if err == nil && (cursor.Sort != filter.Sort || cursor.Order != filter.Order) {
+			err = errors.New("cursor belongs to a different sort order")
+		}
+		if err == nil {
+			err = cursor.CheckValue(sort.cast)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid cursor", err))
+			return
+		}
+		q.Where(fmt.Sprintf("(%s, s.id) %s (?::%s, ?)", sort.column, comparison, sort.cast), cursor.Value, cursor.ID)
+	}
+
+	// One row more than the page tells whether another page follows
+	rows, err := config.DB.Query(fmt.Sprintf(`
+        %s
+        %s
+        ORDER BY %s %s, s.id %s
+        LIMIT %s
+    `, screeningSelect(includes), q.WhereClause(), sort.column, direction, direction, q.Arg(filter.Limit+1)), q.Args()...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to fetch screenings", err))
 		return
 	}
 	defer rows.Close()
 
-	var screenings []models.Screening
+	screenings := []models.Screening{}
 	for rows.Next() {
-		var s models.Screening
-		err := rows.Scan(
-			&s.ID, &s.MovieID, &s.TheaterID, &s.HallID, &s.ShowTime, &s.EndTime,
-			&s.Price, &s.Price3D, &s.AvailableSeats, &s.Is3D, &s.IsAvailable,
-			&s.CreatedAt, &s.UpdatedAt,
-		)
+		s, err := scanScreening(rows, includes)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to scan screening", err))
 			return
@@ -107,7 +369,20 @@ func GetScreenings(c *gin.Context) {
 		screenings = append(screenings, s)
 	}
 
-	c.JSON(http.StatusOK, models.SuccessResponse("Screenings fetched successfully", screenings))
+	pagination := models.Pagination{Limit: filter.Limit}
+	if len(screenings) > filter.Limit {
+		screenings = screenings[:filter.Limit]
+		last := screenings[len(screenings)-1]
+		pagination.HasMore = true
+		pagination.NextCursor = utils.EncodeCursor(utils.Cursor{
+			Sort:  filter.Sort,
+			Order: filter.Order,
+			Value: sort.value(last),
+			ID:    last.ID,
+		})
+	}
+
+	c.JSON(http.StatusOK, models.PaginatedResponse("Screenings fetched successfully", screenings, pagination))
 }
 
 // GetScreening godoc
@@ -118,12 +393,13 @@ func GetScreenings(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id	path		int										true	"Screening ID"
-//	@Success		200	{object}	models.Response{data=models.Screening}	"Screening fetched successfully"
-//	@Failure		400	{object}	models.Response							"Invalid ID"
-//	@Failure		401	{object}	models.Response							"Unauthorized"
-//	@Failure		404	{object}	models.Response							"Screening not found"
-//	@Failure		500	{object}	models.Response							"Internal server error"
+//	@Param			id		path		int										true	"Screening ID"
+//	@Param			include	query		string									false	"Related records to embed, comma separated: movie, theater, hall"
+//	@Success		200		{object}	models.Response{data=models.Screening}	"Screening fetched successfully"
+//	@Failure		400		{object}	models.Response							"Invalid ID or include"
+//	@Failure		401		{object}	models.Response							"Unauthorized"
+//	@Failure		404		{object}	models.Response							"Screening not found"
+//	@Failure		500		{object}	models.Response							"Internal server error"
 //	@Router			/screenings/{id} [get]
 func GetScreening(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -132,17 +408,13 @@ func GetScreening(c *gin.Context) {
 		return
 	}
 
-	var screening models.Screening
-	err = config.DB.QueryRow(`
-        SELECT id, movie_id, theater_id, hall_id, show_time, end_time, price, 
-               price_3d, available_seats, is_3d, is_available, created_at, updated_at
-        FROM screenings WHERE id = $1
-    `, id).Scan(
-		&screening.ID, &screening.MovieID, &screening.TheaterID, &screening.HallID,
-		&screening.ShowTime, &screening.EndTime, &screening.Price, &screening.Price3D,
-		&screening.AvailableSeats, &screening.Is3D, &screening.IsAvailable,
-		&screening.CreatedAt, &screening.UpdatedAt,
-	)
+	includes, err := parseScreeningIncludes(c.Query("include"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid include", err))
+		return
+	}
+
+	screening, err := scanScreening(config.DB.QueryRow(screeningSelect(includes)+" WHERE s.id = $1", id), includes)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, models.ErrorResponse("Screening not found", nil))
@@ -158,7 +430,7 @@ func GetScreening(c *gin.Context) {
 // UpdateScreening godoc
 //
 //	@Summary		Update a screening
-//	@Description	Update an existing screening (Admin only)
+//	@Description	Update an existing screening. The updated screening is checked against the movie's run and the hall's 3D capability like a new one, and override works the same way. Changing the show time or the movie moves the end time to match. (Admin only)
 //	@Tags			screenings
 //	@Accept			json
 //	@Produce		json
@@ -166,7 +438,7 @@ func GetScreening(c *gin.Context) {
 //	@Param			id					path		int								true	"Screening ID"
 //	@Param			screeningRequest	body		models.UpdateScreeningRequest	true	"Screening data to update"
 //	@Success		200					{object}	models.Response					"Screening updated successfully"
-//	@Failure		400					{object}	models.Response					"Invalid request"
+//	@Failure		400					{object}	models.Response					"Invalid request or scheduling rule broken"
 //	@Failure		401					{object}	models.Response					"Unauthorized"
 //	@Failure		404					{object}	models.Response					"Screening not found"
 //	@Failure		500					{object}	models.Response					"Internal server error"
@@ -184,8 +456,38 @@ func UpdateScreening(c *gin.Context) {
 		return
 	}
 
-	// Build dynamic update query
-	query := "UPDATE screenings SET updated_at = NOW()"
+	// Check the screening as it will be after the update. Fields left out
+	// keep their current values and local show times follow the zone of the
+	// theater the screening ends up at.
+	var movie models.Movie
+	var hall models.Hall
+	var showTime time.Time
+	var timezone string
+	err = config.DB.QueryRow(`
+        SELECT s.show_time, t.timezone, m.title, m.duration,
+               COALESCE(TO_CHAR(m.release_date, 'YYYY-MM-DD'), ''), COALESCE(TO_CHAR(m.end_date, 'YYYY-MM-DD'), ''),
+               h.name, COALESCE(h.has_3d_capability, false)
+        FROM screenings s
+        JOIN theaters t ON t.id = COALESCE(NULLIF($2, 0), s.theater_id)
+        JOIN movies m ON m.id = COALESCE(NULLIF($3, 0), s.movie_id)
+        JOIN halls h ON h.id = COALESCE(NULLIF($4, 0), s.hall_id)
+        WHERE s.id = $1
+    `, id, req.TheaterID, req.MovieID, req.HallID).Scan(
+		&showTime, &timezone, &movie.Title, &movie.Duration, &movie.ReleaseDate, &movie.EndDate, &hall.Name, &hall.Has3DCapability,
+	)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Screening, movie, theater or hall not found", nil))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	loc := config.TheaterLocation(timezone)
+
+	// Build dynamic update query. A screening of a schedule edited on its
+	// own is left alone by later edits of the schedule.
+	query := "UPDATE screenings SET updated_at = NOW(), schedule_exception = schedule_id IS NOT NULL"
 	params := []interface{}{}
 	paramCount := 1
 
@@ -208,18 +510,26 @@ func UpdateScreening(c *gin.Context) {
 	}
 
 	if !req.ShowTime.IsZero() {
+		showTime = req.ShowTime.In(loc)
 		query += ", show_time = $" + strconv.Itoa(paramCount)
-		params = append(params, req.ShowTime)
+		params = append(params, showTime)
 		paramCount++
 	}
 
-	if req.Price != 0 {
+	// The screening ends one movie duration after it starts, like a new one
+	if !req.ShowTime.IsZero() || req.MovieID != 0 {
+		query += ", end_time = $" + strconv.Itoa(paramCount)
+		params = append(params, showTime.Add(time.Duration(movie.Duration)*time.Minute))
+		paramCount++
+	}
+
+	if !req.Price.IsZero() {
 		query += ", price = $" + strconv.Itoa(paramCount)
 		params = append(params, req.Price)
 		paramCount++
 	}
 
-	if req.Price3D != 0 {
+	if !req.Price3D.IsZero() {
 		query += ", price_3d = $" + strconv.Itoa(paramCount)
 		params = append(params, req.Price3D)
 		paramCount++
@@ -236,7 +546,20 @@ func UpdateScreening(c *gin.Context) {
 	query += " WHERE id = $" + strconv.Itoa(paramCount)
 	params = append(params, id)
 
-	result, err := config.DB.Exec(query, params...)
+	violations := utils.ScreeningViolations(movie, hall, showTime.In(loc).Format("2006-01-02"), req.Is3D)
+	if len(violations) > 0 && !req.Override {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Screening breaks scheduling rules", errors.New(strings.Join(violations, "; "))))
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, params...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update screening", err))
 		return
@@ -248,23 +571,39 @@ func UpdateScreening(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.SuccessResponse("Screening updated successfully", nil))
+	var data interface{}
+	if len(violations) > 0 {
+		entry := screeningOverrideEntry(c.GetInt("user_id"), id, req.OverrideReason, violations)
+		if err := recordAudit(tx, entry); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to record override", err))
+			return
+		}
+		data = gin.H{"overridden_rules": violations}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update screening", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Screening updated successfully", data))
 }
 
 // DeleteScreening godoc
 //
 //	@Summary		Delete a screening
-//	@Description	Soft delete a screening by setting is_available to false (Admin only)
+//	@Description	Cancel a screening by setting is_available to false (Admin only). Paid bookings are refunded in full, pending bookings are cancelled and every affected customer is notified. Deleting a cancelled screening again finishes the bookings left pending or paid; failed gateway refunds are retried in the background.
 //	@Tags			screenings
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id	path		int				true	"Screening ID"
-//	@Success		200	{object}	models.Response	"Screening deleted successfully"
-//	@Failure		400	{object}	models.Response	"Invalid ID"
-//	@Failure		401	{object}	models.Response	"Unauthorized"
-//	@Failure		404	{object}	models.Response	"Screening not found"
-//	@Failure		500	{object}	models.Response	"Internal server error"
+//	@Param			id				path		int														true	"Screening ID"
+//	@Param			cancelRequest	body		models.CancelScreeningRequest							false	"Cancellation reason"
+//	@Success		200				{object}	models.Response{data=models.CancelScreeningResult}	"Screening deleted successfully"
+//	@Failure		400				{object}	models.Response											"Invalid ID"
+//	@Failure		401				{object}	models.Response											"Unauthorized"
+//	@Failure		404				{object}	models.Response											"Screening not found"
+//	@Failure		500				{object}	models.Response											"Internal server error"
 //	@Router			/screenings/{id} [delete]
 func DeleteScreening(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -273,17 +612,211 @@ func DeleteScreening(c *gin.Context) {
 		return
 	}
 
-	result, err := config.DB.Exec("UPDATE screenings SET is_available = false WHERE id = $1", id)
+	var req models.CancelScreeningRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+			return
+		}
+	}
+
+	result, err := config.DB.Exec(`
+        UPDATE screenings
+        SET is_available = false, cancellation_reason = $1, cancelled_at = NOW(), updated_at = NOW(),
+            schedule_exception = schedule_id IS NOT NULL
+        WHERE id = $2 AND cancelled_at IS NULL
+    `, req.Reason, id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to delete screening", err))
 		return
 	}
 
+	// A screening cancelled before keeps its reason and time; deleting it
+	// again only finishes the cancellations and refunds left outstanding
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, models.ErrorResponse("Screening not found", nil))
+		err := config.DB.QueryRow("SELECT COALESCE(cancellation_reason, '') FROM screenings WHERE id = $1", id).Scan(&req.Reason)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, models.ErrorResponse("Screening not found", nil))
+			} else {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+			}
+			return
+		}
+	}
+
+	summary, err := cancelScreeningBookings(id, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to cancel bookings", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, models.SuccessResponse("Screening deleted successfully", nil))
+	c.JSON(http.StatusOK, models.SuccessResponse("Screening deleted successfully", summary))
+}
+
+// cancelScreeningBookings cancels pending bookings, returning any gift card
+// value, loyalty points and concession stock they hold, and fully refunds
+// paid bookings of a cancelled screening. Each refund runs in its own
+// transaction; a booking whose refund fails to commit stays paid for a later
+// run, and a gateway refund that fails is left to the refund retry job.
+func cancelScreeningBookings(screeningID int, reason string) (models.CancelScreeningResult, error) {
+	var summary models.CancelScreeningResult
+
+	note := "Screening cancelled"
+	if reason != "" {
+		note += ": " + reason
+	}
+
+	notified, err := cancelPendingBookings(screeningID, note)
+	if err != nil {
+		return summary, err
+	}
+	summary.CancelledBookings = len(notified)
+
+	rows, err := config.DB.Query(`
+        SELECT id, user_id, total_amount, COALESCE(payment_reference, '')
+        FROM bookings WHERE screening_id = $1 AND status = $2
+    `, screeningID, models.BookingStatusPaid)
+	if err != nil {
+		return summary, err
+	}
+
+	var paid []models.Booking
+	for rows.Next() {
+		var b models.Booking
+		if err := rows.Scan(&b.ID, &b.UserID, &b.TotalAmount, &b.PaymentReference); err != nil {
+			rows.Close()
+			return summary, err
+		}
+		paid = append(paid, b)
+	}
+	rows.Close()
+
+	for _, b := range paid {
+		err := refundCancelledBooking(b, note)
+		if errors.Is(err, errBookingNotPaid) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to refund booking %d of cancelled screening %d: %v", b.ID, screeningID, err)
+			summary.FailedRefunds++
+			continue
+		}
+		b.Status = models.BookingStatusRefunded
+		notified = append(notified, b)
+		summary.RefundedBookings++
+	}
+
+	summary.AffectedBookings = summary.CancelledBookings + summary.RefundedBookings + summary.FailedRefunds
+
+	for _, b := range notified {
+		message := fmt.Sprintf("Your booking #%d has been cancelled because the screening was cancelled.", b.ID)
+		if b.Status == models.BookingStatusRefunded {
+			message = fmt.Sprintf("Your booking #%d has been refunded in full because the screening was cancelled.", b.ID)
+		}
+		if reason != "" {
+			message += " Reason: " + reason
+		}
+		notifyUser(b.UserID, "Screening cancelled", message)
+	}
+
+	return summary, nil
+}
+
+var errBookingNotPaid = errors.New("booking is no longer paid")
+
+func refundCancelledBooking(b models.Booking, note string) error {
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Re-check the status under lock in case the customer refunded concurrently
+	var status string
+	err = tx.QueryRow("SELECT status FROM bookings WHERE id = $1 FOR UPDATE", b.ID).Scan(&status)
+	if err != nil {
+		return err
+	}
+	if status != models.BookingStatusPaid {
+		return errBookingNotPaid
+	}
+
+	refund, err := refundBooking(tx, b.ID, b.TotalAmount, 100, note, b.PaymentReference)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return sendRefund(&refund, b.PaymentReference)
+}
+
+// cancelPendingBookings cancels the pending bookings of a screening and
+// gives back everything they hold in one transaction, so a failure leaves
+// them pending for a later run
+func cancelPendingBookings(screeningID int, note string) ([]models.Booking, error) {
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+        UPDATE bookings SET status = $1, updated_at = NOW()
+        WHERE screening_id = $2 AND status = $3
+        RETURNING id, user_id, gift_card_amount, loyalty_points
+    `, models.BookingStatusCancelled, screeningID, models.BookingStatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		cancelled []models.Booking
+		ids       []int
+	)
+	for rows.Next() {
+		b := models.Booking{Status: models.BookingStatusCancelled}
+		if err := rows.Scan(&b.ID, &b.UserID, &b.GiftCardAmount, &b.LoyaltyPoints); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		cancelled = append(cancelled, b)
+		ids = append(ids, b.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(cancelled) == 0 {
+		return nil, nil
+	}
+
+	if err := restockConcessions(tx, ids, 1); err != nil {
+		return nil, err
+	}
+
+	for _, b := range cancelled {
+		if b.GiftCardAmount.Amount <= 0 && b.LoyaltyPoints == 0 {
+			continue
+		}
+		if err := releaseBookingCredits(tx, b, note); err != nil {
+			return nil, err
+		}
+	}
+
+	return cancelled, tx.Commit()
+}
+
+// releaseBookingCredits gives the gift card value and loyalty points held by
+// a cancelled or failed pending booking back to their owners
+func releaseBookingCredits(tx *sql.Tx, b models.Booking, note string) error {
+	if _, err := returnGiftCardValue(tx, b.ID, b.GiftCardAmount, note); err != nil {
+		return err
+	}
+
+	return reverseLoyaltyPoints(tx, b.ID, 100, note)
 }
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// screeningExportColumns head the CSV and Excel exports
+var screeningExportColumns = []string{
+	"Screening ID", "Theater", "Hall", "Movie", "Date", "Start", "End", "Time zone",
+	"3D", "Price", "3D price", "Capacity", "Seats sold", "Available seats", "Occupancy %",
+}
+
+// screeningExportContentTypes are the formats an export can be downloaded in
+var screeningExportContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"json": "application/json",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// screeningExportWriter writes an export one row at a time, so only the
+// row being written is held in memory. Close finishes the file; Abort gives
+// up on it, leaving the download cut short, and only releases what the
+// writer holds.
+type screeningExportWriter interface {
+	Write(row models.ScreeningExportRow) error
+	Close() error
+	Abort()
+}
+
+// ExportScreenings godoc
+//
+//	@Summary		Export the screening schedule
+//	@Description	Download the bookable screenings between two dates, of every theater or of one, as CSV (default), a JSON array or an Excel workbook. Dates and times are local to each theater. Rows are streamed as they are read. (Admin only)
+//	@Tags			screenings
+//	@Produce		text/csv
+//	@Produce		json
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Security		BearerAuth
+//	@Param			date_from	query		string							true	"First show date (YYYY-MM-DD)"
+//	@Param			date_to		query		string							true	"Last show date (YYYY-MM-DD)"
+//	@Param			theater_id	query		int								false	"Theater ID"
+//	@Param			format		query		string							false	"File format"	Enums(csv, json, xlsx)	default(csv)
+//	@Success		200			{array}		models.ScreeningExportRow		"Schedule export"
+//	@Failure		400			{object}	models.Response					"Invalid query parameters"
+//	@Failure		401			{object}	models.Response					"Unauthorized"
+//	@Failure		500			{object}	models.Response					"Internal server error"
+//	@Router			/screenings/export [get]
+func ExportScreenings(c *gin.Context) {
+	var query models.ScreeningExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid query parameters", err))
+		return
+	}
+	if query.DateTo < query.DateFrom {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("date_to is before date_from", nil))
+		return
+	}
+	if query.Format == "" {
+		query.Format = "csv"
+	}
+
+	var q utils.QueryBuilder
+	q.Where("s.is_available = true")
+	q.Where("s.show_time >= ?::date::timestamp AT TIME ZONE t.timezone", query.DateFrom)
+	q.Where("s.show_time < (?::date + 1)::timestamp AT TIME ZONE t.timezone", query.DateTo)
+	if query.TheaterID != 0 {
+		q.Where("s.theater_id = ?", query.TheaterID)
+	}
+
+	rows, err := config.DB.Query(fmt.Sprintf(`
+        SELECT s.id, t.name, h.name, m.title, s.show_time, s.end_time, t.timezone,
+               s.is_3d, s.price, COALESCE(s.price_3d, 0), h.capacity, s.available_seats
+        FROM screenings s
+        JOIN theaters t ON t.id = s.theater_id
+        JOIN halls h ON h.id = s.hall_id
+        JOIN movies m ON m.id = s.movie_id
+        %s
+        ORDER BY t.name, t.id, s.show_time, h.name, s.id
+    `, q.WhereClause()), q.Args()...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to export screenings", err))
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("screenings-%s-to-%s.%s", query.DateFrom, query.DateTo, query.Format)
+	c.Header("Content-Type", screeningExportContentTypes[query.Format])
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	var w screeningExportWriter
+	switch query.Format {
+	case "json":
+		w = &jsonExportWriter{out: c.Writer}
+	case "xlsx":
+		w, err = newXLSXExportWriter(c.Writer)
+	default:
+		w, err = newCSVExportWriter(c.Writer)
+	}
+
+	// The status is sent by now, so failures can only be logged and cut the
+	// download short
+	if err == nil {
+		err = writeScreeningExport(rows, w)
+	}
+	if err != nil {
+		log.Printf("Failed to export screenings from %s to %s: %v", query.DateFrom, query.DateTo, err)
+	}
+}
+
+func writeScreeningExport(rows *sql.Rows, w screeningExportWriter) (err error) {
+	defer func() {
+		if err != nil {
+			w.Abort()
+		}
+	}()
+
+	for rows.Next() {
+		var row models.ScreeningExportRow
+		var showTime, endTime time.Time
+		err := rows.Scan(
+			&row.ScreeningID, &row.Theater, &row.Hall, &row.Movie, &showTime, &endTime, &row.Timezone,
+			&row.Is3D, &row.Price, &row.Price3D, &row.Capacity, &row.AvailableSeats,
+		)
+		if err != nil {
+			return err
+		}
+
+		loc := config.TheaterLocation(row.Timezone)
+		showTime, endTime = showTime.In(loc), endTime.In(loc)
+		row.Date = showTime.Format("2006-01-02")
+		row.StartTime = showTime.Format("15:04")
+		row.EndTime = endTime.Format("15:04")
+		row.SeatsSold = row.Capacity - row.AvailableSeats
+		if row.Capacity > 0 {
+			row.Occupancy = math.Round(float64(row.SeatsSold)*1000/float64(row.Capacity)) / 10
+		}
+
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// screeningExportRecord returns the cells of a row in the order of
+// screeningExportColumns
+func screeningExportRecord(row models.ScreeningExportRow) []string {
+	return []string{
+		strconv.Itoa(row.ScreeningID), row.Theater, row.Hall, row.Movie,
+		row.Date, row.StartTime, row.EndTime, row.Timezone,
+		strconv.FormatBool(row.Is3D), row.Price.String(), row.Price3D.String(),
+		strconv.Itoa(row.Capacity), strconv.Itoa(row.SeatsSold), strconv.Itoa(row.AvailableSeats),
+		strconv.FormatFloat(row.Occupancy, 'f', 1, 64),
+	}
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func newCSVExportWriter(out io.Writer) (*csvExportWriter, error) {
+	w := csv.NewWriter(out)
+	return &csvExportWriter{w: w}, w.Write(screeningExportColumns)
+}
+
+func (e *csvExportWriter) Write(row models.ScreeningExportRow) error {
+	return e.w.Write(screeningExportRecord(row))
+}
+
+func (e *csvExportWriter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExportWriter) Abort() {}
+
+// jsonExportWriter writes a JSON array one element at a time
+type jsonExportWriter struct {
+	out  io.Writer
+	rows int
+}
+
+func (e *jsonExportWriter) Write(row models.ScreeningExportRow) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	separator := ","
+	if e.rows == 0 {
+		separator = "["
+	}
+	e.rows++
+	_, err = io.WriteString(e.out, separator+string(data))
+	return err
+}
+
+func (e *jsonExportWriter) Close() error {
+	end := "]"
+	if e.rows == 0 {
+		end = "[]"
+	}
+	_, err := io.WriteString(e.out, end)
+	return err
+}
+
+func (e *jsonExportWriter) Abort() {}
+
+// xlsxExportWriter fills a worksheet through excelize's stream writer, which
+// moves rows to a temporary file once they outgrow its memory buffer. The
+// workbook is written out when it is closed.
+type xlsxExportWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	rows   int
+}
+
+func newXLSXExportWriter(out io.Writer) (*xlsxExportWriter, error) {
+	file := excelize.NewFile()
+	sheet := file.GetSheetName(0)
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	e := &xlsxExportWriter{out: out, file: file, stream: stream, rows: 1}
+
+	bold, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := stream.SetColWidth(2, 4, 28); err != nil {
+		file.Close()
+		return nil, err
+	}
+	header := make([]interface{}, len(screeningExportColumns))
+	for i, name := range screeningExportColumns {
+		header[i] = excelize.Cell{StyleID: bold, Value: name}
+	}
+	if err := stream.SetRow("A1", header, excelize.RowOpts{}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *xlsxExportWriter) Write(row models.ScreeningExportRow) error {
+	e.rows++
+	cell, err := excelize.CoordinatesToCellName(1, e.rows)
+	if err != nil {
+		return err
+	}
+	price, _ := strconv.ParseFloat(row.Price.String(), 64)
+	price3D, _ := strconv.ParseFloat(row.Price3D.String(), 64)
+	return e.stream.SetRow(cell, []interface{}{
+		row.ScreeningID, row.Theater, row.Hall, row.Movie,
+		row.Date, row.StartTime, row.EndTime, row.Timezone,
+		row.Is3D, price, price3D,
+		row.Capacity, row.SeatsSold, row.AvailableSeats, row.Occupancy,
+	})
+}
+
+func (e *xlsxExportWriter) Close() error {
+	defer e.Abort()
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.out)
+}
+
+// Abort removes the temporary file the stream writer may have spilled to.
+// It can be called again after Close.
+func (e *xlsxExportWriter) Abort() {
+	if e.file != nil {
+		e.file.Close()
+		e.file = nil
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+// screeningExportRows returns one screening 96 of 150 seats sold, at 18:00
+// in Makassar (WITA)
+func screeningExportRows() *sqlmock.Rows {
+	showTime := time.Date(2025, 12, 25, 10, 0, 0, 0, time.UTC)
+	return sqlmock.NewRows([]string{
+		"id", "theater", "hall", "title", "show_time", "end_time", "timezone",
+		"is_3d", "price", "price_3d", "capacity", "available_seats",
+	}).AddRow(4, "XXI Trans Studio", "Hall 1", "Avatar", showTime, showTime.Add(192*time.Minute), "Asia/Makassar",
+		false, 50000.0, 0.0, 150, 54)
+}
+
+func expectScreeningExport(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("FROM screenings s JOIN theaters t ON t.id = s.theater_id JOIN halls h ON h.id = s.hall_id JOIN movies m ON m.id = s.movie_id WHERE s.is_available = true AND s.show_time >= \\$1::date::timestamp AT TIME ZONE t.timezone AND s.show_time < \\(\\$2::date \\+ 1\\)::timestamp AT TIME ZONE t.timezone AND s.theater_id = \\$3 ORDER BY t.name").
+		WithArgs("2025-12-25", "2025-12-31", 3).
+		WillReturnRows(screeningExportRows())
+}
+
+func exportScreenings(t *testing.T, format string) *httptest.ResponseRecorder {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	config.DB = db
+	expectScreeningExport(mock)
+
+	router := setupTestRouter()
+	router.GET("/screenings/export", ExportScreenings)
+
+	req, _ := http.NewRequest("GET", "/screenings/export?theater_id=3&date_from=2025-12-25&date_to=2025-12-31&format="+format, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+	return w
+}
+
+func TestExportScreenings_CSV(t *testing.T) {
+	w := exportScreenings(t, "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="screenings-2025-12-25-to-2025-12-31.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t,
+		"Screening ID,Theater,Hall,Movie,Date,Start,End,Time zone,3D,Price,3D price,Capacity,Seats sold,Available seats,Occupancy %\n"+
+			"4,XXI Trans Studio,Hall 1,Avatar,2025-12-25,18:00,21:12,Asia/Makassar,false,50000.00,0.00,150,96,54,64.0\n",
+		w.Body.String())
+}
+
+func TestExportScreenings_JSON(t *testing.T) {
+	w := exportScreenings(t, "json")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var rows []models.ScreeningExportRow
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, "18:00", rows[0].StartTime)
+		assert.Equal(t, 96, rows[0].SeatsSold)
+		assert.Equal(t, 64.0, rows[0].Occupancy)
+	}
+}
+
+func TestExportScreenings_XLSX(t *testing.T) {
+	w := exportScreenings(t, "xlsx")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	file, err := excelize.OpenReader(w.Body)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer file.Close()
+
+	rows, err := file.GetRows(file.GetSheetName(0))
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, "Screening ID", rows[0][0])
+		assert.Equal(t, []string{"4", "XXI Trans Studio", "Hall 1", "Avatar", "2025-12-25", "18:00", "21:12"}, rows[1][:7])
+	}
+}
+
+// recordingExportWriter fails every write and records how it was ended
+type recordingExportWriter struct {
+	closed, aborted bool
+}
+
+func (e *recordingExportWriter) Write(row models.ScreeningExportRow) error {
+	return errors.New("client went away")
+}
+
+func (e *recordingExportWriter) Close() error {
+	e.closed = true
+	return nil
+}
+
+func (e *recordingExportWriter) Abort() {
+	e.aborted = true
+}
+
+func TestWriteScreeningExport_AbortsOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM screenings").WillReturnRows(screeningExportRows())
+	rows, err := db.Query("SELECT * FROM screenings")
+	if err != nil {
+		t.Fatalf("Error querying mock database: %v", err)
+	}
+	defer rows.Close()
+
+	w := &recordingExportWriter{}
+	assert.Error(t, writeScreeningExport(rows, w))
+	assert.True(t, w.aborted)
+	assert.False(t, w.closed)
+}
+
+func TestXLSXExportWriter_AbortAfterClose(t *testing.T) {
+	w, err := newXLSXExportWriter(io.Discard)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, w.Close())
+	assert.Nil(t, w.file)
+	assert.NotPanics(t, w.Abort)
+}
+
+func TestExportScreenings_InvalidFormat(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/screenings/export", ExportScreenings)
+
+	req, _ := http.NewRequest("GET", "/screenings/export?date_from=2025-12-25&date_to=2025-12-31&format=pdf", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// An import holds at most maxImportRows screenings in maxImportBytes
+const (
+	maxImportRows  = 1000
+	maxImportBytes = 2 << 20
+)
+
+// screeningImportColumns are the CSV columns of an import and whether each
+// is required
+var screeningImportColumns = map[string]bool{
+	"movie_id":   true,
+	"theater_id": true,
+	"hall_id":    true,
+	"show_time":  true,
+	"price":      true,
+	"price_3d":   false,
+	"is_3d":      false,
+}
+
+// importedScreening is a valid row waiting to be inserted
+type importedScreening struct {
+	row      int
+	req      models.ScreeningImportRow
+	capacity int
+	showTime time.Time
+	endTime  time.Time
+}
+
+// ImportScreenings godoc
+//
+//	@Summary		Import screenings
+//	@Description	Create many screenings from a CSV (Content-Type text/csv) or JSON array body (Admin only). Every row is checked: the movie exists, the hall belongs to the theater, the scheduling rules hold and the screening overlaps neither an existing one nor another row. If any row is invalid nothing is imported and every problem is reported by row. dry_run=true only checks the rows. Show times without a UTC offset are local time at the theater.
+//	@Tags			screenings
+//	@Accept			json
+//	@Accept			text/csv
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dry_run	query		bool												false	"Check the rows without importing them"
+//	@Param			rows	body		[]models.ScreeningImportRow							true	"Screenings to import"
+//	@Success		200		{object}	models.Response{data=models.ScreeningImportResult}	"Import is valid (dry run)"
+//	@Success		201		{object}	models.Response{data=models.ScreeningImportResult}	"Screenings imported successfully"
+//	@Failure		400		{object}	models.Response{data=models.ScreeningImportResult}	"Invalid file or rows"
+//	@Failure		401		{object}	models.Response										"Unauthorized"
+//	@Failure		415		{object}	models.Response										"Unsupported content type"
+//	@Failure		500		{object}	models.Response										"Internal server error"
+//	@Router			/screenings/import [post]
+func ImportScreenings(c *gin.Context) {
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid dry_run", err))
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	var (
+		rows      []models.ScreeningImportRow
+		rowErrors [][]string
+		err       error
+	)
+	switch c.ContentType() {
+	case "text/csv", "application/csv":
+		rows, rowErrors, err = parseScreeningCSV(body)
+	case "application/json":
+		rows, rowErrors, err = parseScreeningJSON(body)
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, models.ErrorResponse("Send the rows as text/csv or application/json", nil))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid import file", err))
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("No rows to import", nil))
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		return
+	}
+	defer tx.Rollback()
+
+	valid, err := checkScreeningImport(tx, rows, rowErrors)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to check rows", err))
+		return
+	}
+
+	result := models.ScreeningImportResult{DryRun: dryRun, Rows: len(rows), Errors: []models.ImportRowError{}}
+	for i, errs := range rowErrors {
+		if len(errs) > 0 {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: i + 1, Errors: errs})
+		}
+	}
+	if len(result.Errors) > 0 {
+		c.JSON(http.StatusBadRequest, models.Response{Success: false, Message: "Import has invalid rows", Data: result})
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, models.SuccessResponse("Import is valid", result))
+		return
+	}
+
+	for _, s := range valid {
+		var screeningID int
+		err := tx.QueryRow(`
+            INSERT INTO screenings
+            (movie_id, theater_id, hall_id, show_time, end_time, price, price_3d, available_seats, is_3d, is_available)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+            RETURNING id
+        `, s.req.MovieID, s.req.TheaterID, s.req.HallID, s.showTime, s.endTime,
+			s.req.Price, s.req.Price3D, s.capacity, s.req.Is3D, true).Scan(&screeningID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse(fmt.Sprintf("Failed to import row %d", s.row), err))
+			return
+		}
+		result.ScreeningIDs = append(result.ScreeningIDs, screeningID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to import screenings", err))
+		return
+	}
+
+	result.Imported = len(result.ScreeningIDs)
+	c.JSON(http.StatusCreated, models.SuccessResponse("Screenings imported successfully", result))
+}
+
+// parseScreeningCSV reads an import with a header row. Values that cannot be
+// read are reported against their row rather than failing the file.
+func parseScreeningCSV(r io.Reader) ([]models.ScreeningImportRow, [][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Spreadsheets saving as UTF-8 CSV start the file with a byte order mark
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := screeningImportColumns[name]; !ok {
+			return nil, nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns[name] = i
+	}
+	for name, required := range screeningImportColumns {
+		if _, ok := columns[name]; required && !ok {
+			return nil, nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var rows []models.ScreeningImportRow
+	var rowErrors [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rows) == maxImportRows {
+			return nil, nil, fmt.Errorf("an import holds at most %d rows", maxImportRows)
+		}
+
+		var row models.ScreeningImportRow
+		var errs []string
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		number := func(name string) int {
+			n, err := strconv.Atoi(field(name))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("Invalid %s %q", name, field(name)))
+			}
+			return n
+		}
+		amount := func(name string) models.Money {
+			if field(name) == "" {
+				return models.Money{}
+			}
+			m, err := models.ParseMoney(field(name))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("Invalid %s %q", name, field(name)))
+			}
+			return m
+		}
+
+		row.MovieID = number("movie_id")
+		row.TheaterID = number("theater_id")
+		row.HallID = number("hall_id")
+		if row.ShowTime, err = models.ParseLocalTime(field("show_time")); err != nil {
+			errs = append(errs, fmt.Sprintf("Invalid show_time %q", field("show_time")))
+		}
+		row.Price = amount("price")
+		row.Price3D = amount("price_3d")
+		if value := field("is_3d"); value != "" {
+			if row.Is3D, err = strconv.ParseBool(value); err != nil {
+				errs = append(errs, fmt.Sprintf("Invalid is_3d %q", value))
+			}
+		}
+
+		rows = append(rows, row)
+		rowErrors = append(rowErrors, errs)
+	}
+	return rows, rowErrors, nil
+}
+
+func parseScreeningJSON(r io.Reader) ([]models.ScreeningImportRow, [][]string, error) {
+	var rows []models.ScreeningImportRow
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, nil, err
+	}
+	if len(rows) > maxImportRows {
+		return nil, nil, fmt.Errorf("an import holds at most %d rows", maxImportRows)
+	}
+	return rows, make([][]string, len(rows)), nil
+}
+
+// checkScreeningImport adds the problems of each row to rowErrors and
+// returns the rows that can be inserted. The halls involved stay locked
+// until the transaction ends, so nothing can be scheduled into them between
+// the overlap check and the inserts.
+func checkScreeningImport(tx queryer, rows []models.ScreeningImportRow, rowErrors [][]string) ([]importedScreening, error) {
+	var movieIDs, hallIDs pq.Int64Array
+	for i, row := range rows {
+		if len(rowErrors[i]) > 0 {
+			continue
+		}
+		if row.MovieID <= 0 {
+			rowErrors[i] = append(rowErrors[i], "movie_id is required")
+		}
+		if row.TheaterID <= 0 {
+			rowErrors[i] = append(rowErrors[i], "theater_id is required")
+		}
+		if row.HallID <= 0 {
+			rowErrors[i] = append(rowErrors[i], "hall_id is required")
+		}
+		if row.ShowTime.IsZero() {
+			rowErrors[i] = append(rowErrors[i], "show_time is required")
+		}
+		if row.Price.Amount <= 0 {
+			rowErrors[i] = append(rowErrors[i], "price must be greater than 0")
+		}
+		if row.Price3D.Amount < 0 {
+			rowErrors[i] = append(rowErrors[i], "price_3d cannot be negative")
+		}
+		movieIDs = append(movieIDs, int64(row.MovieID))
+		hallIDs = append(hallIDs, int64(row.HallID))
+	}
+
+	movies := map[int]models.Movie{}
+	movieRows, err := tx.Query(`
+        SELECT id, title, duration, COALESCE(TO_CHAR(release_date, 'YYYY-MM-DD'), ''), COALESCE(TO_CHAR(end_date, 'YYYY-MM-DD'), '')
+        FROM movies WHERE id = ANY($1)
+    `, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+	for movieRows.Next() {
+		var m models.Movie
+		if err := movieRows.Scan(&m.ID, &m.Title, &m.Duration, &m.ReleaseDate, &m.EndDate); err != nil {
+			movieRows.Close()
+			return nil, err
+		}
+		movies[m.ID] = m
+	}
+	movieRows.Close()
+
+	halls := map[int]models.Hall{}
+	zones := map[int]*time.Location{}
+	hallRows, err := tx.Query(`
+        SELECT h.id, h.theater_id, h.name, h.capacity, COALESCE(h.has_3d_capability, false), t.timezone
+        FROM halls h
+        JOIN theaters t ON t.id = h.theater_id
+        WHERE h.id = ANY($1)
+        ORDER BY h.id
+        FOR UPDATE OF h
+    `, hallIDs)
+	if err != nil {
+		return nil, err
+	}
+	for hallRows.Next() {
+		var h models.Hall
+		var timezone string
+		if err := hallRows.Scan(&h.ID, &h.TheaterID, &h.Name, &h.Capacity, &h.Has3DCapability, &timezone); err != nil {
+			hallRows.Close()
+			return nil, err
+		}
+		halls[h.ID] = h
+		zones[h.ID] = config.TheaterLocation(timezone)
+	}
+	hallRows.Close()
+
+	var candidates []importedScreening
+	var from, to time.Time
+	now := time.Now()
+	for i, row := range rows {
+		if len(rowErrors[i]) > 0 {
+			continue
+		}
+		movie, movieFound := movies[row.MovieID]
+		if !movieFound {
+			rowErrors[i] = append(rowErrors[i], fmt.Sprintf("Movie %d not found", row.MovieID))
+		}
+		hall, hallFound := halls[row.HallID]
+		if !hallFound {
+			rowErrors[i] = append(rowErrors[i], fmt.Sprintf("Hall %d not found", row.HallID))
+		} else if hall.TheaterID != row.TheaterID {
+			rowErrors[i] = append(rowErrors[i], fmt.Sprintf("Hall %d is not in theater %d", row.HallID, row.TheaterID))
+		}
+		if len(rowErrors[i]) > 0 {
+			continue
+		}
+
+		loc := zones[row.HallID]
+		showTime := row.ShowTime.In(loc)
+		if !showTime.After(now) {
+			rowErrors[i] = append(rowErrors[i], "show_time has already passed")
+		}
+		rowErrors[i] = append(rowErrors[i], utils.ScreeningViolations(movie, hall, showTime.Format("2006-01-02"), row.Is3D)...)
+		if len(rowErrors[i]) > 0 {
+			continue
+		}
+
+		s := importedScreening{
+			row:      i + 1,
+			req:      row,
+			capacity: hall.Capacity,
+			showTime: showTime,
+			endTime:  showTime.Add(time.Duration(movie.Duration) * time.Minute),
+		}
+		if from.IsZero() || s.showTime.Before(from) {
+			from = s.showTime
+		}
+		if s.endTime.After(to) {
+			to = s.endTime
+		}
+		candidates = append(candidates, s)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// Overlaps are checked against the screenings already in the halls and
+	// against earlier rows of the import
+	taken := map[int][]hallSlot{}
+	takenRows, err := tx.Query(`
+        SELECT id, hall_id, show_time, end_time FROM screenings
+        WHERE hall_id = ANY($1) AND is_available = true AND show_time < $3 AND end_time > $2
+        ORDER BY show_time, id
+    `, hallIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for takenRows.Next() {
+		var slot hallSlot
+		var hallID int
+		if err := takenRows.Scan(&slot.screeningID, &hallID, &slot.start, &slot.end); err != nil {
+			takenRows.Close()
+			return nil, err
+		}
+		taken[hallID] = append(taken[hallID], slot)
+	}
+	takenRows.Close()
+	if err := takenRows.Err(); err != nil {
+		return nil, err
+	}
+
+	var valid []importedScreening
+	for _, s := range candidates {
+		overlap := ""
+		for _, slot := range taken[s.req.HallID] {
+			if slot.start.Before(s.endTime) && slot.end.After(s.showTime) {
+				overlap = fmt.Sprintf("Overlaps screening #%d in hall %d", slot.screeningID, s.req.HallID)
+				break
+			}
+		}
+		for _, other := range valid {
+			if overlap == "" && other.req.HallID == s.req.HallID && other.showTime.Before(s.endTime) && other.endTime.After(s.showTime) {
+				overlap = fmt.Sprintf("Overlaps row %d in hall %d", other.row, s.req.HallID)
+			}
+		}
+		if overlap != "" {
+			rowErrors[s.row-1] = append(rowErrors[s.row-1], overlap)
+			continue
+		}
+		valid = append(valid, s)
+	}
+	return valid, nil
+}
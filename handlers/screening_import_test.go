@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectImportLookups(mock sqlmock.Sqlmock, movieIDs, hallIDs string) {
+	mock.ExpectQuery("FROM movies WHERE id = ANY\\(\\$1\\)").
+		WithArgs(movieIDs).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "duration", "release_date", "end_date"}).
+			AddRow(1, "Avatar", 120, "2030-12-01", ""))
+	mock.ExpectQuery("FROM halls h JOIN theaters t ON t.id = h.theater_id WHERE h.id = ANY\\(\\$1\\) ORDER BY h.id FOR UPDATE OF h").
+		WithArgs(hallIDs).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "theater_id", "name", "capacity", "has_3d_capability", "timezone"}).
+			AddRow(1, 1, "Hall 1", 150, false, "Asia/Jakarta").
+			AddRow(2, 2, "Studio 1", 120, true, "Asia/Jakarta"))
+}
+
+func postImport(router http.Handler, target, contentType, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", target, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestImportScreenings_ReportsEveryInvalidRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	loc := config.TheaterLocation("Asia/Jakarta")
+	mock.ExpectBegin()
+	expectImportLookups(mock, "{1,1,1,9}", "{1,2,1,1}")
+	mock.ExpectQuery("SELECT id, hall_id, show_time, end_time FROM screenings WHERE hall_id = ANY\\(\\$1\\)").
+		WithArgs("{1,2,1,1}", time.Date(2030, 12, 2, 13, 0, 0, 0, loc), time.Date(2030, 12, 2, 16, 30, 0, 0, loc)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hall_id", "show_time", "end_time"}))
+	mock.ExpectRollback()
+
+	router := setupTestRouter()
+	router.POST("/screenings/import", ImportScreenings)
+
+	csv := strings.Join([]string{
+		"\ufeffmovie_id,theater_id,hall_id,show_time,price,is_3d",
+		"1,1,1,2030-12-02 13:00,50000,false",
+		"1,1,2,2030-12-02 13:00,50000,false",
+		"1,1,1,2030-12-02 14:00,abc,false",
+		"1,1,1,2030-12-02 14:30,50000,",
+		"9,1,1,2030-12-03 13:00,50000,false",
+	}, "\n")
+	w := postImport(router, "/screenings/import?dry_run=true", "text/csv", csv)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response struct {
+		Message string                       `json:"message"`
+		Data    models.ScreeningImportResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "Import has invalid rows", response.Message)
+	assert.True(t, response.Data.DryRun)
+	assert.Equal(t, 5, response.Data.Rows)
+	assert.Equal(t, 0, response.Data.Imported)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 2, Errors: []string{"Hall 2 is not in theater 1"}},
+		{Row: 3, Errors: []string{`Invalid price "abc"`}},
+		{Row: 4, Errors: []string{"Overlaps row 1 in hall 1"}},
+		{Row: 5, Errors: []string{"Movie 9 not found"}},
+	}, response.Data.Errors)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestImportScreenings_ImportsAllRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	loc := config.TheaterLocation("Asia/Jakarta")
+	at := func(hour int) time.Time { return time.Date(2030, 12, 2, hour, 0, 0, 0, loc) }
+
+	mock.ExpectBegin()
+	expectImportLookups(mock, "{1,1}", "{1,1}")
+	mock.ExpectQuery("SELECT id, hall_id, show_time, end_time FROM screenings WHERE hall_id = ANY\\(\\$1\\)").
+		WithArgs("{1,1}", at(13), at(18)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hall_id", "show_time", "end_time"}).
+			AddRow(7, 1, at(10), at(12)))
+	mock.ExpectQuery("INSERT INTO screenings").
+		WithArgs(1, 1, 1, at(13), at(15), "50000.00", "0.00", 150, false, true).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(30))
+	mock.ExpectQuery("INSERT INTO screenings").
+		WithArgs(1, 1, 1, at(16), at(18), "60000.00", "0.00", 150, false, true).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(31))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/screenings/import", ImportScreenings)
+
+	w := postImport(router, "/screenings/import", "application/json", `[
+		{"movie_id": 1, "theater_id": 1, "hall_id": 1, "show_time": "2030-12-02T13:00:00", "price": 50000},
+		{"movie_id": 1, "theater_id": 1, "hall_id": 1, "show_time": "2030-12-02T16:00:00", "price": 60000}
+	]`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response struct {
+		Data models.ScreeningImportResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, 2, response.Data.Imported)
+	assert.Equal(t, []int{30, 31}, response.Data.ScreeningIDs)
+	assert.Empty(t, response.Data.Errors)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestImportScreenings_UnknownColumn(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/screenings/import", ImportScreenings)
+
+	w := postImport(router, "/screenings/import", "text/csv", "movie_id,theater_id,hall_id,show_time,price,price3d\n")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, `unknown column "price3d"`, response.Error)
+}
@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"cinema-ticket-api/config"
 	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,32 +28,42 @@ func TestCreateScreening_Success(t *testing.T) {
 	config.DB = db
 
 	// Mock movie duration query
-	movieRows := sqlmock.NewRows([]string{"duration"}).AddRow(120)
-	mock.ExpectQuery("SELECT duration FROM movies WHERE id = \\$1").
+	movieRows := sqlmock.NewRows([]string{"title", "duration", "release_date", "end_date"}).
+		AddRow("Avatar", 120, "2030-12-01", "2031-01-31")
+	mock.ExpectQuery("SELECT title, duration, .* FROM movies WHERE id = \\$1").
 		WithArgs(1).
 		WillReturnRows(movieRows)
 
+	// The theater is in WITA, so 18:00 without an offset is 10:00 UTC
+	mock.ExpectQuery("SELECT timezone FROM theaters WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"timezone"}).AddRow("Asia/Makassar"))
+	showTime := time.Date(2030, 12, 25, 18, 0, 0, 0, config.TheaterLocation("Asia/Makassar"))
+
 	// Mock hall capacity query
-	hallRows := sqlmock.NewRows([]string{"capacity"}).AddRow(150)
-	mock.ExpectQuery("SELECT capacity FROM halls WHERE id = \\$1").
+	hallRows := sqlmock.NewRows([]string{"name", "capacity", "has_3d_capability"}).AddRow("Hall 1", 150, true)
+	mock.ExpectQuery("SELECT name, capacity, .* FROM halls WHERE id = \\$1").
 		WithArgs(1).
 		WillReturnRows(hallRows)
 
 	// Mock insert screening
+	mock.ExpectBegin()
 	mock.ExpectQuery("INSERT INTO screenings").
-		WithArgs(1, 1, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), 50000.0, 75000.0, 150, true, true).
+		WithArgs(1, 1, 1, showTime, showTime.Add(2*time.Hour), "50000.00", "75000.00", 150, true, true).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
 
 	router := setupTestRouter()
 	router.POST("/screenings", CreateScreening)
 
+	localShowTime, _ := models.ParseLocalTime("2030-12-25T18:00:00")
 	screeningReq := models.CreateScreeningRequest{
 		MovieID:   1,
 		TheaterID: 1,
 		HallID:    1,
-		ShowTime:  time.Now().Add(24 * time.Hour),
-		Price:     50000.0,
-		Price3D:   75000.0,
+		ShowTime:  localShowTime,
+		Price:     rupiah(50000),
+		Price3D:   rupiah(75000),
 		Is3D:      true,
 	}
 
@@ -74,6 +87,272 @@ func TestCreateScreening_Success(t *testing.T) {
 	}
 }
 
+// expectScheduleChecks mocks the lookups CreateScreening makes for a 3D
+// screening of a movie showing in December 2030 in a hall without 3D
+func expectScheduleChecks(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT title, duration, .* FROM movies WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "duration", "release_date", "end_date"}).
+			AddRow("Avatar", 120, "2030-12-01", "2030-12-31"))
+	mock.ExpectQuery("SELECT timezone FROM theaters WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"timezone"}).AddRow("Asia/Jakarta"))
+	mock.ExpectQuery("SELECT name, capacity, .* FROM halls WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "capacity", "has_3d_capability"}).AddRow("Hall 1", 150, false))
+}
+
+func postScreening(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/screenings", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateScreening_BreaksSchedulingRules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+	expectScheduleChecks(mock)
+
+	router := setupTestRouter()
+	router.POST("/screenings", CreateScreening)
+
+	w := postScreening(router, `{"movie_id": 1, "theater_id": 1, "hall_id": 1, "show_time": "2031-01-05T19:00:00", "price": 50000, "is_3d": true}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "Screening breaks scheduling rules", response.Message)
+	assert.Contains(t, response.Error, "Avatar ended its run on 2030-12-31")
+	assert.Contains(t, response.Error, "Hall 1 cannot show 3D screenings")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateScreening_OverrideNeedsReason(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/screenings", CreateScreening)
+
+	w := postScreening(router, `{"movie_id": 1, "theater_id": 1, "hall_id": 1, "show_time": "2031-01-05T19:00:00", "price": 50000, "override": true}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateScreening_OverrideIsAudited(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+	expectScheduleChecks(mock)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO screenings").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(1, models.AuditScreeningScheduleOverride, "screening", 9, "Fan event",
+			`{"violations":["Avatar ended its run on 2030-12-31","Hall 1 cannot show 3D screenings"]}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.POST("/screenings", withUser(1), CreateScreening)
+
+	w := postScreening(router, `{"movie_id": 1, "theater_id": 1, "hall_id": 1, "show_time": "2031-01-05T19:00:00", "price": 50000, "is_3d": true, "override": true, "override_reason": "Fan event"}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response struct {
+		Data struct {
+			ID              int      `json:"id"`
+			OverriddenRules []string `json:"overridden_rules"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, 9, response.Data.ID)
+	assert.Len(t, response.Data.OverriddenRules, 2)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateScreening_BreaksSchedulingRules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	// The new hall shows 3D, but the new show time is the day before the
+	// movie opens
+	mock.ExpectQuery("FROM screenings s JOIN theaters t ON t.id = COALESCE\\(NULLIF\\(\\$2, 0\\), s.theater_id\\)").
+		WithArgs(4, 0, 0, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"show_time", "timezone", "title", "duration", "release_date", "end_date", "name", "has_3d_capability"}).
+			AddRow(time.Date(2030, 11, 30, 18, 0, 0, 0, time.UTC), "Asia/Jakarta", "Avatar", 162, "2030-12-01", "", "Hall 2", true))
+
+	router := setupTestRouter()
+	router.PUT("/screenings/:id", UpdateScreening)
+
+	req, _ := http.NewRequest("PUT", "/screenings/4", bytes.NewBufferString(`{"hall_id": 2, "show_time": "2030-11-30T20:00:00", "is_3d": true, "is_available": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "Avatar is not released until 2030-12-01", response.Error)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateScreening_NewMovieMovesEndTime(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	// The show time stays 18:00 UTC and the new movie runs 176 minutes
+	showTime := time.Date(2030, 12, 5, 18, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM screenings s JOIN theaters t ON t.id = COALESCE\\(NULLIF\\(\\$2, 0\\), s.theater_id\\)").
+		WithArgs(4, 0, 3, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"show_time", "timezone", "title", "duration", "release_date", "end_date", "name", "has_3d_capability"}).
+			AddRow(showTime, "Asia/Jakarta", "The Batman", 176, "2030-12-01", "", "Hall 1", false))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE screenings SET updated_at = NOW\\(\\), schedule_exception = schedule_id IS NOT NULL, movie_id = \\$1, end_time = \\$2").
+		WithArgs(3, showTime.Add(176*time.Minute), false, true, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	router := setupTestRouter()
+	router.PUT("/screenings/:id", UpdateScreening)
+
+	req, _ := http.NewRequest("PUT", "/screenings/4", bytes.NewBufferString(`{"movie_id": 3, "is_available": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetScreenings_FiltersAndPaginates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{
+		"id", "movie_id", "theater_id", "hall_id", "show_time", "end_time", "timezone",
+		"price", "price_3d", "available_seats", "is_3d", "is_available", "created_at", "updated_at",
+	})
+	for i := 1; i <= 3; i++ {
+		showTime := now.Add(time.Duration(i) * time.Hour)
+		rows.AddRow(i, 1, 2, 1, showTime, showTime.Add(2*time.Hour), "Asia/Jakarta", 60000.0-float64(i)*5000, 0.0, 150, false, true, now, now)
+	}
+
+	mock.ExpectQuery("FROM screenings s JOIN theaters t ON t.id = s.theater_id WHERE s.show_time >= \\$1::date::timestamp AT TIME ZONE t.timezone AND s.show_time < \\(\\$2::date \\+ 1\\)::timestamp AT TIME ZONE t.timezone AND s.theater_id = \\$3 AND s.is_3d = \\$4 AND s.price <= \\$5 ORDER BY s.price DESC, s.id DESC LIMIT \\$6").
+		WithArgs("2025-12-24", "2025-12-25", 2, false, "60000.00", 3).
+		WillReturnRows(rows)
+
+	router := setupTestRouter()
+	router.GET("/screenings", GetScreenings)
+
+	req, _ := http.NewRequest("GET", "/screenings?theater_id=2&date_from=2025-12-24&date_to=2025-12-25&is_3d=false&max_price=60000&sort=price&order=desc&limit=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data       []models.Screening `json:"data"`
+		Pagination models.Pagination  `json:"pagination"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Len(t, response.Data, 2)
+	assert.True(t, response.Pagination.HasMore)
+
+	cursor, err := utils.DecodeCursor(response.Pagination.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Cursor{Sort: "price", Order: "desc", Value: "50000.00", ID: 2}, cursor)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetScreenings_NextPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("FROM screenings s JOIN theaters t ON t.id = s.theater_id WHERE s.show_time > NOW\\(\\) AND \\(s.show_time, s.id\\) > \\(\\$1::timestamptz, \\$2\\) ORDER BY s.show_time ASC, s.id ASC LIMIT \\$3").
+		WithArgs("2025-12-24T18:00:00Z", 7, 21).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	router := setupTestRouter()
+	router.GET("/screenings", GetScreenings)
+
+	cursor := utils.EncodeCursor(utils.Cursor{Sort: "show_time", Order: "asc", Value: "2025-12-24T18:00:00Z", ID: 7})
+	req, _ := http.NewRequest("GET", "/screenings?cursor="+cursor, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"pagination":{"limit":20,"has_more":false}`)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetScreenings_InvalidQuery(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/screenings", GetScreenings)
+
+	cursor := utils.EncodeCursor(utils.Cursor{Sort: "price", Order: "asc", Value: "50000.00", ID: 7})
+	tampered := utils.EncodeCursor(utils.Cursor{Sort: "show_time", Order: "asc", Value: "tomorrow", ID: 7})
+	for _, query := range []string{"sort=movie_id", "limit=500", "date_from=24-12-2025", "order=up", "include=seats", "cursor=" + cursor, "cursor=" + tampered} {
+		req, _ := http.NewRequest("GET", "/screenings?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
 func TestGetScreening_Success(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -88,14 +367,14 @@ func TestGetScreening_Success(t *testing.T) {
 
 	// Mock screening query
 	rows := sqlmock.NewRows([]string{
-		"id", "movie_id", "theater_id", "hall_id", "show_time", "end_time",
+		"id", "movie_id", "theater_id", "hall_id", "show_time", "end_time", "timezone",
 		"price", "price_3d", "available_seats", "is_3d", "is_available", "created_at", "updated_at",
 	}).AddRow(
-		1, 1, 1, 1, showTime, showTime.Add(2*time.Hour),
+		1, 1, 1, 1, showTime, showTime.Add(2*time.Hour), "Asia/Jakarta",
 		50000.0, 75000.0, 150, true, true, now, now,
 	)
 
-	mock.ExpectQuery("SELECT id, movie_id, theater_id, hall_id, show_time, end_time, price, price_3d, available_seats, is_3d, is_available, created_at, updated_at FROM screenings WHERE id = \\$1").
+	mock.ExpectQuery("SELECT s.id, s.movie_id, s.theater_id, s.hall_id, s.show_time, s.end_time, t.timezone, s.price, s.price_3d, s.available_seats, s.is_3d, s.is_available, s.created_at, s.updated_at FROM screenings s JOIN theaters t ON t.id = s.theater_id WHERE s.id = \\$1").
 		WithArgs(1).
 		WillReturnRows(rows)
 
@@ -129,7 +408,7 @@ func TestGetScreening_NotFound(t *testing.T) {
 	config.DB = db
 
 	// Mock screening not found
-	mock.ExpectQuery("SELECT id, movie_id, theater_id, hall_id, show_time, end_time, price, price_3d, available_seats, is_3d, is_available, created_at, updated_at FROM screenings WHERE id = \\$1").
+	mock.ExpectQuery("SELECT s.id, s.movie_id, s.theater_id, s.hall_id, s.show_time, s.end_time, t.timezone, s.price, s.price_3d, s.available_seats, s.is_3d, s.is_available, s.created_at, s.updated_at FROM screenings s JOIN theaters t ON t.id = s.theater_id WHERE s.id = \\$1").
 		WithArgs(999).
 		WillReturnError(sql.ErrNoRows)
 
@@ -152,3 +431,265 @@ func TestGetScreening_NotFound(t *testing.T) {
 		t.Errorf("Unfulfilled expectations: %s", err)
 	}
 }
+
+type fakeNotifier struct {
+	userIDs []int
+}
+
+func (n *fakeNotifier) Notify(userID int, subject, message string) error {
+	n.userIDs = append(n.userIDs, userID)
+	return nil
+}
+
+func TestGetScreening_IncludesRelatedRecords(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	now := time.Now()
+	showTime := now.Add(24 * time.Hour)
+	rows := sqlmock.NewRows([]string{
+		"id", "movie_id", "theater_id", "hall_id", "show_time", "end_time", "timezone",
+		"price", "price_3d", "available_seats", "is_3d", "is_available", "created_at", "updated_at",
+		"m_id", "title", "description", "duration", "genre", "rating", "director", "cast", "release_date", "end_date",
+		"h_id", "h_theater_id", "name", "capacity", "screen_type", "has_3d_capability",
+	}).AddRow(
+		1, 2, 1, 3, showTime, showTime.Add(3*time.Hour), "Asia/Jakarta", 50000.0, 0.0, 150, false, true, now, now,
+		2, "The Batman", "", 176, "{Action,Crime}", "13+", "Matt Reeves", "{}", "2022-03-04", "",
+		3, 1, "Hall 3", 150, "IMAX", true,
+	)
+
+	// Movie and hall come from joins in the one query, in a fixed order
+	mock.ExpectQuery("FROM screenings s JOIN theaters t ON t.id = s.theater_id JOIN movies m ON m.id = s.movie_id JOIN halls h ON h.id = s.hall_id WHERE s.id = \\$1").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	router := setupTestRouter()
+	router.GET("/screenings/:id", GetScreening)
+
+	req, _ := http.NewRequest("GET", "/screenings/1?include=hall,movie,movie", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.Screening `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "The Batman", response.Data.Movie.Title)
+	assert.Equal(t, []string{"Action", "Crime"}, response.Data.Movie.Genres)
+	assert.Equal(t, "IMAX", response.Data.Hall.ScreenType)
+	assert.Equal(t, "Asia/Jakarta", response.Data.Timezone)
+	assert.Equal(t, "+07:00", response.Data.ShowTimeLocal.Format("-07:00"))
+	assert.True(t, response.Data.ShowTimeLocal.Equal(response.Data.ShowTime))
+	assert.Nil(t, response.Data.Theater)
+	assert.NotContains(t, w.Body.String(), `"theater":`)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetScreening_UnknownInclude(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/screenings/:id", GetScreening)
+
+	req, _ := http.NewRequest("GET", "/screenings/1?include=movie,seats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `unknown include \"seats\"`)
+}
+
+func TestDeleteScreening_RefundsBookings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+	utils.Gateway = &fakeGateway{refundID: "RF-1"}
+	notifier := &fakeNotifier{}
+	utils.Notifications = notifier
+
+	now := time.Now()
+	mock.ExpectExec("UPDATE screenings SET is_available = false, cancellation_reason = \\$1").
+		WithArgs("Projector broken", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE bookings SET status = \\$1").
+		WithArgs(models.BookingStatusCancelled, 1, models.BookingStatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "gift_card_amount", "loyalty_points"}).AddRow(3, 7, 0.0, 0))
+	expectRestockConcessions(mock, "{3}", 1)
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT id, user_id, total_amount").
+		WithArgs(1, models.BookingStatusPaid).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_amount", "payment_reference"}).
+			AddRow(4, 8, 100000.0, "PAY-4"))
+
+	// Full refund of booking 4
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status FROM bookings WHERE id = \\$1 FOR UPDATE").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.BookingStatusPaid))
+	mock.ExpectQuery("INSERT INTO refunds").
+		WithArgs(4, "100000.00", 100, "Screening cancelled: Projector broken", models.RefundStatusPending, "PAY-4").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, now))
+	mock.ExpectQuery("INSERT INTO refund_status_history").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+	mock.ExpectExec("UPDATE bookings SET status = \\$1").
+		WithArgs(models.BookingStatusRefunded, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE screenings").
+		WithArgs(4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRestockConcessions(mock, "{4}", 1)
+	expectLoyaltyPoints(mock, 4, 0, 0)
+	expectGiftCardRedemptions(mock, 4, sqlmock.NewRows([]string{"gift_card_id", "amount"}))
+	expectSettleRefund(mock, 1, models.RefundStatusPending, "0.00")
+	mock.ExpectCommit()
+	expectFinishRefund(mock, 1, models.RefundStatusCompleted, "RF-1", "Refunded by payment gateway")
+
+	mock.ExpectExec("INSERT INTO notifications").
+		WithArgs(7, "Screening cancelled", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO notifications").
+		WithArgs(8, "Screening cancelled", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	router := setupTestRouter()
+	router.DELETE("/screenings/:id", DeleteScreening)
+
+	body, _ := json.Marshal(models.CancelScreeningRequest{Reason: "Projector broken"})
+	req, _ := http.NewRequest("DELETE", "/screenings/1", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Success bool                         `json:"success"`
+		Data    models.CancelScreeningResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.True(t, response.Success)
+	assert.Equal(t, 2, response.Data.AffectedBookings)
+	assert.Equal(t, 1, response.Data.RefundedBookings)
+	assert.Equal(t, 1, response.Data.CancelledBookings)
+	assert.Equal(t, []int{7, 8}, notifier.userIDs)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteScreening_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectExec("UPDATE screenings SET is_available = false").
+		WithArgs("", 999).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COALESCE\\(cancellation_reason, ''\\) FROM screenings WHERE id = \\$1").
+		WithArgs(999).
+		WillReturnRows(sqlmock.NewRows([]string{"cancellation_reason"}))
+
+	router := setupTestRouter()
+	router.DELETE("/screenings/:id", DeleteScreening)
+
+	req, _ := http.NewRequest("DELETE", "/screenings/999", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteScreening_AgainRefundsBookingsLeftPaid(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+	utils.Gateway = &fakeGateway{err: errors.New("connection reset")}
+	utils.Notifications = &fakeNotifier{}
+
+	now := time.Now()
+	mock.ExpectExec("UPDATE screenings SET is_available = false, cancellation_reason = \\$1, cancelled_at = NOW\\(\\)").
+		WithArgs("", 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COALESCE\\(cancellation_reason, ''\\) FROM screenings WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"cancellation_reason"}).AddRow("Projector broken"))
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE bookings SET status = \\$1").
+		WithArgs(models.BookingStatusCancelled, 1, models.BookingStatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "gift_card_amount", "loyalty_points"}))
+	mock.ExpectRollback()
+	mock.ExpectQuery("SELECT id, user_id, total_amount").
+		WithArgs(1, models.BookingStatusPaid).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_amount", "payment_reference"}).
+			AddRow(4, 8, 100000.0, "PAY-4"))
+
+	// The refund keeps the original reason and is committed before the
+	// gateway fails, leaving it for the retry job
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status FROM bookings WHERE id = \\$1 FOR UPDATE").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.BookingStatusPaid))
+	mock.ExpectQuery("INSERT INTO refunds").
+		WithArgs(4, "100000.00", 100, "Screening cancelled: Projector broken", models.RefundStatusPending, "PAY-4").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, now))
+	mock.ExpectQuery("INSERT INTO refund_status_history").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+	mock.ExpectExec("UPDATE bookings SET status = \\$1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE screenings").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRestockConcessions(mock, "{4}", 1)
+	expectLoyaltyPoints(mock, 4, 0, 0)
+	expectGiftCardRedemptions(mock, 4, sqlmock.NewRows([]string{"gift_card_id", "amount"}))
+	expectSettleRefund(mock, 1, models.RefundStatusPending, "0.00")
+	mock.ExpectCommit()
+	expectFinishRefund(mock, 1, models.RefundStatusFailed, "", "payment gateway refund failed: connection reset")
+
+	router := setupTestRouter()
+	router.DELETE("/screenings/:id", DeleteScreening)
+
+	req, _ := http.NewRequest("DELETE", "/screenings/1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.CancelScreeningResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, 1, response.Data.FailedRefunds)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
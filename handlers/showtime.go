@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// GetShowtimes godoc
+//
+//	@Summary		Browse showtimes
+//	@Description	List the screenings still bookable on a day, grouped by movie and then by theater. The day is taken in each theater's own time zone. No login needed.
+//	@Tags			showtimes
+//	@Produce		json
+//	@Param			date	query		string											false	"Show date (YYYY-MM-DD), defaults to today"
+//	@Param			city	query		string											false	"Only theaters in this city"
+//	@Success		200		{object}	models.Response{data=models.ShowtimeListing}	"Showtimes fetched successfully"
+//	@Failure		400		{object}	models.Response									"Invalid date"
+//	@Failure		500		{object}	models.Response									"Internal server error"
+//	@Router			/showtimes [get]
+func GetShowtimes(c *gin.Context) {
+	date := time.Now().In(config.Location()).Format("2006-01-02")
+	if value := c.Query("date"); value != "" {
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid date, expected YYYY-MM-DD", err))
+			return
+		}
+		date = value
+	}
+
+	// The day runs from midnight to midnight where each theater is
+	var q utils.QueryBuilder
+	q.Where("s.is_available = true")
+	q.Where("s.show_time > NOW()")
+	q.Where("s.show_time >= ?::date::timestamp AT TIME ZONE t.timezone", date)
+	q.Where("s.show_time < (?::date + 1)::timestamp AT TIME ZONE t.timezone", date)
+
+	listing := models.ShowtimeListing{Date: date, City: c.Query("city"), Movies: []models.MovieShowtimes{}}
+	if listing.City != "" {
+		q.Where("LOWER(t.city) = LOWER(?)", listing.City)
+	}
+
+	rows, err := config.DB.Query(fmt.Sprintf(`
+        SELECT m.id, m.title, COALESCE(m.rating, ''), m.duration, COALESCE(m.genre, '{}'),
+               t.id, t.name, t.address, COALESCE(t.city, ''), t.timezone,
+               s.id, s.show_time, s.end_time, h.id, h.name, COALESCE(h.screen_type, ''),
+               s.is_3d, s.price, COALESCE(s.price_3d, 0), s.available_seats
+        FROM screenings s
+        JOIN movies m ON m.id = s.movie_id
+        JOIN theaters t ON t.id = s.theater_id
+        JOIN halls h ON h.id = s.hall_id
+        %s
+        ORDER BY m.title, m.id, t.name, t.id, s.show_time, s.id
+    `, q.WhereClause()), q.Args()...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to fetch showtimes", err))
+		return
+	}
+	defer rows.Close()
+
+	// Rows arrive sorted by movie and theater, so each one either continues
+	// the last group or starts a new one
+	for rows.Next() {
+		var movie models.MovieShowtimes
+		var theater models.TheaterShowtimes
+		var showtime models.Showtime
+		var genres pq.StringArray
+		err := rows.Scan(
+			&movie.MovieID, &movie.Title, &movie.Rating, &movie.Duration, &genres,
+			&theater.TheaterID, &theater.Name, &theater.Address, &theater.City, &theater.Timezone,
+			&showtime.ScreeningID, &showtime.ShowTime, &showtime.EndTime,
+			&showtime.HallID, &showtime.HallName, &showtime.ScreenType,
+			&showtime.Is3D, &showtime.Price, &showtime.Price3D, &showtime.AvailableSeats,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to scan showtime", err))
+			return
+		}
+		loc := config.TheaterLocation(theater.Timezone)
+		showtime.ShowTime, showtime.ShowTimeLocal = showtime.ShowTime.UTC(), showtime.ShowTime.In(loc)
+		showtime.EndTime, showtime.EndTimeLocal = showtime.EndTime.UTC(), showtime.EndTime.In(loc)
+
+		if n := len(listing.Movies); n == 0 || listing.Movies[n-1].MovieID != movie.MovieID {
+			movie.Genres = []string(genres)
+			movie.Theaters = []models.TheaterShowtimes{}
+			listing.Movies = append(listing.Movies, movie)
+		}
+		m := &listing.Movies[len(listing.Movies)-1]
+
+		if n := len(m.Theaters); n == 0 || m.Theaters[n-1].TheaterID != theater.TheaterID {
+			m.Theaters = append(m.Theaters, theater)
+		}
+		t := &m.Theaters[len(m.Theaters)-1]
+		t.Showtimes = append(t.Showtimes, showtime)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to fetch showtimes", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Showtimes fetched successfully", listing))
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetShowtimes_GroupsByMovieAndTheater(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	// 25 December at a WITA theater runs from 16:00 UTC the day before
+	start := time.Date(2025, 12, 24, 16, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{
+		"movie_id", "title", "rating", "duration", "genre", "theater_id", "name", "address", "city", "timezone",
+		"screening_id", "show_time", "end_time", "hall_id", "hall_name", "screen_type",
+		"is_3d", "price", "price_3d", "available_seats",
+	})
+	for _, r := range []struct{ movieID, theaterID, screeningID, hours int }{
+		{2, 1, 4, 2}, {2, 1, 5, 5}, {2, 2, 6, 3}, {1, 2, 7, 4},
+	} {
+		showTime := start.Add(time.Duration(r.hours) * time.Hour)
+		rows.AddRow(r.movieID, "Movie", "13+", 176, "{Action,Crime}", r.theaterID, "Theater", "Jl. Sudirman", "Makassar", "Asia/Makassar",
+			r.screeningID, showTime, showTime.Add(3*time.Hour), 1, "Hall 1", "IMAX", false, 50000.0, 0.0, 150)
+	}
+
+	mock.ExpectQuery("JOIN halls h ON h.id = s.hall_id WHERE s.is_available = true AND s.show_time > NOW\\(\\) AND s.show_time >= \\$1::date::timestamp AT TIME ZONE t.timezone AND s.show_time < \\(\\$2::date \\+ 1\\)::timestamp AT TIME ZONE t.timezone AND LOWER\\(t.city\\) = LOWER\\(\\$3\\)").
+		WithArgs("2025-12-25", "2025-12-25", "makassar").
+		WillReturnRows(rows)
+
+	router := setupTestRouter()
+	router.GET("/showtimes", GetShowtimes)
+
+	req, _ := http.NewRequest("GET", "/showtimes?date=2025-12-25&city=makassar", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.ShowtimeListing `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "2025-12-25", response.Data.Date)
+	assert.Len(t, response.Data.Movies, 2)
+	assert.Equal(t, []string{"Action", "Crime"}, response.Data.Movies[0].Genres)
+	assert.Len(t, response.Data.Movies[0].Theaters, 2)
+	assert.Len(t, response.Data.Movies[0].Theaters[0].Showtimes, 2)
+	assert.Equal(t, 6, response.Data.Movies[0].Theaters[1].Showtimes[0].ScreeningID)
+	assert.Equal(t, 7, response.Data.Movies[1].Theaters[0].Showtimes[0].ScreeningID)
+
+	first := response.Data.Movies[0].Theaters[0].Showtimes[0]
+	assert.Equal(t, "2025-12-24T18:00:00Z", first.ShowTime.Format(time.RFC3339))
+	assert.Equal(t, "2025-12-25T02:00:00+08:00", first.ShowTimeLocal.Format(time.RFC3339))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetShowtimes_InvalidDate(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/showtimes", GetShowtimes)
+
+	req, _ := http.NewRequest("GET", "/showtimes?date=25-12-2025", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
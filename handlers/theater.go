@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Theater columns of a query where theaters are aliased t
+const theaterColumns = `
+        t.id, t.name, t.address, COALESCE(t.city, ''), t.latitude, t.longitude, t.timezone, t.total_halls,
+        COALESCE(t.contact_phone, ''), COALESCE(t.contact_email, '')`
+
+// Hall columns of a query where halls are aliased h
+const hallColumns = `
+        h.id, h.theater_id, h.name, h.capacity, COALESCE(h.screen_type, ''), COALESCE(h.has_3d_capability, false)`
+
+// Nearby theaters are searched within 10 km unless radius asks otherwise
+const defaultNearbyRadiusKm = 10.0
+
+// theaterDest returns the scan destinations of theaterColumns
+func theaterDest(t *models.Theater) []interface{} {
+	return []interface{}{
+		&t.ID, &t.Name, &t.Address, &t.City, &t.Latitude, &t.Longitude, &t.Timezone, &t.TotalHalls,
+		&t.ContactPhone, &t.ContactEmail,
+	}
+}
+
+// hallDest returns the scan destinations of hallColumns
+func hallDest(h *models.Hall) []interface{} {
+	return []interface{}{&h.ID, &h.TheaterID, &h.Name, &h.Capacity, &h.ScreenType, &h.Has3DCapability}
+}
+
+// GetNearbyTheaters godoc
+//
+//	@Summary		Find nearby theaters
+//	@Description	List theaters within radius kilometres of a location, nearest first. Distances are great-circle (haversine). No login needed.
+//	@Tags			theaters
+//	@Produce		json
+//	@Param			lat		query		number										true	"Latitude"
+//	@Param			lng		query		number										true	"Longitude"
+//	@Param			radius	query		number										false	"Search radius in km (up to 100)"	default(10)
+//	@Success		200		{object}	models.Response{data=[]models.NearbyTheater}	"Theaters fetched successfully"
+//	@Failure		400		{object}	models.Response								"Invalid query parameters"
+//	@Failure		500		{object}	models.Response								"Internal server error"
+//	@Router			/theaters/nearby [get]
+func GetNearbyTheaters(c *gin.Context) {
+	var query models.NearbyTheatersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid query parameters", err))
+		return
+	}
+	if query.Radius == 0 {
+		query.Radius = defaultNearbyRadiusKm
+	}
+
+	// A degree of latitude is about 111 km everywhere, which narrows the
+	// candidates through the location index before distances are computed
+	rows, err := config.DB.Query(`
+        SELECT `+theaterColumns+`, t.distance_km
+        FROM (
+            SELECT *, 6371 * 2 * ASIN(SQRT(
+                POWER(SIN(RADIANS(latitude - $1) / 2), 2) +
+                COS(RADIANS($1)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - $2) / 2), 2)
+            )) AS distance_km
+            FROM theaters
+            WHERE latitude BETWEEN $1 - $3 / 111.0 AND $1 + $3 / 111.0
+        ) t
+        WHERE t.distance_km <= $3
+        ORDER BY t.distance_km, t.id
+    `, *query.Lat, *query.Lng, query.Radius)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to fetch theaters", err))
+		return
+	}
+	defer rows.Close()
+
+	theaters := []models.NearbyTheater{}
+	for rows.Next() {
+		var theater models.NearbyTheater
+		if err := rows.Scan(append(theaterDest(&theater.Theater), &theater.DistanceKm)...); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to scan theater", err))
+			return
+		}
+		theaters = append(theaters, theater)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to fetch theaters", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Theaters fetched successfully", theaters))
+}
+
+// GetCities godoc
+//
+//	@Summary		List cities
+//	@Description	List the cities that have theaters, for location pickers. No login needed.
+//	@Tags			theaters
+//	@Produce		json
+//	@Success		200	{object}	models.Response{data=[]models.City}	"Cities fetched successfully"
+//	@Failure		500	{object}	models.Response						"Internal server error"
+//	@Router			/cities [get]
+func GetCities(c *gin.Context) {
+	// Cities are typed in by hand, so spelling variants in case and spacing
+	// are counted as one
+	rows, err := config.DB.Query(`
+        SELECT MIN(TRIM(city)), COUNT(*)
+        FROM theaters
+        WHERE TRIM(COALESCE(city, '')) <> ''
+        GROUP BY LOWER(TRIM(city))
+        ORDER BY MIN(TRIM(city))
+    `)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to fetch cities", err))
+		return
+	}
+	defer rows.Close()
+
+	cities := []models.City{}
+	for rows.Next() {
+		var city models.City
+		if err := rows.Scan(&city.Name, &city.Theaters); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to scan city", err))
+			return
+		}
+		cities = append(cities, city)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to fetch cities", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Cities fetched successfully", cities))
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNearbyTheaters_NearestFirst(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("WHERE t.distance_km <= \\$3 ORDER BY t.distance_km").
+		WithArgs(-6.2088, 106.8456, 10.0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "address", "city", "latitude", "longitude", "timezone", "total_halls",
+			"contact_phone", "contact_email", "distance_km",
+		}).
+			AddRow(1, "Cinema XXI Grand Indonesia", "Jl. M.H. Thamrin No.1", "Jakarta", -6.1951, 106.8213, "Asia/Jakarta", 8, "", "", 3.04).
+			AddRow(2, "CGV Pacific Place", "Jl. Jend. Sudirman", "Jakarta", -6.2247, 106.8096, "Asia/Jakarta", 6, "", "", 4.36))
+
+	router := setupTestRouter()
+	router.GET("/theaters/nearby", GetNearbyTheaters)
+
+	req, _ := http.NewRequest("GET", "/theaters/nearby?lat=-6.2088&lng=106.8456", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []models.NearbyTheater `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, 3.04, response.Data[0].DistanceKm)
+	assert.Equal(t, -6.1951, *response.Data[0].Latitude)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetNearbyTheaters_InvalidLocation(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/theaters/nearby", GetNearbyTheaters)
+
+	for _, query := range []string{"lng=106.8", "lat=-6.2", "lat=91&lng=106.8", "lat=-6.2&lng=106.8&radius=500", "lat=abc&lng=106.8"} {
+		req, _ := http.NewRequest("GET", "/theaters/nearby?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestGetCities(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("GROUP BY LOWER\\(TRIM\\(city\\)\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"city", "count"}).AddRow("Bandung", 1).AddRow("Jakarta", 2))
+
+	router := setupTestRouter()
+	router.GET("/cities", GetCities)
+
+	req, _ := http.NewRequest("GET", "/cities", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []models.City `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, []models.City{{Name: "Bandung", Theaters: 1}, {Name: "Jakarta", Theaters: 2}}, response.Data)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
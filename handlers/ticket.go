@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBookingTickets godoc
+//
+//	@Summary		Get booking tickets
+//	@Description	Get a signed e-ticket with a QR code PNG (base64) for every seat of a paid booking
+//	@Tags			bookings
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int										true	"Booking ID"
+//	@Success		200	{object}	models.Response{data=[]models.Ticket}	"Tickets fetched successfully"
+//	@Failure		400	{object}	models.Response							"Booking is not paid"
+//	@Failure		401	{object}	models.Response							"Unauthorized"
+//	@Failure		404	{object}	models.Response							"Booking not found"
+//	@Failure		500	{object}	models.Response							"Internal server error"
+//	@Router			/bookings/{id}/tickets [get]
+func GetBookingTickets(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid booking ID", err))
+		return
+	}
+
+	var (
+		userID      int
+		status      string
+		screeningID int
+		pickupCode  string
+		endTime     time.Time
+	)
+	err = config.DB.QueryRow(`
+        SELECT b.user_id, b.status, b.screening_id, COALESCE(b.pickup_code, ''), s.end_time
+        FROM bookings b JOIN screenings s ON s.id = b.screening_id
+        WHERE b.id = $1
+    `, id).Scan(&userID, &status, &screeningID, &pickupCode, &endTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	if userID != c.GetInt("user_id") {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Booking not found", nil))
+		return
+	}
+
+	if status != models.BookingStatusPaid {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Tickets are only available for paid bookings", nil))
+		return
+	}
+
+	tickets, err := bookingTickets(id, screeningID, pickupCode, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate tickets", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Tickets fetched successfully", tickets))
+}
+
+// bookingTickets signs one ticket per booked seat, valid until the screening
+// ends. Every ticket shows the pickup code of the booking's concessions.
+func bookingTickets(bookingID, screeningID int, pickupCode string, expiresAt time.Time) ([]models.Ticket, error) {
+	rows, err := config.DB.Query(`
+        SELECT id, seat_label FROM booking_seats
+        WHERE booking_id = $1
+        ORDER BY seat_label
+    `, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := []models.Ticket{}
+	for rows.Next() {
+		t := models.Ticket{
+			BookingID:   bookingID,
+			ScreeningID: screeningID,
+			PickupCode:  pickupCode,
+			ExpiresAt:   expiresAt,
+		}
+		if err := rows.Scan(&t.ID, &t.Seat); err != nil {
+			return nil, err
+		}
+
+		t.Token, err = utils.GenerateTicketToken(t.ID, bookingID, screeningID, t.Seat, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		t.QRCodePNG, err = utils.TicketQRCode(t.Token)
+		if err != nil {
+			return nil, err
+		}
+
+		tickets = append(tickets, t)
+	}
+
+	return tickets, rows.Err()
+}
+
+// CheckinTicket godoc
+//
+//	@Summary		Check in a ticket
+//	@Description	Validate a scanned ticket for a screening in the staff member's theater that starts soon and mark it as used. A ticket can only be checked in once.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			checkinRequest	body		models.CheckinRequest						true	"Scanned ticket"
+//	@Success		200				{object}	models.Response{data=models.CheckinResult}	"Ticket checked in successfully"
+//	@Failure		400				{object}	models.Response								"Invalid ticket or check-in not open"
+//	@Failure		401				{object}	models.Response								"Unauthorized"
+//	@Failure		403				{object}	models.Response								"Ticket belongs to another theater"
+//	@Failure		404				{object}	models.Response								"Ticket not found"
+//	@Failure		409				{object}	models.Response								"Ticket already used"
+//	@Failure		500				{object}	models.Response								"Internal server error"
+//	@Router			/checkin [post]
+func CheckinTicket(c *gin.Context) {
+	var req models.CheckinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request", err))
+		return
+	}
+
+	claims, err := utils.VerifyTicketToken(req.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid ticket", err))
+		return
+	}
+
+	ticketID, err := strconv.Atoi(claims.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid ticket", err))
+		return
+	}
+
+	result := models.CheckinResult{TicketID: ticketID}
+	var (
+		status        string
+		minutesToShow float64
+		isStaff       bool
+	)
+	err = config.DB.QueryRow(`
+        SELECT bs.booking_id, bs.seat_label, b.status, b.screening_id, m.title, h.name, s.show_time,
+               EXTRACT(EPOCH FROM (s.show_time - NOW())) / 60,
+               EXISTS (SELECT 1 FROM theater_staff ts WHERE ts.user_id = $2 AND ts.theater_id = s.theater_id)
+        FROM booking_seats bs
+        JOIN bookings b ON b.id = bs.booking_id
+        JOIN screenings s ON s.id = b.screening_id
+        JOIN movies m ON m.id = s.movie_id
+        JOIN halls h ON h.id = s.hall_id
+        WHERE bs.id = $1
+    `, ticketID, c.GetInt("user_id")).Scan(
+		&result.BookingID, &result.Seat, &status, &result.ScreeningID, &result.MovieTitle,
+		&result.HallName, &result.ShowTime, &minutesToShow, &isStaff,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Ticket not found", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error", err))
+		}
+		return
+	}
+
+	if result.BookingID != claims.BookingID || result.ScreeningID != claims.ScreeningID || result.Seat != claims.Seat {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid ticket", nil))
+		return
+	}
+
+	if !isStaff {
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Ticket is for a screening in another theater", nil))
+		return
+	}
+
+	if status != models.BookingStatusPaid {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Ticket is no longer valid", nil))
+		return
+	}
+
+	if minutesToShow > config.CheckinWindow().Minutes() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Check-in is not open yet for this screening", nil))
+		return
+	}
+
+	// Only the first scan can set checked_in_at, so replays are rejected
+	err = config.DB.QueryRow(`
+        UPDATE booking_seats SET checked_in_at = NOW(), checked_in_by = $2
+        WHERE id = $1 AND checked_in_at IS NULL
+        RETURNING checked_in_at
+    `, ticketID, c.GetInt("user_id")).Scan(&result.CheckedInAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, models.ErrorResponse("Ticket already used", nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to check in ticket", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Ticket checked in successfully", result))
+}
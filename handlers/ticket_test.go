@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"bytes"
+	"cinema-ticket-api/config"
+	"cinema-ticket-api/models"
+	"cinema-ticket-api/utils"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBookingTickets_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	endTime := time.Now().Add(3 * time.Hour)
+	mock.ExpectQuery("SELECT b.user_id, b.status, b.screening_id").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "status", "screening_id", "pickup_code", "end_time"}).
+			AddRow(1, models.BookingStatusPaid, 2, "K7Q2MX", endTime))
+	mock.ExpectQuery("SELECT id, seat_label FROM booking_seats").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seat_label"}).
+			AddRow(10, "A1").
+			AddRow(11, "A2"))
+
+	router := setupTestRouter()
+	router.GET("/bookings/:id/tickets", withUser(1), GetBookingTickets)
+
+	req, _ := http.NewRequest("GET", "/bookings/1/tickets", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Success bool            `json:"success"`
+		Data    []models.Ticket `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.True(t, response.Success)
+	assert.Len(t, response.Data, 2)
+	assert.NotEmpty(t, response.Data[0].QRCodePNG)
+	assert.Equal(t, "K7Q2MX", response.Data[1].PickupCode)
+
+	claims, err := utils.VerifyTicketToken(response.Data[1].Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "11", claims.ID)
+	assert.Equal(t, "A2", claims.Seat)
+	assert.Equal(t, 2, claims.ScreeningID)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetBookingTickets_NotPaid(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("SELECT b.user_id, b.status, b.screening_id").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "status", "screening_id", "pickup_code", "end_time"}).
+			AddRow(1, models.BookingStatusPending, 2, "", time.Now()))
+
+	router := setupTestRouter()
+	router.GET("/bookings/:id/tickets", withUser(1), GetBookingTickets)
+
+	req, _ := http.NewRequest("GET", "/bookings/1/tickets", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "Tickets are only available for paid bookings", response.Message)
+}
+
+func checkinRows(status string, minutesToShow float64, isStaff bool) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"booking_id", "seat_label", "status", "screening_id", "title", "name", "show_time", "minutes", "is_staff",
+	}).AddRow(1, "A1", status, 2, "The Batman", "Hall 1", time.Now().Add(30*time.Minute), minutesToShow, isStaff)
+}
+
+func newCheckinRequest(t *testing.T) *http.Request {
+	token, err := utils.GenerateTicketToken(10, 1, 2, "A1", time.Now().Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("Error generating ticket: %v", err)
+	}
+
+	body, _ := json.Marshal(models.CheckinRequest{Token: token})
+	req, _ := http.NewRequest("POST", "/checkin", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestCheckinTicket_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("SELECT bs.booking_id, bs.seat_label").
+		WithArgs(10, 5).
+		WillReturnRows(checkinRows(models.BookingStatusPaid, 30, true))
+	mock.ExpectQuery("UPDATE booking_seats SET checked_in_at = NOW\\(\\)").
+		WithArgs(10, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"checked_in_at"}).AddRow(time.Now()))
+
+	router := setupTestRouter()
+	router.POST("/checkin", withUser(5), CheckinTicket)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newCheckinRequest(t))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Success bool                 `json:"success"`
+		Data    models.CheckinResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.True(t, response.Success)
+	assert.Equal(t, "A1", response.Data.Seat)
+	assert.Equal(t, "Hall 1", response.Data.HallName)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckinTicket_Replay(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	config.DB = db
+
+	mock.ExpectQuery("SELECT bs.booking_id, bs.seat_label").
+		WillReturnRows(checkinRows(models.BookingStatusPaid, 30, true))
+	mock.ExpectQuery("UPDATE booking_seats SET checked_in_at = NOW\\(\\)").
+		WillReturnError(sql.ErrNoRows)
+
+	router := setupTestRouter()
+	router.POST("/checkin", withUser(5), CheckinTicket)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newCheckinRequest(t))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response models.Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "Ticket already used", response.Message)
+}
+
+func TestCheckinTicket_Rejected(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        string
+		minutesToShow float64
+		isStaff       bool
+		code          int
+	}{
+		{"other theater", models.BookingStatusPaid, 30, false, http.StatusForbidden},
+		{"refunded booking", models.BookingStatusRefunded, 30, true, http.StatusBadRequest},
+		{"too early", models.BookingStatusPaid, 600, true, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error creating mock database: %v", err)
+			}
+			defer db.Close()
+
+			config.DB = db
+
+			mock.ExpectQuery("SELECT bs.booking_id, bs.seat_label").
+				WillReturnRows(checkinRows(tt.status, tt.minutesToShow, tt.isStaff))
+
+			router := setupTestRouter()
+			router.POST("/checkin", withUser(5), CheckinTicket)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, newCheckinRequest(t))
+
+			assert.Equal(t, tt.code, w.Code)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestCheckinTicket_InvalidToken(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/checkin", withUser(5), CheckinTicket)
+
+	body, _ := json.Marshal(models.CheckinRequest{Token: "forged.ticket.token"})
+	req, _ := http.NewRequest("POST", "/checkin", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -44,6 +44,15 @@ func main() {
 	config.InitDB()
 	defer config.DB.Close()
 
+	// Write off expired loyalty points in the background
+	handlers.StartLoyaltyExpiry(config.LoyaltyExpiryInterval())
+
+	// Release the seats of bookings left unpaid in the background
+	handlers.StartBookingExpiry(config.BookingHold())
+
+	// Send refunds the payment gateway has not confirmed again in the background
+	handlers.StartRefundRetry(config.RefundRetryInterval())
+
 	// Initialize router
 	router := gin.Default()
 
@@ -54,6 +63,35 @@ func main() {
 	public := router.Group("/api/v1")
 	{
 		public.POST("/login", handlers.Login)
+		public.POST("/webhooks/payments", handlers.PaymentWebhook)
+		public.GET("/calendar/:token", handlers.GetCalendarFeed)
+		public.GET("/showtimes", handlers.GetShowtimes)
+		public.GET("/movies/search", handlers.SearchMovies)
+		public.GET("/theaters/nearby", handlers.GetNearbyTheaters)
+		public.GET("/cities", handlers.GetCities)
+	}
+
+	// Customer routes
+	customer := router.Group("/api/v1")
+	customer.Use(middleware.AuthMiddleware())
+	{
+		customer.POST("/bookings", handlers.CreateBooking)
+		customer.GET("/bookings/:id", handlers.GetBooking)
+		customer.POST("/bookings/:id/apply-promo", handlers.ApplyPromo)
+		customer.POST("/bookings/:id/concessions", handlers.AddBookingConcessions)
+		customer.POST("/bookings/:id/redeem-points", handlers.RedeemLoyaltyPoints)
+		customer.POST("/bookings/:id/apply-gift-card", handlers.ApplyGiftCard)
+		customer.POST("/bookings/:id/refund", handlers.RefundBooking)
+		customer.GET("/bookings/:id/tickets", handlers.GetBookingTickets)
+		customer.GET("/bookings/:id/ticket.pdf", handlers.GetTicketPDF)
+		customer.GET("/bookings/:id/receipt.pdf", handlers.GetReceiptPDF)
+		customer.GET("/bookings/:id/calendar.ics", handlers.GetBookingCalendar)
+		customer.GET("/me/calendar", handlers.GetCalendarFeedURL)
+		customer.POST("/me/calendar/reset", handlers.ResetCalendarFeedURL)
+		customer.GET("/me/loyalty", handlers.GetLoyalty)
+		customer.POST("/checkin", handlers.CheckinTicket)
+		customer.POST("/gift-cards/balance", handlers.GetGiftCardBalance)
+		customer.GET("/theaters/:id/concessions", handlers.GetTheaterConcessions)
 	}
 
 	// Protected routes
@@ -62,10 +100,39 @@ func main() {
 	{
 		// Screening CRUD routes
 		protected.POST("/screenings", handlers.CreateScreening)
+		protected.POST("/screenings/import", handlers.ImportScreenings)
 		protected.GET("/screenings", handlers.GetScreenings)
+		protected.GET("/screenings/export", handlers.ExportScreenings)
 		protected.GET("/screenings/:id", handlers.GetScreening)
 		protected.PUT("/screenings/:id", handlers.UpdateScreening)
 		protected.DELETE("/screenings/:id", handlers.DeleteScreening)
+		protected.GET("/screening-schedules", handlers.GetScreeningSchedules)
+		protected.POST("/screening-schedules", handlers.CreateScreeningSchedule)
+		protected.GET("/screening-schedules/:id", handlers.GetScreeningSchedule)
+		protected.PUT("/screening-schedules/:id", handlers.UpdateScreeningSchedule)
+		protected.DELETE("/screening-schedules/:id", handlers.CancelScreeningSchedule)
+		protected.GET("/screenings/:id/price-tiers", handlers.GetScreeningPricing)
+		protected.PUT("/screenings/:id/price-tiers", handlers.UpdateScreeningPricing)
+		protected.GET("/theaters/:id/pricing", handlers.GetTheaterPricing)
+		protected.PUT("/theaters/:id/pricing", handlers.UpdateTheaterPricing)
+		protected.GET("/theaters/:id/charges", handlers.GetTheaterCharges)
+		protected.PUT("/theaters/:id/charges", handlers.UpdateTheaterCharges)
+		protected.GET("/screenings/:id/price-preview", handlers.PreviewScreeningPrice)
+		protected.GET("/pricing-rules", handlers.GetPricingRules)
+		protected.POST("/pricing-rules", handlers.CreatePricingRule)
+		protected.PUT("/pricing-rules/:id", handlers.UpdatePricingRule)
+		protected.DELETE("/pricing-rules/:id", handlers.DeletePricingRule)
+		protected.GET("/promo-codes", handlers.GetPromoCodes)
+		protected.POST("/promo-codes", handlers.CreatePromoCode)
+		protected.PUT("/promo-codes/:id", handlers.UpdatePromoCode)
+		protected.DELETE("/promo-codes/:id", handlers.DeletePromoCode)
+		protected.GET("/gift-cards", handlers.GetGiftCards)
+		protected.POST("/gift-cards", handlers.IssueGiftCard)
+		protected.GET("/gift-cards/:id", handlers.GetGiftCard)
+		protected.GET("/concessions", handlers.GetConcessionItems)
+		protected.POST("/theaters/:id/concessions", handlers.CreateConcessionItem)
+		protected.PUT("/concessions/:id", handlers.UpdateConcessionItem)
+		protected.DELETE("/concessions/:id", handlers.DeleteConcessionItem)
 	}
 
 	// Start server
@@ -0,0 +1,16 @@
+package models
+
+// Audit log actions
+const (
+	AuditScreeningScheduleOverride = "screening.schedule_override"
+)
+
+// AuditEntry is an admin action recorded in the audit log
+type AuditEntry struct {
+	UserID   int
+	Action   string
+	Entity   string
+	EntityID int
+	Reason   string
+	Details  map[string]interface{}
+}
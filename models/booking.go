@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+// Booking statuses
+const (
+	BookingStatusPending   = "pending"
+	BookingStatusPaid      = "paid"
+	BookingStatusFailed    = "failed"
+	BookingStatusRefunded  = "refunded"
+	BookingStatusCancelled = "cancelled"
+)
+
+// Booking represents a customer booking for a screening
+//
+//	@Description	Booking information
+type Booking struct {
+	ID               int          `json:"id" example:"1"`
+	UserID           int          `json:"user_id" example:"1"`
+	ScreeningID      int          `json:"screening_id" example:"1"`
+	Status           string       `json:"status" example:"pending"`
+	TotalAmount      Money        `json:"total_amount"`
+	GiftCardAmount   Money        `json:"gift_card_amount"`
+	LoyaltyPoints    int          `json:"loyalty_points" example:"0"`
+	AmountDue        Money        `json:"amount_due"`
+	PaymentReference string       `json:"payment_reference,omitempty" example:"PAY-123456"`
+	PickupCode       string       `json:"pickup_code,omitempty" example:"K7Q2MX"`
+	PaidAt           *time.Time   `json:"paid_at,omitempty" example:"2025-12-25T17:00:00Z"`
+	Seats            []BookedSeat `json:"seats,omitempty"`
+	LineItems        []LineItem   `json:"line_items,omitempty"`
+	CreatedAt        time.Time    `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt        time.Time    `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// BookedSeat represents a seat of a booking
+//
+//	@Description	Booked seat
+type BookedSeat struct {
+	Seat     string `json:"seat" example:"A1"`
+	Category string `json:"category" example:"adult"`
+	SeatType string `json:"seat_type" example:"regular"`
+}
+
+// CreateBookingRequest represents data needed to book seats for a screening
+//
+//	@Description	Data required to create a booking
+type CreateBookingRequest struct {
+	ScreeningID int                  `json:"screening_id" binding:"required" example:"1"`
+	Seats       []BookingSeatRequest `json:"seats" binding:"required,min=1,max=10,dive"`
+}
+
+// BookingSeatRequest represents one requested seat
+//
+//	@Description	Requested seat and ticket category
+type BookingSeatRequest struct {
+	Seat     string `json:"seat" binding:"required,max=10" example:"A1"`
+	Category string `json:"category" binding:"omitempty,oneof=adult child student senior" example:"adult"`
+}
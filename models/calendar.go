@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// CalendarEvent represents a booking rendered as an iCalendar event
+type CalendarEvent struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Start        time.Time
+	End          time.Time
+	Cancelled    bool
+	LastModified time.Time
+}
+
+// CalendarFeed represents the subscription URL of a user's booking calendar
+//
+//	@Description	Calendar subscription feed
+type CalendarFeed struct {
+	URL string `json:"url" example:"http://localhost:4000/api/v1/calendar/1.0.5f2b9c.ics"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// ConcessionItem represents food or a drink a theater sells
+//
+//	@Description	Concession item with its remaining stock
+type ConcessionItem struct {
+	ID          int       `json:"id" example:"1"`
+	TheaterID   int       `json:"theater_id" example:"1"`
+	Name        string    `json:"name" example:"Popcorn Regular"`
+	Description string    `json:"description,omitempty" example:"Salted popcorn, 85 g"`
+	Price       Money     `json:"price"`
+	Stock       int       `json:"stock" example:"200"`
+	IsActive    bool      `json:"is_active" example:"true"`
+	CreatedAt   time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// ConcessionItemRequest represents data needed to create or replace a concession item
+//
+//	@Description	Data required to create or replace a concession item
+type ConcessionItemRequest struct {
+	Name        string `json:"name" binding:"required,max=100" example:"Popcorn Regular"`
+	Description string `json:"description" example:"Salted popcorn, 85 g"`
+	Price       Money  `json:"price" binding:"required,gt=0"`
+	Stock       int    `json:"stock" binding:"gte=0" example:"200"`
+	IsActive    *bool  `json:"is_active" example:"true"`
+}
+
+// AddConcessionsRequest represents concessions ordered with a booking
+//
+//	@Description	Concession items and quantities to add to a booking
+type AddConcessionsRequest struct {
+	Items []ConcessionOrderRequest `json:"items" binding:"required,min=1,max=20,dive"`
+}
+
+// ConcessionOrderRequest represents one ordered concession item
+//
+//	@Description	Concession item and quantity
+type ConcessionOrderRequest struct {
+	ConcessionID int `json:"concession_id" binding:"required" example:"1"`
+	Quantity     int `json:"quantity" binding:"required,gt=0,lte=20" example:"2"`
+}
+
+// ConcessionOrder holds a concession item ordered with a booking, priced at
+// the time of ordering
+type ConcessionOrder struct {
+	ItemID    int
+	Name      string
+	Quantity  int
+	UnitPrice Money
+}
+
+// AddedConcessions is the result of adding concessions to a booking
+//
+//	@Description	New total of a booking and the code to collect its concessions with
+type AddedConcessions struct {
+	BookingID   int        `json:"booking_id" example:"1"`
+	PickupCode  string     `json:"pickup_code" example:"K7Q2MX"`
+	TotalAmount Money      `json:"total_amount"`
+	AmountDue   Money      `json:"amount_due"`
+	LineItems   []LineItem `json:"line_items"`
+}
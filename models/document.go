@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// Line item kinds
+const (
+	LineItemTicket      = "ticket"
+	LineItemSurcharge   = "surcharge"
+	LineItemPricingRule = "pricing_rule"
+	LineItemFee         = "fee"
+	LineItemTax         = "tax"
+	LineItemDiscount    = "discount"
+	LineItemConcession  = "concession"
+)
+
+// LineItem represents one priced line of a booking
+//
+//	@Description	Priced line of a booking
+type LineItem struct {
+	Kind        string `json:"kind" example:"ticket"`
+	Description string `json:"description" example:"Adult ticket"`
+	Quantity    int    `json:"quantity" example:"1"`
+	UnitPrice   Money  `json:"unit_price"`
+	Amount      Money  `json:"amount"`
+}
+
+// BookingDocument holds everything printed on a ticket or receipt
+type BookingDocument struct {
+	BookingID        int
+	Status           string
+	CustomerName     string
+	CustomerEmail    string
+	MovieTitle       string
+	TheaterName      string
+	TheaterAddress   string
+	HallName         string
+	Timezone         string
+	ShowTime         time.Time
+	EndTime          time.Time
+	Seats            []string
+	LineItems        []LineItem
+	Total            Money
+	PickupCode       string
+	PaymentReference string
+	PaidAt           *time.Time
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+)
+
+// Gift card transaction kinds
+const (
+	GiftCardIssue  = "issue"
+	GiftCardRedeem = "redeem"
+	GiftCardRefund = "refund"
+)
+
+// GiftCard represents a prepaid card redeemable against bookings
+//
+//	@Description	Gift card with its current balance
+type GiftCard struct {
+	ID             int                   `json:"id" example:"1"`
+	Code           string                `json:"code" example:"GC7K-Q2MX-9TRD-4HWP"`
+	InitialBalance Money                 `json:"initial_balance"`
+	Balance        Money                 `json:"balance"`
+	RecipientEmail string                `json:"recipient_email,omitempty" example:"friend@example.com"`
+	ExpiresOn      string                `json:"expires_on,omitempty" example:"2026-12-31"`
+	IsActive       bool                  `json:"is_active" example:"true"`
+	IssuedBy       int                   `json:"issued_by" example:"1"`
+	Transactions   []GiftCardTransaction `json:"transactions,omitempty"`
+	CreatedAt      time.Time             `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt      time.Time             `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// GiftCardTransaction represents one debit or credit of a gift card
+//
+//	@Description	Gift card ledger entry. Debits are negative.
+type GiftCardTransaction struct {
+	ID           int       `json:"id" example:"1"`
+	BookingID    *int      `json:"booking_id,omitempty" example:"1"`
+	Kind         string    `json:"kind" example:"redeem"`
+	Amount       Money     `json:"amount"`
+	BalanceAfter Money     `json:"balance_after"`
+	Note         string    `json:"note,omitempty" example:"Booking #1"`
+	CreatedAt    time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// IssueGiftCardRequest represents data needed to issue a gift card
+//
+//	@Description	Data required to issue a gift card
+type IssueGiftCardRequest struct {
+	Amount         Money  `json:"amount" binding:"gt=0"`
+	RecipientEmail string `json:"recipient_email" binding:"omitempty,email" example:"friend@example.com"`
+	ExpiresOn      string `json:"expires_on" binding:"omitempty,datetime=2006-01-02" example:"2026-12-31"`
+}
+
+// GiftCardCodeRequest represents a gift card looked up by its code
+//
+//	@Description	Gift card code
+type GiftCardCodeRequest struct {
+	Code string `json:"code" binding:"required,max=20" example:"GC7K-Q2MX-9TRD-4HWP"`
+}
+
+// GiftCardBalance represents what a customer sees of a gift card
+//
+//	@Description	Gift card balance
+type GiftCardBalance struct {
+	Code      string `json:"code" example:"GC7K-Q2MX-9TRD-4HWP"`
+	Balance   Money  `json:"balance"`
+	ExpiresOn string `json:"expires_on,omitempty" example:"2026-12-31"`
+	IsActive  bool   `json:"is_active" example:"true"`
+}
+
+// ApplyGiftCardRequest represents a gift card used to pay part of a booking
+//
+//	@Description	Gift card to apply and, optionally, how much of it to use
+type ApplyGiftCardRequest struct {
+	Code   string `json:"code" binding:"required,max=20" example:"GC7K-Q2MX-9TRD-4HWP"`
+	Amount *Money `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// AppliedGiftCard is the result of paying part of a booking with a gift card
+//
+//	@Description	Amount taken from a gift card and what is left to pay
+type AppliedGiftCard struct {
+	BookingID      int    `json:"booking_id" example:"1"`
+	Code           string `json:"code" example:"GC7K-Q2MX-9TRD-4HWP"`
+	Amount         Money  `json:"amount"`
+	CardBalance    Money  `json:"card_balance"`
+	GiftCardAmount Money  `json:"gift_card_amount"`
+	AmountDue      Money  `json:"amount_due"`
+	Status         string `json:"status" example:"pending"`
+}
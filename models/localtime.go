@@ -0,0 +1,92 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// localTimeLayouts are the accepted forms without a UTC offset
+var localTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// LocalTime is a date and time sent by a client. It may carry a UTC offset,
+// "2025-12-25T18:00:00+08:00", or leave it out, "2025-12-25T18:00:00", to
+// mean that wall clock time wherever it is used, e.g. in the theater's zone.
+type LocalTime struct {
+	clock     time.Time
+	hasOffset bool
+}
+
+// NewLocalTime returns t as a LocalTime that keeps its offset
+func NewLocalTime(t time.Time) LocalTime {
+	return LocalTime{clock: t, hasOffset: true}
+}
+
+// ParseLocalTime reads an RFC 3339 time, or one of localTimeLayouts without
+// an offset
+func ParseLocalTime(s string) (LocalTime, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return LocalTime{clock: t, hasOffset: true}, nil
+	}
+	for _, layout := range localTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return LocalTime{clock: t}, nil
+		}
+	}
+	return LocalTime{}, fmt.Errorf("invalid time %q, expected e.g. 2025-12-25T18:00:00 or 2025-12-25T18:00:00+07:00", s)
+}
+
+// In returns the instant t names in loc. Times with an offset are already
+// an instant and are only converted to loc.
+func (t LocalTime) In(loc *time.Location) time.Time {
+	if t.hasOffset {
+		return t.clock.In(loc)
+	}
+	c := t.clock
+	return time.Date(c.Year(), c.Month(), c.Day(), c.Hour(), c.Minute(), c.Second(), c.Nanosecond(), loc)
+}
+
+// IsZero reports whether t was left out
+func (t LocalTime) IsZero() bool {
+	return t.clock.IsZero()
+}
+
+// MarshalJSON writes t back in the form it was given
+func (t LocalTime) MarshalJSON() ([]byte, error) {
+	if t.hasOffset {
+		return json.Marshal(t.clock.Format(time.RFC3339))
+	}
+	return json.Marshal(t.clock.Format(localTimeLayouts[0]))
+}
+
+// UnmarshalJSON reads a string accepted by ParseLocalTime
+func (t *LocalTime) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("time must be a string: %w", err)
+	}
+	parsed, err := ParseLocalTime(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Validation tags such as required on LocalTime fields look at the clock
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+			return field.Interface().(LocalTime).clock
+		}, LocalTime{})
+	}
+}
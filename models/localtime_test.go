@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalTime_WithoutOffsetUsesLocation(t *testing.T) {
+	makassar, _ := time.LoadLocation("Asia/Makassar")
+
+	var lt LocalTime
+	assert.NoError(t, json.Unmarshal([]byte(`"2025-12-25T18:00"`), &lt))
+
+	assert.Equal(t, time.Date(2025, 12, 25, 10, 0, 0, 0, time.UTC), lt.In(makassar).UTC())
+	assert.Equal(t, 18, lt.In(makassar).Hour())
+}
+
+func TestLocalTime_WithOffsetIsAnInstant(t *testing.T) {
+	makassar, _ := time.LoadLocation("Asia/Makassar")
+
+	lt, err := ParseLocalTime("2025-12-25T18:00:00+07:00")
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 12, 25, 11, 0, 0, 0, time.UTC), lt.In(makassar).UTC())
+	assert.Equal(t, 19, lt.In(makassar).Hour())
+}
+
+func TestLocalTime_Invalid(t *testing.T) {
+	var lt LocalTime
+	assert.Error(t, json.Unmarshal([]byte(`"25/12/2025 18:00"`), &lt))
+	assert.Error(t, json.Unmarshal([]byte(`1766660400`), &lt))
+	assert.True(t, lt.IsZero())
+}
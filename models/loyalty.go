@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// Loyalty transaction kinds
+const (
+	LoyaltyEarn     = "earn"
+	LoyaltyRedeem   = "redeem"
+	LoyaltyExpire   = "expire"
+	LoyaltyReversal = "reversal"
+)
+
+// LoyaltyTransaction represents one credit or debit of a customer's points
+//
+//	@Description	Loyalty points ledger entry. Debits are negative.
+type LoyaltyTransaction struct {
+	ID        int        `json:"id" example:"1"`
+	BookingID *int       `json:"booking_id,omitempty" example:"1"`
+	Kind      string     `json:"kind" example:"earn"`
+	Points    int        `json:"points" example:"8"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2026-12-25T17:00:00Z"`
+	Note      string     `json:"note,omitempty" example:"Booking #1"`
+	CreatedAt time.Time  `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// LoyaltyAccount represents the loyalty points of a customer
+//
+//	@Description	Loyalty points balance and history
+type LoyaltyAccount struct {
+	Points       int                  `json:"points" example:"120"`
+	Value        Money                `json:"value"`
+	ExpiringSoon int                  `json:"expiring_soon" example:"20"`
+	History      []LoyaltyTransaction `json:"history"`
+}
+
+// RedeemPointsRequest represents loyalty points spent on a booking
+//
+//	@Description	Number of loyalty points to redeem
+type RedeemPointsRequest struct {
+	Points int `json:"points" binding:"required,gt=0" example:"50"`
+}
+
+// RedeemedPoints is the result of redeeming loyalty points on a booking
+//
+//	@Description	Points taken off a booking and its new total
+type RedeemedPoints struct {
+	BookingID   int        `json:"booking_id" example:"1"`
+	Points      int        `json:"points" example:"50"`
+	Discount    Money      `json:"discount"`
+	Balance     int        `json:"balance" example:"70"`
+	TotalAmount Money      `json:"total_amount"`
+	LineItems   []LineItem `json:"line_items"`
+}
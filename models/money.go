@@ -0,0 +1,310 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// DefaultCurrency is the ISO 4217 code of amounts read from the database
+const DefaultCurrency = "IDR"
+
+// Every currency is kept with two decimals, matching the DECIMAL(10,2) columns
+const minorUnitsPerUnit = 100
+
+// Money is an exact amount in minor units (hundredths) of a currency
+//
+//	@Description	Amount of money. The amount is a decimal string so it never loses precision.
+type Money struct {
+	Amount   int64  `json:"amount" swaggertype:"string" example:"50000.00"`
+	Currency string `json:"currency" example:"IDR"`
+}
+
+// NewMoney returns an amount of minor units in DefaultCurrency
+func NewMoney(minorUnits int64) Money {
+	return Money{Amount: minorUnits, Currency: DefaultCurrency}
+}
+
+// ParseMoney parses a decimal string such as "50000", "-10000.5" or
+// "50000.00" in DefaultCurrency. More than two decimals is an error rather
+// than being rounded away.
+func ParseMoney(s string) (Money, error) {
+	amount, err := parseFixed(s)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	return NewMoney(amount), nil
+}
+
+// Add returns m + o. Amounts in different currencies can't be added; a zero
+// Money without a currency takes the currency of the other operand.
+func (m Money) Add(o Money) Money {
+	return Money{Amount: m.Amount + o.Amount, Currency: m.commonCurrency(o)}
+}
+
+// Sub returns m - o
+func (m Money) Sub(o Money) Money {
+	return Money{Amount: m.Amount - o.Amount, Currency: m.commonCurrency(o)}
+}
+
+// Mul returns m times a quantity
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Percent returns p percent of m, rounded half away from zero to the
+// nearest minor unit
+func (m Money) Percent(p Decimal) Money {
+	return Money{Amount: divRound(m.Amount*int64(p), 100*minorUnitsPerUnit), Currency: m.Currency}
+}
+
+// PercentFloor returns p percent of m rounded down to the nearest minor unit
+func (m Money) PercentFloor(p Decimal) Money {
+	return Money{Amount: divFloor(m.Amount*int64(p), 100*minorUnitsPerUnit), Currency: m.Currency}
+}
+
+// FloorUnits rounds m down to a whole number of currency units
+func (m Money) FloorUnits() Money {
+	return Money{Amount: divFloor(m.Amount, minorUnitsPerUnit) * minorUnitsPerUnit, Currency: m.Currency}
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// String formats the amount as a decimal string with two decimals
+func (m Money) String() string {
+	return formatFixed(m.Amount)
+}
+
+// MarshalJSON encodes m as {"amount": "50000.00", "currency": "IDR"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.String(), currency})
+}
+
+// UnmarshalJSON accepts the object form, or a bare decimal string or number
+// in DefaultCurrency. Numbers are parsed from their text, never as floats.
+// Amounts are stored without their currency, so any other currency is an
+// error rather than something to convert or mix up later.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var obj struct {
+		Amount   json.Number `json:"amount"`
+		Currency string      `json:"currency"`
+	}
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+	} else if err := json.Unmarshal(data, &obj.Amount); err != nil {
+		return err
+	}
+
+	parsed, err := ParseMoney(obj.Amount.String())
+	if err != nil {
+		return err
+	}
+	if currency := strings.ToUpper(obj.Currency); currency != "" && currency != DefaultCurrency {
+		return fmt.Errorf("unsupported currency %q, amounts must be in %s", obj.Currency, DefaultCurrency)
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads a DECIMAL column in DefaultCurrency
+func (m *Money) Scan(src interface{}) error {
+	amount, err := scanFixed(src)
+	if err != nil {
+		return err
+	}
+	*m = NewMoney(amount)
+	return nil
+}
+
+// Value writes the amount as a decimal string
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m Money) commonCurrency(o Money) string {
+	switch {
+	case m.Currency == "":
+		return o.Currency
+	case o.Currency == "" || o.Currency == m.Currency:
+		return m.Currency
+	}
+	panic(fmt.Sprintf("money: mixing %s and %s", m.Currency, o.Currency))
+}
+
+// Decimal is an exact number with two decimals, stored in hundredths. It is
+// used for percentages and other non-money quantities.
+//
+//	@Description	Decimal number with two decimals
+type Decimal int64
+
+// NewDecimal returns a whole number as a Decimal
+func NewDecimal(units int64) Decimal {
+	return Decimal(units * minorUnitsPerUnit)
+}
+
+// ParseDecimal parses a decimal string with at most two decimals
+func ParseDecimal(s string) (Decimal, error) {
+	value, err := parseFixed(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid decimal %q: %w", s, err)
+	}
+	return Decimal(value), nil
+}
+
+// String formats d with two decimals
+func (d Decimal) String() string {
+	return formatFixed(int64(d))
+}
+
+// MarshalJSON encodes d as a decimal string
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts a decimal string or number
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	parsed, err := ParseDecimal(number.String())
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan reads a DECIMAL column
+func (d *Decimal) Scan(src interface{}) error {
+	value, err := scanFixed(src)
+	if err != nil {
+		return err
+	}
+	*d = Decimal(value)
+	return nil
+}
+
+// Value writes d as a decimal string
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func parseFixed(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("empty number")
+	}
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("more than two decimals")
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	for _, part := range []string{whole, frac} {
+		if strings.TrimLeft(part, "0123456789") != "" {
+			return 0, fmt.Errorf("not a decimal number")
+		}
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	hundredths, _ := strconv.ParseInt(frac, 10, 64)
+
+	value := units*minorUnitsPerUnit + hundredths
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+func formatFixed(value int64) string {
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/minorUnitsPerUnit, value%minorUnitsPerUnit)
+}
+
+func scanFixed(src interface{}) (int64, error) {
+	switch v := src.(type) {
+	case []byte:
+		return parseFixed(string(v))
+	case string:
+		return parseFixed(v)
+	case int64:
+		return v * minorUnitsPerUnit, nil
+	case float64:
+		return parseFixed(strconv.FormatFloat(v, 'f', 2, 64))
+	case nil:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("cannot scan %T into a decimal", src)
+}
+
+// divFloor divides rounding towards negative infinity
+func divFloor(numerator, denominator int64) int64 {
+	quotient := numerator / denominator
+	if numerator%denominator != 0 && numerator < 0 {
+		quotient--
+	}
+	return quotient
+}
+
+// divRound divides rounding half away from zero
+func divRound(numerator, denominator int64) int64 {
+	quotient, remainder := numerator/denominator, numerator%denominator
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if remainder*2 >= denominator {
+		if numerator < 0 {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+	return quotient
+}
+
+// Validation tags such as required or gt=0 on Money fields compare the
+// amount in minor units
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+			return field.Interface().(Money).Amount
+		}, Money{})
+	}
+}
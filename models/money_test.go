@@ -0,0 +1,111 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]int64{
+		"50000":     5000000,
+		"50000.5":   5000050,
+		"50000.50":  5000050,
+		"0.05":      5,
+		".5":        50,
+		"-10000.00": -1000000,
+		"+12":       1200,
+	}
+	for input, minorUnits := range cases {
+		m, err := ParseMoney(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, NewMoney(minorUnits), m, input)
+	}
+
+	// Sub-cent amounts are rejected instead of being rounded away
+	for _, input := range []string{"", "-", "1.005", "1e3", "12,50", "abc", "1.2.3"} {
+		_, err := ParseMoney(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "50000.00", NewMoney(5000000).String())
+	assert.Equal(t, "0.05", NewMoney(5).String())
+	assert.Equal(t, "-0.50", NewMoney(-50).String())
+}
+
+func TestMoney_Percent(t *testing.T) {
+	// Results round half away from zero to the nearest minor unit
+	assert.Equal(t, NewMoney(5000), NewMoney(10000).Percent(NewDecimal(50)))
+	assert.Equal(t, NewMoney(2), NewMoney(3).Percent(NewDecimal(50)))       // 1.5 rounds up
+	assert.Equal(t, NewMoney(-2), NewMoney(-3).Percent(NewDecimal(50)))     // -1.5 rounds down
+	assert.Equal(t, NewMoney(1), NewMoney(3).Percent(NewDecimal(33)))       // 0.99 rounds up
+	assert.Equal(t, NewMoney(0), NewMoney(1).Percent(NewDecimal(49)))       // 0.49 rounds down
+	assert.Equal(t, NewMoney(1250), NewMoney(10000).Percent(Decimal(1250))) // 12.50%
+}
+
+func TestMoney_PercentFloor(t *testing.T) {
+	// Taxes round down so 11% of 50.000,99 is 5.500,10 and not 5.500,11
+	assert.Equal(t, NewMoney(550010), NewMoney(5000099).PercentFloor(NewDecimal(11)))
+	assert.Equal(t, NewMoney(-2), NewMoney(-3).PercentFloor(NewDecimal(50)))
+	assert.Equal(t, NewMoney(550000), NewMoney(550010).FloorUnits())
+	assert.Equal(t, NewMoney(-100), NewMoney(-1).FloorUnits())
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	assert.Equal(t, NewMoney(150), NewMoney(100).Add(NewMoney(50)))
+	assert.Equal(t, NewMoney(50), NewMoney(100).Sub(NewMoney(50)))
+	assert.Equal(t, NewMoney(300), NewMoney(100).Mul(3))
+	assert.Equal(t, NewMoney(-100), NewMoney(100).Neg())
+
+	// A zero value takes the currency of the amount it is added to
+	assert.Equal(t, NewMoney(100), Money{}.Add(NewMoney(100)))
+
+	assert.Panics(t, func() {
+		NewMoney(100).Add(Money{Amount: 100, Currency: "USD"})
+	})
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(5000050))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"50000.50","currency":"IDR"}`, string(data))
+
+	var m Money
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":"12.99","currency":"idr"}`), &m))
+	assert.Equal(t, NewMoney(1299), m)
+
+	// Only DefaultCurrency is accepted, so request values never mix currencies
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":"12.99","currency":"USD"}`), &m))
+
+	assert.NoError(t, json.Unmarshal([]byte(`"50000.00"`), &m))
+	assert.Equal(t, NewMoney(5000000), m)
+
+	// Numbers are read from their text so 0.1 stays exact
+	assert.NoError(t, json.Unmarshal([]byte(`0.1`), &m))
+	assert.Equal(t, NewMoney(10), m)
+
+	assert.Error(t, json.Unmarshal([]byte(`"0.001"`), &m))
+}
+
+func TestMoney_Scan(t *testing.T) {
+	var m Money
+	assert.NoError(t, m.Scan([]byte("75000.00")))
+	assert.Equal(t, NewMoney(7500000), m)
+
+	value, err := m.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "75000.00", value)
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	var d Decimal
+	assert.NoError(t, json.Unmarshal([]byte(`12.5`), &d))
+	assert.Equal(t, Decimal(1250), d)
+
+	data, err := json.Marshal(d)
+	assert.NoError(t, err)
+	assert.Equal(t, `"12.50"`, string(data))
+}
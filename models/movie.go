@@ -0,0 +1,25 @@
+package models
+
+// Movie represents a movie in the catalog
+//
+//	@Description	Movie information
+type Movie struct {
+	ID          int      `json:"id" example:"1"`
+	Title       string   `json:"title" example:"The Batman"`
+	Description string   `json:"description" example:"The Dark Knight investigates corruption in Gotham City"`
+	Duration    int      `json:"duration" example:"176"`
+	Genres      []string `json:"genres" example:"Action,Crime,Drama"`
+	Rating      string   `json:"rating" example:"13+"`
+	Director    string   `json:"director" example:"Matt Reeves"`
+	Cast        []string `json:"cast" example:"Robert Pattinson,Zoë Kravitz"`
+	ReleaseDate string   `json:"release_date,omitempty" example:"2022-03-04"`
+	EndDate     string   `json:"end_date,omitempty" example:"2022-06-04"`
+}
+
+// MovieSearchResult is a movie found by a search with how well it matched
+//
+//	@Description	Movie search hit
+type MovieSearchResult struct {
+	Movie
+	Score float64 `json:"score" example:"0.87"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// Payment webhook event types
+const (
+	PaymentEventSucceeded = "payment.succeeded"
+	PaymentEventFailed    = "payment.failed"
+)
+
+// PaymentWebhookEvent represents an event sent by the payment gateway
+//
+//	@Description	Payment gateway webhook event
+type PaymentWebhookEvent struct {
+	EventID          string    `json:"event_id" binding:"required" example:"evt_123456"`
+	Type             string    `json:"type" binding:"required,oneof=payment.succeeded payment.failed" example:"payment.succeeded"`
+	BookingID        int       `json:"booking_id" binding:"required" example:"1"`
+	PaymentReference string    `json:"payment_reference" example:"PAY-123456"`
+	OccurredAt       time.Time `json:"occurred_at" binding:"required" example:"2025-12-25T17:00:00Z"`
+}
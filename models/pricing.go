@@ -0,0 +1,61 @@
+package models
+
+// Ticket categories
+const (
+	TicketCategoryAdult   = "adult"
+	TicketCategoryChild   = "child"
+	TicketCategoryStudent = "student"
+	TicketCategorySenior  = "senior"
+)
+
+// TicketCategories lists the categories in the order they appear on receipts
+var TicketCategories = []string{
+	TicketCategoryAdult,
+	TicketCategoryChild,
+	TicketCategoryStudent,
+	TicketCategorySenior,
+}
+
+// SeatTypeRegular is the seat type of seats without a surcharge
+const SeatTypeRegular = "regular"
+
+// PriceTier represents the price of a ticket category
+//
+//	@Description	Ticket category price
+type PriceTier struct {
+	Category string `json:"category" binding:"required,oneof=adult child student senior" example:"child"`
+	Price    Money  `json:"price" binding:"required,gt=0"`
+	Price3D  Money  `json:"price_3d" binding:"gte=0"`
+}
+
+// SeatSurcharge represents the extra cost of a seat type
+//
+//	@Description	Seat type surcharge
+type SeatSurcharge struct {
+	SeatType string `json:"seat_type" binding:"required" example:"vip"`
+	Amount   Money  `json:"amount" binding:"gte=0"`
+}
+
+// TheaterCharges represents the tax and fees added to bookings at a theater
+//
+//	@Description	PPN rate and per-ticket convenience fee of a theater
+type TheaterCharges struct {
+	PPNRate        Decimal `json:"ppn_rate" binding:"gte=0,lte=10000" swaggertype:"string" example:"11.00"`
+	ConvenienceFee Money   `json:"convenience_fee" binding:"gte=0"`
+	FeeTaxable     bool    `json:"fee_taxable" example:"true"`
+}
+
+// ScreeningPricing represents the price tiers of a screening
+//
+//	@Description	Price tiers of a screening, overriding the theater defaults
+type ScreeningPricing struct {
+	Tiers []PriceTier `json:"tiers" binding:"unique=Category,dive"`
+}
+
+// TheaterPricing represents the default price tiers and seat surcharges of a theater
+//
+//	@Description	Default price tiers and seat surcharges of a theater
+type TheaterPricing struct {
+	Tiers          []PriceTier     `json:"tiers" binding:"unique=Category,dive"`
+	SeatSurcharges []SeatSurcharge `json:"seat_surcharges" binding:"unique=SeatType,dive"`
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+)
+
+// Pricing rule adjustment types
+const (
+	AdjustmentPercent = "percent"
+	AdjustmentFixed   = "fixed"
+)
+
+// PricingRule represents a price adjustment applied when all of its conditions match
+//
+//	@Description	Dynamic pricing rule. Conditions left empty match every screening.
+type PricingRule struct {
+	ID              int       `json:"id" example:"1"`
+	Name            string    `json:"name" example:"Weekend"`
+	TheaterID       *int      `json:"theater_id" example:"1"`
+	Priority        int       `json:"priority" example:"50"`
+	AdjustmentType  string    `json:"adjustment_type" example:"percent"`
+	AdjustmentValue Decimal   `json:"adjustment_value" swaggertype:"string" example:"10.00"`
+	Exclusive       bool      `json:"exclusive" example:"false"`
+	DaysOfWeek      []int     `json:"days_of_week" example:"0,6"`
+	StartTime       string    `json:"start_time" example:"18:00"`
+	EndTime         string    `json:"end_time" example:"22:00"`
+	HolidaysOnly    bool      `json:"holidays_only" example:"false"`
+	PremiereDays    int       `json:"premiere_days" example:"0"`
+	MinOccupancy    int       `json:"min_occupancy" example:"0"`
+	ValidFrom       string    `json:"valid_from" example:"2025-12-01"`
+	ValidUntil      string    `json:"valid_until" example:"2025-12-31"`
+	IsActive        bool      `json:"is_active" example:"true"`
+	CreatedAt       time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt       time.Time `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// PricingRuleRequest represents data needed to create or replace a pricing rule
+//
+//	@Description	Data required to create or replace a pricing rule
+type PricingRuleRequest struct {
+	Name            string  `json:"name" binding:"required" example:"Weekend"`
+	TheaterID       *int    `json:"theater_id" example:"1"`
+	Priority        int     `json:"priority" example:"50"`
+	AdjustmentType  string  `json:"adjustment_type" binding:"required,oneof=percent fixed" example:"percent"`
+	AdjustmentValue Decimal `json:"adjustment_value" binding:"required" swaggertype:"string" example:"10.00"`
+	Exclusive       bool    `json:"exclusive" example:"false"`
+	DaysOfWeek      []int   `json:"days_of_week" binding:"dive,min=0,max=6" example:"0,6"`
+	StartTime       string  `json:"start_time" binding:"omitempty,datetime=15:04" example:"18:00"`
+	EndTime         string  `json:"end_time" binding:"omitempty,datetime=15:04" example:"22:00"`
+	HolidaysOnly    bool    `json:"holidays_only" example:"false"`
+	PremiereDays    int     `json:"premiere_days" binding:"gte=0" example:"0"`
+	MinOccupancy    int     `json:"min_occupancy" binding:"gte=0,lte=100" example:"0"`
+	ValidFrom       string  `json:"valid_from" binding:"omitempty,datetime=2006-01-02" example:"2025-12-01"`
+	ValidUntil      string  `json:"valid_until" binding:"omitempty,datetime=2006-01-02" example:"2025-12-31"`
+	IsActive        *bool   `json:"is_active" example:"true"`
+}
+
+// PricingContext holds the facts about a screening that pricing rules match on
+type PricingContext struct {
+	TheaterID   int
+	ShowTime    time.Time // in the theater's local time
+	ReleaseDate *time.Time
+	Holiday     string
+	Occupancy   int // percentage of seats already sold
+}
+
+// AppliedRule explains one pricing rule that changed the price
+//
+//	@Description	Pricing rule that matched, with the reason and amount it added
+type AppliedRule struct {
+	RuleID     int    `json:"rule_id" example:"2"`
+	Name       string `json:"name" example:"Weekend"`
+	Reason     string `json:"reason" example:"show is on a Saturday"`
+	Adjustment Money  `json:"adjustment"`
+}
+
+// PriceEvaluation is the result of running the pricing rules over a base price
+//
+//	@Description	Base price, final price and the rules that produced it
+type PriceEvaluation struct {
+	BasePrice  Money         `json:"base_price"`
+	FinalPrice Money         `json:"final_price"`
+	Applied    []AppliedRule `json:"applied_rules"`
+}
+
+// PricePreview is the price of one ticket for a screening under the current rules
+//
+//	@Description	Preview of the dynamic price of one ticket
+type PricePreview struct {
+	ScreeningID int       `json:"screening_id" example:"1"`
+	Category    string    `json:"category" example:"adult"`
+	ShowTime    time.Time `json:"show_time" example:"2025-12-27T19:00:00+07:00"`
+	Holiday     string    `json:"holiday" example:""`
+	Occupancy   int       `json:"occupancy" example:"45"`
+	PriceEvaluation
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+)
+
+// PromoCode represents a discount code customers apply to a pending booking
+//
+//	@Description	Promo code. Empty restrictions and limits match every booking.
+type PromoCode struct {
+	ID             int       `json:"id" example:"1"`
+	Code           string    `json:"code" example:"NONTON20"`
+	Description    string    `json:"description" example:"20% off weekday shows"`
+	DiscountType   string    `json:"discount_type" example:"percent"`
+	DiscountValue  Decimal   `json:"discount_value" swaggertype:"string" example:"20.00"`
+	MaxDiscount    *Money    `json:"max_discount,omitempty"`
+	MinPurchase    Money     `json:"min_purchase"`
+	ValidFrom      string    `json:"valid_from" example:"2025-12-01"`
+	ValidUntil     string    `json:"valid_until" example:"2025-12-31"`
+	MaxRedemptions int       `json:"max_redemptions" example:"500"`
+	MaxPerUser     int       `json:"max_per_user" example:"1"`
+	MovieIDs       []int     `json:"movie_ids" example:"1,2"`
+	TheaterIDs     []int     `json:"theater_ids" example:"1"`
+	Redemptions    int       `json:"redemptions" example:"42"`
+	IsActive       bool      `json:"is_active" example:"true"`
+	CreatedAt      time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt      time.Time `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// PromoCodeRequest represents data needed to create or replace a promo code
+//
+//	@Description	Data required to create or replace a promo code
+type PromoCodeRequest struct {
+	Code           string  `json:"code" binding:"required,max=50,alphanum" example:"NONTON20"`
+	Description    string  `json:"description" binding:"max=200" example:"20% off weekday shows"`
+	DiscountType   string  `json:"discount_type" binding:"required,oneof=percent fixed" example:"percent"`
+	DiscountValue  Decimal `json:"discount_value" binding:"required,gt=0" swaggertype:"string" example:"20.00"`
+	MaxDiscount    *Money  `json:"max_discount" binding:"omitempty,gt=0"`
+	MinPurchase    Money   `json:"min_purchase" binding:"gte=0"`
+	ValidFrom      string  `json:"valid_from" binding:"omitempty,datetime=2006-01-02" example:"2025-12-01"`
+	ValidUntil     string  `json:"valid_until" binding:"omitempty,datetime=2006-01-02" example:"2025-12-31"`
+	MaxRedemptions int     `json:"max_redemptions" binding:"gte=0" example:"500"`
+	MaxPerUser     int     `json:"max_per_user" binding:"gte=0" example:"1"`
+	MovieIDs       []int   `json:"movie_ids" binding:"dive,gt=0" example:"1,2"`
+	TheaterIDs     []int   `json:"theater_ids" binding:"dive,gt=0" example:"1"`
+	IsActive       *bool   `json:"is_active" example:"true"`
+}
+
+// ApplyPromoRequest represents a promo code applied to a booking
+//
+//	@Description	Promo code to apply
+type ApplyPromoRequest struct {
+	Code string `json:"code" binding:"required,max=50" example:"NONTON20"`
+}
+
+// PromoContext holds the facts about a booking that promo codes are checked against
+type PromoContext struct {
+	MovieID   int
+	TheaterID int
+	Date      string // today in the theater's local time, as YYYY-MM-DD
+	Subtotal  Money  // tickets, surcharges and pricing rules before fees and tax
+}
+
+// AppliedPromo is the result of applying a promo code to a booking
+//
+//	@Description	Discount taken off a booking and its new total
+type AppliedPromo struct {
+	BookingID   int        `json:"booking_id" example:"1"`
+	Code        string     `json:"code" example:"NONTON20"`
+	Discount    Money      `json:"discount"`
+	TotalAmount Money      `json:"total_amount"`
+	LineItems   []LineItem `json:"line_items"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// Refund statuses
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusCompleted = "completed"
+	RefundStatusFailed    = "failed"
+)
+
+// Refund represents a refund issued for a booking
+//
+//	@Description	Refund information
+type Refund struct {
+	ID               int                   `json:"id" example:"1"`
+	BookingID        int                   `json:"booking_id" example:"1"`
+	Amount           Money                 `json:"amount"`
+	GiftCardAmount   Money                 `json:"gift_card_amount"`
+	Percentage       int                   `json:"percentage" example:"75"`
+	Reason           string                `json:"reason,omitempty" example:"Cannot attend"`
+	Status           string                `json:"status" example:"completed"`
+	GatewayReference string                `json:"gateway_reference,omitempty" example:"RF-123456"`
+	History          []RefundStatusHistory `json:"history"`
+	CreatedAt        time.Time             `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt        time.Time             `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// RefundStatusHistory represents a status change of a refund
+//
+//	@Description	Refund status change
+type RefundStatusHistory struct {
+	Status    string    `json:"status" example:"completed"`
+	Note      string    `json:"note,omitempty" example:"Refunded by payment gateway"`
+	CreatedAt time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// RefundRequest represents data needed to refund a booking
+//
+//	@Description	Data required to refund a booking
+type RefundRequest struct {
+	Reason string `json:"reason" example:"Cannot attend"`
+}
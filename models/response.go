@@ -6,10 +6,20 @@ import "time"
 //
 //	@Description	Standard API response structure
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success    bool        `json:"success"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Pagination tells clients how to fetch the page after the one in Data
+//
+//	@Description	Pagination of a list response
+type Pagination struct {
+	Limit      int    `json:"limit" example:"20"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJzIjoic2hvd190aW1lIn0"`
+	HasMore    bool   `json:"has_more" example:"true"`
 }
 
 // LoginResponse represents login response data
@@ -42,6 +52,13 @@ func SuccessResponse(message string, data interface{}) Response {
 	}
 }
 
+// PaginatedResponse is a SuccessResponse carrying one page of a list
+func PaginatedResponse(message string, data interface{}, pagination Pagination) Response {
+	response := SuccessResponse(message, data)
+	response.Pagination = &pagination
+	return response
+}
+
 func ErrorResponse(message string, err error) Response {
 	errorMsg := ""
 	if err != nil {
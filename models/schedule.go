@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+)
+
+// ScreeningSchedule represents screenings repeated on some weekdays over a
+// date range. Show times are local to the theater.
+//
+//	@Description	Recurring screening schedule
+type ScreeningSchedule struct {
+	ID         int       `json:"id" example:"1"`
+	MovieID    int       `json:"movie_id" example:"1"`
+	TheaterID  int       `json:"theater_id" example:"1"`
+	HallID     int       `json:"hall_id" example:"1"`
+	DaysOfWeek []int     `json:"days_of_week" example:"1,2,3,4,5"`
+	ShowTimes  []string  `json:"show_times" example:"13:00,19:30"`
+	StartDate  string    `json:"start_date" example:"2025-12-01"`
+	EndDate    string    `json:"end_date" example:"2025-12-31"`
+	Price      Money     `json:"price"`
+	Price3D    Money     `json:"price_3d"`
+	Is3D       bool      `json:"is_3d" example:"false"`
+	IsActive   bool      `json:"is_active" example:"true"`
+	CreatedAt  time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt  time.Time `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+
+	Occurrences []ScheduleOccurrence `json:"occurrences,omitempty"`
+}
+
+// ScreeningScheduleRequest represents data needed to create or replace a
+// screening schedule. Days of week run from 0 (Sunday) to 6 (Saturday).
+//
+//	@Description	Data required to create or replace a screening schedule
+type ScreeningScheduleRequest struct {
+	MovieID    int      `json:"movie_id" binding:"required" example:"1"`
+	TheaterID  int      `json:"theater_id" binding:"required" example:"1"`
+	HallID     int      `json:"hall_id" binding:"required" example:"1"`
+	DaysOfWeek []int    `json:"days_of_week" binding:"required,min=1,max=7,unique,dive,min=0,max=6" example:"1,2,3,4,5"`
+	ShowTimes  []string `json:"show_times" binding:"required,min=1,max=12,unique,dive,datetime=15:04" example:"13:00,19:30"`
+	StartDate  string   `json:"start_date" binding:"required,datetime=2006-01-02" example:"2025-12-01"`
+	EndDate    string   `json:"end_date" binding:"required,datetime=2006-01-02" example:"2025-12-31"`
+	Price      Money    `json:"price" binding:"required,gt=0"`
+	Price3D    Money    `json:"price_3d" binding:"gte=0"`
+	Is3D       bool     `json:"is_3d" example:"false"`
+}
+
+// ScheduleOccurrence is a screening created by a schedule
+//
+//	@Description	Screening of a schedule
+type ScheduleOccurrence struct {
+	ScreeningID    int       `json:"screening_id" example:"12"`
+	ShowTime       time.Time `json:"show_time" example:"2025-12-01T06:00:00Z"`
+	ShowTimeLocal  time.Time `json:"show_time_local" example:"2025-12-01T13:00:00+07:00"`
+	AvailableSeats int       `json:"available_seats" example:"150"`
+	IsAvailable    bool      `json:"is_available" example:"true"`
+	IsException    bool      `json:"is_exception" example:"false"`
+}
+
+// SkippedOccurrence is a show time of a schedule that was not created
+//
+//	@Description	Show time left out of a schedule and why
+type SkippedOccurrence struct {
+	ShowTime      time.Time `json:"show_time" example:"2025-12-02T12:00:00Z"`
+	ShowTimeLocal time.Time `json:"show_time_local" example:"2025-12-02T19:00:00+07:00"`
+	Reason        string    `json:"reason" example:"Hall is taken by screening #7"`
+}
+
+// ScheduleExpansion reports the screenings a schedule created or replaced.
+// Kept lists upcoming screenings of the series that already had bookings or
+// their own prices, so were left as they were.
+//
+//	@Description	Schedule with the screenings it created and the show times it skipped
+type ScheduleExpansion struct {
+	Schedule ScreeningSchedule    `json:"schedule"`
+	Created  []ScheduleOccurrence `json:"created"`
+	Skipped  []SkippedOccurrence  `json:"skipped"`
+	Kept     []ScheduleOccurrence `json:"kept,omitempty"`
+}
@@ -12,40 +12,91 @@ type Screening struct {
 	MovieID        int       `json:"movie_id" example:"1"`
 	TheaterID      int       `json:"theater_id" example:"1"`
 	HallID         int       `json:"hall_id" example:"1"`
-	ShowTime       time.Time `json:"show_time" example:"2025-12-25T18:00:00Z"`
-	EndTime        time.Time `json:"end_time" example:"2025-12-25T21:00:00Z"`
-	Price          float64   `json:"price" example:"50000.00"`
-	Price3D        float64   `json:"price_3d" example:"75000.00"`
+	ShowTime       time.Time `json:"show_time" example:"2025-12-25T11:00:00Z"`
+	EndTime        time.Time `json:"end_time" example:"2025-12-25T14:00:00Z"`
+	Timezone       string    `json:"timezone" example:"Asia/Jakarta"`
+	ShowTimeLocal  time.Time `json:"show_time_local" example:"2025-12-25T18:00:00+07:00"`
+	EndTimeLocal   time.Time `json:"end_time_local" example:"2025-12-25T21:00:00+07:00"`
+	Price          Money     `json:"price"`
+	Price3D        Money     `json:"price_3d"`
 	AvailableSeats int       `json:"available_seats" example:"150"`
 	Is3D           bool      `json:"is_3d" example:"true"`
 	IsAvailable    bool      `json:"is_available" example:"true"`
 	CreatedAt      time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
 	UpdatedAt      time.Time `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+	Movie          *Movie    `json:"movie,omitempty"`
+	Theater        *Theater  `json:"theater,omitempty"`
+	Hall           *Hall     `json:"hall,omitempty"`
 }
 
-// CreateScreeningRequest represents data needed to create a screening
+// ScreeningFilter holds the query parameters of a screening list
+type ScreeningFilter struct {
+	MovieID     int    `form:"movie_id"`
+	TheaterID   int    `form:"theater_id"`
+	HallID      int    `form:"hall_id"`
+	DateFrom    string `form:"date_from" binding:"omitempty,datetime=2006-01-02"`
+	DateTo      string `form:"date_to" binding:"omitempty,datetime=2006-01-02"`
+	Is3D        *bool  `form:"is_3d"`
+	IsAvailable *bool  `form:"is_available"`
+	MinPrice    *Money `form:"min_price" binding:"omitempty,gte=0"`
+	MaxPrice    *Money `form:"max_price" binding:"omitempty,gte=0"`
+	Sort        string `form:"sort"`
+	Order       string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Limit       int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor      string `form:"cursor"`
+	Include     string `form:"include"`
+}
+
+// CreateScreeningRequest represents data needed to create a screening.
+// A show time without a UTC offset is local time at the theater.
 //
 //	@Description	Data required to create a new screening
 type CreateScreeningRequest struct {
 	MovieID   int       `json:"movie_id" binding:"required" example:"1"`
 	TheaterID int       `json:"theater_id" binding:"required" example:"1"`
 	HallID    int       `json:"hall_id" binding:"required" example:"1"`
-	ShowTime  time.Time `json:"show_time" binding:"required" example:"2025-12-25T18:00:00Z"`
-	Price     float64   `json:"price" binding:"required" example:"50000.00"`
-	Price3D   float64   `json:"price_3d" example:"75000.00"`
+	ShowTime  LocalTime `json:"show_time" binding:"required" swaggertype:"string" example:"2025-12-25T18:00:00"`
+	Price     Money     `json:"price" binding:"required,gt=0"`
+	Price3D   Money     `json:"price_3d" binding:"gte=0"`
 	Is3D      bool      `json:"is_3d" example:"true"`
+	// Override schedules the screening even if it breaks the movie's run or
+	// the hall's 3D capability. The reason is kept in the audit log.
+	Override       bool   `json:"override" example:"false"`
+	OverrideReason string `json:"override_reason" binding:"required_if=Override true" example:"Press screening before release"`
 }
 
-// UpdateScreeningRequest represents data needed to update a screening
+// UpdateScreeningRequest represents data needed to update a screening.
+// A show time without a UTC offset is local time at the theater.
 //
 //	@Description	Data required to update an existing screening
 type UpdateScreeningRequest struct {
 	MovieID     int       `json:"movie_id" example:"1"`
 	TheaterID   int       `json:"theater_id" example:"1"`
 	HallID      int       `json:"hall_id" example:"1"`
-	ShowTime    time.Time `json:"show_time" example:"2025-12-25T18:00:00Z"`
-	Price       float64   `json:"price" example:"50000.00"`
-	Price3D     float64   `json:"price_3d" example:"75000.00"`
+	ShowTime    LocalTime `json:"show_time" swaggertype:"string" example:"2025-12-25T18:00:00"`
+	Price       Money     `json:"price" binding:"gte=0"`
+	Price3D     Money     `json:"price_3d" binding:"gte=0"`
 	Is3D        bool      `json:"is_3d" example:"true"`
 	IsAvailable bool      `json:"is_available" example:"true"`
+	// Override works as in CreateScreeningRequest
+	Override       bool   `json:"override" example:"false"`
+	OverrideReason string `json:"override_reason" binding:"required_if=Override true" example:"Press screening before release"`
+}
+
+// CancelScreeningRequest represents data sent when cancelling a screening
+//
+//	@Description	Reason for cancelling a screening
+type CancelScreeningRequest struct {
+	Reason string `json:"reason" example:"Projector maintenance"`
+}
+
+// CancelScreeningResult summarizes the bookings affected by a cancellation
+//
+//	@Description	Bookings affected by a screening cancellation
+type CancelScreeningResult struct {
+	CancelledScreenings int `json:"cancelled_screenings,omitempty" example:"1"`
+	AffectedBookings    int `json:"affected_bookings" example:"3"`
+	RefundedBookings    int `json:"refunded_bookings" example:"2"`
+	CancelledBookings   int `json:"cancelled_bookings" example:"1"`
+	FailedRefunds       int `json:"failed_refunds" example:"0"`
 }
@@ -0,0 +1,33 @@
+package models
+
+// ScreeningExportQuery holds the query parameters of a schedule export.
+// Dates are local to each theater.
+type ScreeningExportQuery struct {
+	TheaterID int    `form:"theater_id"`
+	DateFrom  string `form:"date_from" binding:"required,datetime=2006-01-02"`
+	DateTo    string `form:"date_to" binding:"required,datetime=2006-01-02"`
+	Format    string `form:"format" binding:"omitempty,oneof=csv json xlsx"`
+}
+
+// ScreeningExportRow is a screening as exported for theater managers. Date
+// and times are local to the theater; occupancy is the percentage of seats
+// sold.
+//
+//	@Description	Screening in a schedule export
+type ScreeningExportRow struct {
+	ScreeningID    int     `json:"screening_id" example:"1"`
+	Theater        string  `json:"theater" example:"Cinema XXI Grand Indonesia"`
+	Hall           string  `json:"hall" example:"Hall 1"`
+	Movie          string  `json:"movie" example:"Avatar: The Way of Water"`
+	Date           string  `json:"date" example:"2025-12-25"`
+	StartTime      string  `json:"start_time" example:"18:00"`
+	EndTime        string  `json:"end_time" example:"21:12"`
+	Timezone       string  `json:"timezone" example:"Asia/Jakarta"`
+	Is3D           bool    `json:"is_3d" example:"false"`
+	Price          Money   `json:"price"`
+	Price3D        Money   `json:"price_3d"`
+	Capacity       int     `json:"capacity" example:"150"`
+	SeatsSold      int     `json:"seats_sold" example:"96"`
+	AvailableSeats int     `json:"available_seats" example:"54"`
+	Occupancy      float64 `json:"occupancy" example:"64"`
+}
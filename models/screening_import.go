@@ -0,0 +1,36 @@
+package models
+
+// ScreeningImportRow is one screening of a bulk import. CSV files name their
+// columns after the JSON fields; price_3d and is_3d may be left out.
+//
+//	@Description	Screening to import
+type ScreeningImportRow struct {
+	MovieID   int       `json:"movie_id" example:"1"`
+	TheaterID int       `json:"theater_id" example:"1"`
+	HallID    int       `json:"hall_id" example:"1"`
+	ShowTime  LocalTime `json:"show_time" swaggertype:"string" example:"2025-12-25T18:00:00"`
+	Price     Money     `json:"price"`
+	Price3D   Money     `json:"price_3d"`
+	Is3D      bool      `json:"is_3d" example:"false"`
+}
+
+// ImportRowError lists what is wrong with a row of an import. Rows count
+// from 1 and leave out the CSV header.
+//
+//	@Description	Problems found in one imported row
+type ImportRowError struct {
+	Row    int      `json:"row" example:"3"`
+	Errors []string `json:"errors" example:"Hall 2 is not in theater 1"`
+}
+
+// ScreeningImportResult reports a bulk import. Nothing is imported unless
+// every row is valid.
+//
+//	@Description	Outcome of a screening import
+type ScreeningImportResult struct {
+	DryRun       bool             `json:"dry_run" example:"false"`
+	Rows         int              `json:"rows" example:"42"`
+	Imported     int              `json:"imported" example:"42"`
+	ScreeningIDs []int            `json:"screening_ids,omitempty"`
+	Errors       []ImportRowError `json:"errors"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// ShowtimeListing is what is playing on one day, grouped by movie and then
+// by theater
+//
+//	@Description	Showtimes of a day grouped by movie and theater
+type ShowtimeListing struct {
+	Date   string           `json:"date" example:"2025-12-25"`
+	City   string           `json:"city,omitempty" example:"Jakarta"`
+	Movies []MovieShowtimes `json:"movies"`
+}
+
+// MovieShowtimes lists the theaters playing a movie
+//
+//	@Description	A movie and the theaters playing it
+type MovieShowtimes struct {
+	MovieID  int                `json:"movie_id" example:"1"`
+	Title    string             `json:"title" example:"The Batman"`
+	Rating   string             `json:"rating" example:"13+"`
+	Duration int                `json:"duration" example:"176"`
+	Genres   []string           `json:"genres" example:"Action,Crime"`
+	Theaters []TheaterShowtimes `json:"theaters"`
+}
+
+// TheaterShowtimes lists the times a theater plays a movie
+//
+//	@Description	A theater and its show times for a movie
+type TheaterShowtimes struct {
+	TheaterID int        `json:"theater_id" example:"1"`
+	Name      string     `json:"name" example:"Cinema XXI Grand Indonesia"`
+	Address   string     `json:"address" example:"Jl. M.H. Thamrin No.1, Jakarta"`
+	City      string     `json:"city" example:"Jakarta"`
+	Timezone  string     `json:"timezone" example:"Asia/Jakarta"`
+	Showtimes []Showtime `json:"showtimes"`
+}
+
+// Showtime is a single screening in a showtime listing
+//
+//	@Description	A bookable screening
+type Showtime struct {
+	ScreeningID    int       `json:"screening_id" example:"1"`
+	ShowTime       time.Time `json:"show_time" example:"2025-12-25T11:00:00Z"`
+	EndTime        time.Time `json:"end_time" example:"2025-12-25T14:00:00Z"`
+	ShowTimeLocal  time.Time `json:"show_time_local" example:"2025-12-25T18:00:00+07:00"`
+	EndTimeLocal   time.Time `json:"end_time_local" example:"2025-12-25T21:00:00+07:00"`
+	HallID         int       `json:"hall_id" example:"1"`
+	HallName       string    `json:"hall_name" example:"Hall 1"`
+	ScreenType     string    `json:"screen_type" example:"IMAX"`
+	Is3D           bool      `json:"is_3d" example:"false"`
+	Price          Money     `json:"price"`
+	Price3D        Money     `json:"price_3d"`
+	AvailableSeats int       `json:"available_seats" example:"150"`
+}
@@ -0,0 +1,53 @@
+package models
+
+// Theater represents a cinema location
+//
+//	@Description	Theater information
+type Theater struct {
+	ID           int      `json:"id" example:"1"`
+	Name         string   `json:"name" example:"Cinema XXI Grand Indonesia"`
+	Address      string   `json:"address" example:"Jl. M.H. Thamrin No.1, Jakarta"`
+	City         string   `json:"city" example:"Jakarta"`
+	Latitude     *float64 `json:"latitude,omitempty" example:"-6.1951"`
+	Longitude    *float64 `json:"longitude,omitempty" example:"106.8213"`
+	Timezone     string   `json:"timezone" example:"Asia/Jakarta"`
+	TotalHalls   int      `json:"total_halls" example:"8"`
+	ContactPhone string   `json:"contact_phone" example:"021-1234567"`
+	ContactEmail string   `json:"contact_email" example:"gi@cinema21.com"`
+}
+
+// NearbyTheater is a theater found by a location search
+//
+//	@Description	Theater with its distance from the searched location
+type NearbyTheater struct {
+	Theater
+	DistanceKm float64 `json:"distance_km" example:"1.84"`
+}
+
+// NearbyTheatersQuery holds the query parameters of a location search.
+// Radius is in kilometres.
+type NearbyTheatersQuery struct {
+	Lat    *float64 `form:"lat" binding:"required,min=-90,max=90"`
+	Lng    *float64 `form:"lng" binding:"required,min=-180,max=180"`
+	Radius float64  `form:"radius" binding:"omitempty,gt=0,max=100"`
+}
+
+// City is a city with theaters, for location pickers
+//
+//	@Description	City with the number of theaters in it
+type City struct {
+	Name     string `json:"name" example:"Jakarta"`
+	Theaters int    `json:"theaters" example:"2"`
+}
+
+// Hall represents a screening room in a theater
+//
+//	@Description	Hall information
+type Hall struct {
+	ID              int    `json:"id" example:"1"`
+	TheaterID       int    `json:"theater_id" example:"1"`
+	Name            string `json:"name" example:"Hall 1"`
+	Capacity        int    `json:"capacity" example:"150"`
+	ScreenType      string `json:"screen_type" example:"IMAX"`
+	Has3DCapability bool   `json:"has_3d_capability" example:"true"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// Ticket represents a signed e-ticket for one booked seat
+//
+//	@Description	E-ticket for one seat
+type Ticket struct {
+	ID          int       `json:"id" example:"1"`
+	BookingID   int       `json:"booking_id" example:"1"`
+	ScreeningID int       `json:"screening_id" example:"1"`
+	Seat        string    `json:"seat" example:"A1"`
+	PickupCode  string    `json:"pickup_code,omitempty" example:"K7Q2MX"`
+	Token       string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	QRCodePNG   []byte    `json:"qr_code_png" swaggertype:"string" format:"base64"`
+	ExpiresAt   time.Time `json:"expires_at" example:"2025-12-25T21:00:00Z"`
+}
+
+// CheckinRequest represents a scanned ticket
+//
+//	@Description	Ticket scanned at the theater gate
+type CheckinRequest struct {
+	Token string `json:"token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// CheckinResult represents the seat information shown to gate staff
+//
+//	@Description	Checked in ticket information
+type CheckinResult struct {
+	TicketID    int       `json:"ticket_id" example:"1"`
+	BookingID   int       `json:"booking_id" example:"1"`
+	ScreeningID int       `json:"screening_id" example:"1"`
+	MovieTitle  string    `json:"movie_title" example:"Avengers: Endgame"`
+	HallName    string    `json:"hall_name" example:"Hall 1"`
+	Seat        string    `json:"seat" example:"A1"`
+	ShowTime    time.Time `json:"show_time" example:"2025-12-25T18:00:00Z"`
+	CheckedInAt time.Time `json:"checked_in_at" example:"2025-12-25T17:45:00Z"`
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+)
+
+// GeneratePickupCode returns a short code such as K7Q2MX that counter staff
+// match with the booking to hand over its concessions
+func GeneratePickupCode() (string, error) {
+	return randomCode(6)
+}
+
+// ConcessionLineItems returns the lines that add orders to a booking whose
+// lines are items: one per ordered item and a PPN correction for the larger
+// taxable amount
+func ConcessionLineItems(items []models.LineItem, orders []models.ConcessionOrder, charges models.TheaterCharges) []models.LineItem {
+	var lines []models.LineItem
+	for _, order := range orders {
+		lines = append(lines, lineItem(models.LineItemConcession, order.Name, order.Quantity, order.UnitPrice))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return withPPNCorrection(items, lines, charges)
+}
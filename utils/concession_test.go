@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePickupCode(t *testing.T) {
+	code, err := GeneratePickupCode()
+
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[2-9A-HJ-NP-Z]{6}$`), code)
+}
+
+func TestConcessionLineItems(t *testing.T) {
+	items := []models.LineItem{
+		{Kind: models.LineItemTicket, Description: "Adult ticket", Quantity: 2, UnitPrice: rupiah(45500), Amount: rupiah(91000)},
+		{Kind: models.LineItemFee, Description: "Convenience fee", Quantity: 2, UnitPrice: rupiah(4000), Amount: rupiah(8000)},
+		{Kind: models.LineItemTax, Description: "PPN 11%", Quantity: 1, UnitPrice: rupiah(10890), Amount: rupiah(10890)},
+	}
+	charges := models.TheaterCharges{PPNRate: models.NewDecimal(11), ConvenienceFee: rupiah(4000), FeeTaxable: true}
+	orders := []models.ConcessionOrder{
+		{ItemID: 1, Name: "Popcorn Regular", Quantity: 2, UnitPrice: rupiah(45000)},
+		{ItemID: 3, Name: "Mineral Water", Quantity: 1, UnitPrice: rupiah(15000)},
+	}
+
+	lines := ConcessionLineItems(items, orders, charges)
+
+	// 11% of 204.000 is 22.440, 11.550 more than before
+	assert.Equal(t, []models.LineItem{
+		{Kind: models.LineItemConcession, Description: "Popcorn Regular", Quantity: 2, UnitPrice: rupiah(45000), Amount: rupiah(90000)},
+		{Kind: models.LineItemConcession, Description: "Mineral Water", Quantity: 1, UnitPrice: rupiah(15000), Amount: rupiah(15000)},
+		{Kind: models.LineItemTax, Description: "PPN 11% adjustment", Quantity: 1, UnitPrice: rupiah(11550), Amount: rupiah(11550)},
+	}, lines)
+	assert.Equal(t, rupiah(91000), PromoSubtotal(append(items, lines...)))
+	assert.Equal(t, rupiah(226440), LineItemsTotal(append(items, lines...)))
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"bytes"
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// PaymentGateway is the subset of the payment gateway API used by the handlers
+type PaymentGateway interface {
+	Refund(paymentReference string, amount models.Money, idempotencyKey string) (string, error)
+}
+
+// Gateway is the payment gateway used by the handlers. Tests replace it with a fake.
+var Gateway PaymentGateway = &HTTPPaymentGateway{
+	Client: &http.Client{Timeout: 10 * time.Second},
+}
+
+// HTTPPaymentGateway talks to the gateway configured by PAYMENT_GATEWAY_URL
+// and PAYMENT_GATEWAY_KEY
+type HTTPPaymentGateway struct {
+	Client *http.Client
+}
+
+// Refund asks the gateway to refund amount of the given payment and returns
+// the gateway's refund reference. The gateway pays out a refund once per
+// idempotency key, so sending the same refund again is safe.
+func (g *HTTPPaymentGateway) Refund(paymentReference string, amount models.Money, idempotencyKey string) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"payment_reference": paymentReference,
+		"amount":            amount.String(),
+		"currency":          amount.Currency,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, os.Getenv("PAYMENT_GATEWAY_URL")+"/refunds", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+os.Getenv("PAYMENT_GATEWAY_KEY"))
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("payment gateway returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		RefundID string `json:"refund_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	return result.RefundID, nil
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPPaymentGateway_Refund(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/refunds", r.URL.Path)
+		assert.Equal(t, "Bearer gateway_key", r.Header.Get("Authorization"))
+		assert.Equal(t, "refund-7", r.Header.Get("Idempotency-Key"))
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "PAY-1", body["payment_reference"])
+		assert.Equal(t, "50000.00", body["amount"])
+		assert.Equal(t, "IDR", body["currency"])
+
+		w.Write([]byte(`{"refund_id":"RF-1"}`))
+	}))
+	defer server.Close()
+
+	os.Setenv("PAYMENT_GATEWAY_URL", server.URL)
+	os.Setenv("PAYMENT_GATEWAY_KEY", "gateway_key")
+
+	gateway := &HTTPPaymentGateway{Client: server.Client()}
+	refundID, err := gateway.Refund("PAY-1", models.NewMoney(5000000), "refund-7")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "RF-1", refundID)
+}
+
+func TestHTTPPaymentGateway_RefundError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	os.Setenv("PAYMENT_GATEWAY_URL", server.URL)
+
+	gateway := &HTTPPaymentGateway{Client: server.Client()}
+	_, err := gateway.Refund("PAY-1", models.NewMoney(5000000), "refund-7")
+
+	assert.Error(t, err)
+}
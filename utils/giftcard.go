@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// codeAlphabet leaves out 0, O, 1 and I so codes can be read aloud and
+// typed from a printed card
+const codeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// GenerateGiftCardCode returns a random code such as GC7K-Q2MX-9TRD-4HWP.
+// Sixteen characters from a 32 letter alphabet give 80 bits, too many to guess.
+func GenerateGiftCardCode() (string, error) {
+	code, err := randomCode(16)
+	if err != nil {
+		return "", err
+	}
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16], nil
+}
+
+func randomCode(length int) (string, error) {
+	var code strings.Builder
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+// NormalizeGiftCardCode upper-cases a code typed by a customer and adds the
+// dashes if they were left out
+func NormalizeGiftCardCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != 16 {
+		return code
+	}
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+}
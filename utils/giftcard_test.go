@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateGiftCardCode(t *testing.T) {
+	format := regexp.MustCompile(`^[2-9A-HJ-NP-Z]{4}(-[2-9A-HJ-NP-Z]{4}){3}$`)
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		code, err := GenerateGiftCardCode()
+		assert.NoError(t, err)
+		assert.Regexp(t, format, code)
+		assert.False(t, seen[code], code)
+		seen[code] = true
+	}
+}
+
+func TestNormalizeGiftCardCode(t *testing.T) {
+	assert.Equal(t, "GC7K-Q2MX-9TRD-4HWP", NormalizeGiftCardCode("gc7kq2mx9trd4hwp"))
+	assert.Equal(t, "GC7K-Q2MX-9TRD-4HWP", NormalizeGiftCardCode(" gc7k-q2mx 9trd-4hwp"))
+	assert.Equal(t, "SHORT", NormalizeGiftCardCode("short"))
+}
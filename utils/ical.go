@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const icalTimeLayout = "20060102T150405Z"
+
+// RenderCalendar renders events as an RFC 5545 calendar
+func RenderCalendar(name string, events []models.CalendarEvent) []byte {
+	var b strings.Builder
+
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//Cinema Ticket API//Bookings//EN")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "METHOD:PUBLISH")
+	writeICalLine(&b, "X-WR-CALNAME:"+escapeICalText(name))
+
+	now := time.Now().UTC().Format(icalTimeLayout)
+	for _, event := range events {
+		status := "CONFIRMED"
+		if event.Cancelled {
+			status = "CANCELLED"
+		}
+
+		writeICalLine(&b, "BEGIN:VEVENT")
+		writeICalLine(&b, "UID:"+event.UID)
+		writeICalLine(&b, "DTSTAMP:"+now)
+		writeICalLine(&b, "DTSTART:"+event.Start.UTC().Format(icalTimeLayout))
+		writeICalLine(&b, "DTEND:"+event.End.UTC().Format(icalTimeLayout))
+		if !event.LastModified.IsZero() {
+			writeICalLine(&b, "LAST-MODIFIED:"+event.LastModified.UTC().Format(icalTimeLayout))
+		}
+		writeICalLine(&b, "SUMMARY:"+escapeICalText(event.Summary))
+		if event.Description != "" {
+			writeICalLine(&b, "DESCRIPTION:"+escapeICalText(event.Description))
+		}
+		if event.Location != "" {
+			writeICalLine(&b, "LOCATION:"+escapeICalText(event.Location))
+		}
+		writeICalLine(&b, "STATUS:"+status)
+		writeICalLine(&b, "END:VEVENT")
+	}
+
+	writeICalLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// escapeICalText escapes a TEXT value as described in RFC 5545 section 3.3.11
+func escapeICalText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// writeICalLine folds content lines longer than 75 octets without splitting
+// UTF-8 characters and terminates them with CRLF
+func writeICalLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isUTF8Start(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isUTF8Start(c byte) bool {
+	return c&0xC0 != 0x80
+}
+
+// GenerateCalendarToken returns the token identifying a user's calendar feed.
+// Calendar apps cannot send an Authorization header, so the feed URL itself
+// carries a signature made with the JWT secret. version is the user's calendar
+// token version; raising it revokes every token issued before.
+func GenerateCalendarToken(userID, version int) string {
+	id := strconv.Itoa(userID) + "." + strconv.Itoa(version)
+	return id + "." + SignPayload([]byte("calendar:"+id), string(jwtSecret()))
+}
+
+// VerifyCalendarToken returns the user and token version a calendar feed token
+// belongs to. The caller still has to check the version is the user's current one.
+func VerifyCalendarToken(token string) (int, int, bool) {
+	parts := strings.SplitN(token, ".", 3)
+	if len(parts) != 3 {
+		return 0, 0, false
+	}
+
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	id := parts[0] + "." + parts[1]
+	if !VerifySignature([]byte("calendar:"+id), parts[2], string(jwtSecret())) {
+		return 0, 0, false
+	}
+
+	return userID, version, true
+}
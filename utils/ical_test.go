@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderCalendar(t *testing.T) {
+	start := time.Date(2025, 12, 25, 18, 0, 0, 0, time.FixedZone("WIB", 7*3600))
+	calendar := string(RenderCalendar("My bookings", []models.CalendarEvent{{
+		UID:       "booking-1@cinema-ticket-api",
+		Summary:   "Avengers: Endgame",
+		Location:  "Cinema XXI Grand Indonesia, Jl. M.H. Thamrin No.1; Jakarta",
+		Start:     start,
+		End:       start.Add(3 * time.Hour),
+		Cancelled: true,
+	}}))
+
+	assert.True(t, strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(calendar, "END:VCALENDAR\r\n"))
+	assert.Contains(t, calendar, "UID:booking-1@cinema-ticket-api\r\n")
+	assert.Contains(t, calendar, "DTSTART:20251225T110000Z\r\n")
+	assert.Contains(t, calendar, "DTEND:20251225T140000Z\r\n")
+	assert.Contains(t, calendar, `LOCATION:Cinema XXI Grand Indonesia\, Jl. M.H. Thamrin No.1\; Jakarta`)
+	assert.Contains(t, calendar, "STATUS:CANCELLED\r\n")
+}
+
+func TestWriteICalLine_Folding(t *testing.T) {
+	var b strings.Builder
+	writeICalLine(&b, "DESCRIPTION:"+strings.Repeat("é", 100))
+
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+
+	unfolded := strings.ReplaceAll(b.String(), "\r\n ", "")
+	assert.Equal(t, "DESCRIPTION:"+strings.Repeat("é", 100)+"\r\n", unfolded)
+}
+
+func TestCalendarToken(t *testing.T) {
+	os.Setenv("JWT_SECRET", "test_secret_key")
+
+	token := GenerateCalendarToken(42, 3)
+
+	userID, version, ok := VerifyCalendarToken(token)
+	assert.True(t, ok)
+	assert.Equal(t, 42, userID)
+	assert.Equal(t, 3, version)
+
+	_, _, ok = VerifyCalendarToken("43" + token[2:])
+	assert.False(t, ok)
+
+	_, _, ok = VerifyCalendarToken("42.4" + token[4:])
+	assert.False(t, ok)
+
+	_, _, ok = VerifyCalendarToken("garbage")
+	assert.False(t, ok)
+}
@@ -8,7 +8,10 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// jwtSecret is read on every use because .env is loaded after package initialization
+func jwtSecret() []byte {
+	return []byte(os.Getenv("JWT_SECRET"))
+}
 
 type Claims struct {
 	UserID int    `json:"user_id"`
@@ -30,12 +33,12 @@ func GenerateToken(userID int, email string) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString(jwtSecret())
 }
 
 func VerifyToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return jwtSecret(), nil
 	})
 	if err != nil {
 		return nil, err
@@ -46,5 +49,12 @@ func VerifyToken(tokenString string) (*Claims, error) {
 		return nil, jwt.ErrSignatureInvalid
 	}
 
+	// Tickets share the signing secret but must not work as login tokens
+	for _, audience := range claims.Audience {
+		if audience == ticketAudience {
+			return nil, jwt.ErrTokenInvalidAudience
+		}
+	}
+
 	return claims, nil
 }
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"log"
+)
+
+// Notifier delivers messages to customers
+type Notifier interface {
+	Notify(userID int, subject, message string) error
+}
+
+// Notifications is the notifier used by the handlers. Tests replace it with a fake.
+var Notifications Notifier = LogNotifier{}
+
+// LogNotifier writes notifications to the server log until an email or SMS
+// provider is configured
+type LogNotifier struct{}
+
+// Notify logs the notification
+func (LogNotifier) Notify(userID int, subject, message string) error {
+	log.Printf("Notification for user %d: %s - %s", userID, subject, message)
+	return nil
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"bytes"
+	"cinema-ticket-api/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const showTimeLayout = "Monday, 02 January 2006 15:04 MST"
+
+// FormatMoney formats an amount the Indonesian way, e.g. "Rp 50.000" or
+// "USD 1.250,50" for other currencies
+func FormatMoney(m models.Money) string {
+	amount := m.Amount
+	negative := amount < 0
+	if negative {
+		amount = -amount
+	}
+
+	whole, cents := amount/100, amount%100
+
+	digits := strconv.FormatInt(whole, 10)
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(d)
+	}
+
+	symbol := "Rp"
+	if m.Currency != "" && m.Currency != "IDR" {
+		symbol = m.Currency
+	}
+
+	result := symbol + " " + grouped.String()
+	if cents > 0 {
+		result += fmt.Sprintf(",%02d", cents)
+	}
+	if negative {
+		result = "-" + result
+	}
+	return result
+}
+
+// RenderTicketPDF renders one page per ticket with its QR code. Show times
+// are printed in loc.
+func RenderTicketPDF(doc models.BookingDocument, tickets []models.Ticket, loc *time.Location) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A5", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(fmt.Sprintf("Tickets for booking #%d", doc.BookingID), true)
+
+	for _, ticket := range tickets {
+		pdf.AddPage()
+
+		pdf.SetFont("Helvetica", "B", 18)
+		pdf.CellFormat(0, 10, tr(doc.MovieTitle), "", 1, "C", false, 0, "")
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(0, 6, tr(doc.TheaterName), "", 1, "C", false, 0, "")
+		pdf.CellFormat(0, 6, tr(doc.TheaterAddress), "", 1, "C", false, 0, "")
+		pdf.Ln(4)
+
+		writeField(pdf, tr, "Show time", doc.ShowTime.In(loc).Format(showTimeLayout))
+		writeField(pdf, tr, "Hall", doc.HallName)
+		writeField(pdf, tr, "Seat", ticket.Seat)
+		writeField(pdf, tr, "Booking", fmt.Sprintf("#%d", doc.BookingID))
+		if doc.PickupCode != "" {
+			writeField(pdf, tr, "Pickup code", doc.PickupCode)
+		}
+
+		name := fmt.Sprintf("qr-%d", ticket.ID)
+		pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(ticket.QRCodePNG))
+		pageWidth, _ := pdf.GetPageSize()
+		pdf.ImageOptions(name, (pageWidth-70)/2, pdf.GetY()+6, 70, 70, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	}
+
+	return outputPDF(pdf)
+}
+
+// RenderReceiptPDF renders the receipt of a booking with its price breakdown.
+// Show times are printed in loc.
+func RenderReceiptPDF(doc models.BookingDocument, loc *time.Location) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(fmt.Sprintf("Receipt for booking #%d", doc.BookingID), true)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, "Receipt", "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	writeField(pdf, tr, "Booking", fmt.Sprintf("#%d", doc.BookingID))
+	writeField(pdf, tr, "Customer", fmt.Sprintf("%s <%s>", doc.CustomerName, doc.CustomerEmail))
+	if doc.PaidAt != nil {
+		writeField(pdf, tr, "Paid at", doc.PaidAt.In(loc).Format(showTimeLayout))
+	}
+	if doc.PaymentReference != "" {
+		writeField(pdf, tr, "Payment", doc.PaymentReference)
+	}
+	writeField(pdf, tr, "Status", doc.Status)
+	pdf.Ln(4)
+
+	writeField(pdf, tr, "Movie", doc.MovieTitle)
+	writeField(pdf, tr, "Theater", doc.TheaterName+", "+doc.HallName)
+	writeField(pdf, tr, "Show time", doc.ShowTime.In(loc).Format(showTimeLayout))
+	writeField(pdf, tr, "Seats", strings.Join(doc.Seats, ", "))
+	if doc.PickupCode != "" {
+		writeField(pdf, tr, "Pickup code", doc.PickupCode)
+	}
+	pdf.Ln(6)
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(90, 8, "Item", "B", 0, "L", false, 0, "")
+	pdf.CellFormat(20, 8, "Qty", "B", 0, "R", false, 0, "")
+	pdf.CellFormat(40, 8, "Unit price", "B", 0, "R", false, 0, "")
+	pdf.CellFormat(40, 8, "Amount", "B", 1, "R", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 11)
+	for _, item := range doc.LineItems {
+		pdf.CellFormat(90, 7, tr(item.Description), "", 0, "L", false, 0, "")
+		pdf.CellFormat(20, 7, strconv.Itoa(item.Quantity), "", 0, "R", false, 0, "")
+		pdf.CellFormat(40, 7, FormatMoney(item.UnitPrice), "", 0, "R", false, 0, "")
+		pdf.CellFormat(40, 7, FormatMoney(item.Amount), "", 1, "R", false, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(150, 8, "Total", "T", 0, "R", false, 0, "")
+	pdf.CellFormat(40, 8, FormatMoney(doc.Total), "T", 1, "R", false, 0, "")
+
+	return outputPDF(pdf)
+}
+
+func writeField(pdf *fpdf.Fpdf, tr func(string) string, label, value string) {
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(30, 7, label, "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 7, tr(value), "", 1, "L", false, 0, "")
+}
+
+func outputPDF(pdf *fpdf.Fpdf) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"bytes"
+	"cinema-ticket-api/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBookingDocument() models.BookingDocument {
+	paidAt := time.Date(2025, 12, 20, 3, 0, 0, 0, time.UTC)
+	return models.BookingDocument{
+		BookingID:      1,
+		Status:         models.BookingStatusPaid,
+		CustomerName:   "Admin User",
+		CustomerEmail:  "admin@cinema.com",
+		MovieTitle:     "Pokémon Detective",
+		TheaterName:    "Cinema XXI Grand Indonesia",
+		TheaterAddress: "Jl. M.H. Thamrin No.1, Jakarta",
+		HallName:       "Hall 1",
+		ShowTime:       time.Date(2025, 12, 25, 11, 0, 0, 0, time.UTC),
+		Seats:          []string{"A1", "A2"},
+		LineItems: []models.LineItem{
+			{Description: "Ticket A1", Quantity: 1, UnitPrice: rupiah(50000), Amount: rupiah(50000)},
+			{Description: "Ticket A2", Quantity: 1, UnitPrice: rupiah(50000), Amount: rupiah(50000)},
+		},
+		Total:            rupiah(100000),
+		PaymentReference: "PAY-1",
+		PaidAt:           &paidAt,
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	assert.Equal(t, "Rp 0", FormatMoney(models.NewMoney(0)))
+	assert.Equal(t, "Rp 500", FormatMoney(models.NewMoney(50000)))
+	assert.Equal(t, "Rp 50.000", FormatMoney(models.NewMoney(5000000)))
+	assert.Equal(t, "Rp 1.250.000,50", FormatMoney(models.NewMoney(125000050)))
+	assert.Equal(t, "-Rp 75.000", FormatMoney(models.NewMoney(-7500000)))
+	assert.Equal(t, "USD 12,99", FormatMoney(models.Money{Amount: 1299, Currency: "USD"}))
+}
+
+func TestRenderTicketPDF(t *testing.T) {
+	png, err := TicketQRCode("signed.ticket.token")
+	assert.NoError(t, err)
+
+	tickets := []models.Ticket{
+		{ID: 10, Seat: "A1", QRCodePNG: png},
+		{ID: 11, Seat: "A2", QRCodePNG: png},
+	}
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	pdf, err := RenderTicketPDF(testBookingDocument(), tickets, loc)
+
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF")))
+}
+
+func TestRenderReceiptPDF(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	pdf, err := RenderReceiptPDF(testBookingDocument(), loc)
+
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF")))
+}
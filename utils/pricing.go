@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PriceList holds everything needed to price the seats of one screening
+type PriceList struct {
+	Price      models.Money
+	Price3D    models.Money
+	Is3D       bool
+	Tiers      map[string]models.PriceTier
+	Surcharges map[string]models.Money
+}
+
+// UnitPrice returns the ticket price of a category. Categories without a
+// tier cost the screening's own price.
+func (p PriceList) UnitPrice(category string) models.Money {
+	price, price3D := p.Price, p.Price3D
+	if tier, ok := p.Tiers[category]; ok {
+		price, price3D = tier.Price, tier.Price3D
+	}
+
+	if p.Is3D && price3D.Amount > 0 {
+		return price3D
+	}
+	return price
+}
+
+// PriceSeats returns the line items and total for the booked seats. Tickets
+// are grouped per category and surcharges per seat type so receipts stay short.
+func PriceSeats(list PriceList, seats []models.BookedSeat) ([]models.LineItem, models.Money, error) {
+	categories := map[string]int{}
+	seatTypes := map[string]int{}
+
+	for _, seat := range seats {
+		categories[seat.Category]++
+
+		if seat.SeatType == "" || seat.SeatType == models.SeatTypeRegular {
+			continue
+		}
+		if _, ok := list.Surcharges[seat.SeatType]; !ok {
+			return nil, models.Money{}, fmt.Errorf("no surcharge configured for seat type %q", seat.SeatType)
+		}
+		seatTypes[seat.SeatType]++
+	}
+
+	var items []models.LineItem
+	for _, category := range models.TicketCategories {
+		quantity := categories[category]
+		if quantity == 0 {
+			continue
+		}
+
+		description := strings.ToUpper(category[:1]) + category[1:] + " ticket"
+		if list.Is3D {
+			description += " (3D)"
+		}
+		items = append(items, lineItem(models.LineItemTicket, description, quantity, list.UnitPrice(category)))
+		delete(categories, category)
+	}
+
+	for category := range categories {
+		return nil, models.Money{}, fmt.Errorf("unknown ticket category %q", category)
+	}
+
+	types := make([]string, 0, len(seatTypes))
+	for seatType := range seatTypes {
+		types = append(types, seatType)
+	}
+	sort.Strings(types)
+
+	for _, seatType := range types {
+		description := strings.ToUpper(seatType) + " seat surcharge"
+		items = append(items, lineItem(models.LineItemSurcharge, description, seatTypes[seatType], list.Surcharges[seatType]))
+	}
+
+	return items, LineItemsTotal(items), nil
+}
+
+// ChargeLineItems returns the convenience fee for the tickets and the PPN
+// over items and, when the theater taxes it, the fee. PPN is rounded down to
+// whole Rupiah like on an e-Faktur.
+func ChargeLineItems(items []models.LineItem, tickets int, charges models.TheaterCharges) []models.LineItem {
+	var charged []models.LineItem
+
+	taxable := LineItemsTotal(items)
+	if charges.ConvenienceFee.Amount > 0 && tickets > 0 {
+		fee := lineItem(models.LineItemFee, "Convenience fee", tickets, charges.ConvenienceFee)
+		charged = append(charged, fee)
+		if charges.FeeTaxable {
+			taxable = taxable.Add(fee.Amount)
+		}
+	}
+
+	if charges.PPNRate > 0 && taxable.Amount > 0 {
+		charged = append(charged, lineItem(models.LineItemTax, ppnLabel(charges.PPNRate), 1, ppn(taxable, charges.PPNRate)))
+	}
+
+	return charged
+}
+
+// LineItemsTotal adds up the amounts of items
+func LineItemsTotal(items []models.LineItem) models.Money {
+	var total models.Money
+	for _, item := range items {
+		total = total.Add(item.Amount)
+	}
+	if total.Currency == "" {
+		total.Currency = models.DefaultCurrency
+	}
+	return total
+}
+
+// ppn returns the tax over taxable, rounded down to whole Rupiah
+func ppn(taxable models.Money, rate models.Decimal) models.Money {
+	if taxable.Amount <= 0 {
+		return models.Money{Currency: taxable.Currency}
+	}
+	return taxable.PercentFloor(rate).FloorUnits()
+}
+
+func ppnLabel(rate models.Decimal) string {
+	return "PPN " + strings.TrimSuffix(strings.TrimRight(rate.String(), "0"), ".") + "%"
+}
+
+func lineItem(kind, description string, quantity int, unitPrice models.Money) models.LineItem {
+	return models.LineItem{
+		Kind:        kind,
+		Description: description,
+		Quantity:    quantity,
+		UnitPrice:   unitPrice,
+		Amount:      unitPrice.Mul(quantity),
+	}
+}
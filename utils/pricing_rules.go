@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EvaluatePricingRules runs the matching rules over base, the price of
+// quantity tickets. Rules run from the highest priority down, ties broken by
+// ID, so the result never depends on the order the rules were loaded in.
+// Percentages apply to the running price and round half away from zero,
+// fixed amounts are per ticket, and an exclusive rule stops the rules below it.
+func EvaluatePricingRules(rules []models.PricingRule, ctx models.PricingContext, base models.Money, quantity int) models.PriceEvaluation {
+	ordered := make([]models.PricingRule, len(rules))
+	copy(ordered, rules)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority > ordered[j].Priority
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	eval := models.PriceEvaluation{BasePrice: base, FinalPrice: base, Applied: []models.AppliedRule{}}
+	for _, rule := range ordered {
+		if !rule.IsActive {
+			continue
+		}
+		reason, ok := matchPricingRule(rule, ctx)
+		if !ok {
+			continue
+		}
+
+		var adjustment models.Money
+		switch rule.AdjustmentType {
+		case models.AdjustmentPercent:
+			adjustment = eval.FinalPrice.Percent(rule.AdjustmentValue)
+		case models.AdjustmentFixed:
+			// A fixed adjustment is an amount with two decimals like the price
+			adjustment = models.Money{Amount: int64(rule.AdjustmentValue), Currency: base.Currency}.Mul(quantity)
+		default:
+			continue
+		}
+
+		// Discounts never take the price below zero
+		if eval.FinalPrice.Add(adjustment).Amount < 0 {
+			adjustment = eval.FinalPrice.Neg()
+		}
+		eval.FinalPrice = eval.FinalPrice.Add(adjustment)
+
+		eval.Applied = append(eval.Applied, models.AppliedRule{
+			RuleID:     rule.ID,
+			Name:       rule.Name,
+			Reason:     reason,
+			Adjustment: adjustment,
+		})
+
+		if rule.Exclusive {
+			break
+		}
+	}
+
+	return eval
+}
+
+// RuleLineItems turns the applied rules into receipt lines
+func RuleLineItems(eval models.PriceEvaluation) []models.LineItem {
+	var items []models.LineItem
+	for _, applied := range eval.Applied {
+		if applied.Adjustment.IsZero() {
+			continue
+		}
+		items = append(items, lineItem(models.LineItemPricingRule, applied.Name, 1, applied.Adjustment))
+	}
+	return items
+}
+
+// matchPricingRule reports whether every condition of rule holds and
+// describes the conditions that did
+func matchPricingRule(rule models.PricingRule, ctx models.PricingContext) (string, bool) {
+	var reasons []string
+
+	if rule.TheaterID != nil && *rule.TheaterID != ctx.TheaterID {
+		return "", false
+	}
+
+	date := ctx.ShowTime.Format("2006-01-02")
+	if rule.ValidFrom != "" && date < rule.ValidFrom {
+		return "", false
+	}
+	if rule.ValidUntil != "" && date > rule.ValidUntil {
+		return "", false
+	}
+
+	if len(rule.DaysOfWeek) > 0 {
+		weekday := ctx.ShowTime.Weekday()
+		found := false
+		for _, day := range rule.DaysOfWeek {
+			if time.Weekday(day) == weekday {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", false
+		}
+		reasons = append(reasons, "show is on a "+weekday.String())
+	}
+
+	if rule.StartTime != "" || rule.EndTime != "" {
+		clock := ctx.ShowTime.Format("15:04")
+		if !inClockRange(clock, rule.StartTime, rule.EndTime) {
+			return "", false
+		}
+		reasons = append(reasons, fmt.Sprintf("show starts at %s, between %s and %s", clock, orDefault(rule.StartTime, "00:00"), orDefault(rule.EndTime, "24:00")))
+	}
+
+	if rule.HolidaysOnly {
+		if ctx.Holiday == "" {
+			return "", false
+		}
+		reasons = append(reasons, "show is on "+ctx.Holiday)
+	}
+
+	if rule.PremiereDays > 0 {
+		if ctx.ReleaseDate == nil {
+			return "", false
+		}
+		days := daysBetween(*ctx.ReleaseDate, ctx.ShowTime)
+		if days < 0 || days >= rule.PremiereDays {
+			return "", false
+		}
+		reasons = append(reasons, fmt.Sprintf("show is %d days after release", days))
+	}
+
+	if rule.MinOccupancy > 0 {
+		if ctx.Occupancy < rule.MinOccupancy {
+			return "", false
+		}
+		reasons = append(reasons, fmt.Sprintf("occupancy %d%% is at least %d%%", ctx.Occupancy, rule.MinOccupancy))
+	}
+
+	if len(reasons) == 0 {
+		return "applies to every screening", true
+	}
+	return strings.Join(reasons, "; "), true
+}
+
+// inClockRange reports whether clock is in [start, end). A range whose end
+// comes before its start wraps past midnight.
+func inClockRange(clock, start, end string) bool {
+	start = orDefault(start, "00:00")
+	end = orDefault(end, "24:00")
+
+	if start <= end {
+		return clock >= start && clock < end
+	}
+	return clock >= start || clock < end
+}
+
+// daysBetween counts calendar days from the date of from to the date of to,
+// each taken in its own location
+func daysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPricingRules() []models.PricingRule {
+	return []models.PricingRule{
+		{ID: 6, Name: "High demand", Priority: 10, AdjustmentType: models.AdjustmentPercent, AdjustmentValue: models.NewDecimal(10), MinOccupancy: 80, IsActive: true},
+		{ID: 2, Name: "Weekend", Priority: 50, AdjustmentType: models.AdjustmentPercent, AdjustmentValue: models.NewDecimal(10), DaysOfWeek: []int{0, 6}, IsActive: true},
+		{ID: 1, Name: "Public holiday", Priority: 100, AdjustmentType: models.AdjustmentPercent, AdjustmentValue: models.NewDecimal(20), HolidaysOnly: true, Exclusive: true, IsActive: true},
+		{ID: 3, Name: "Weekday matinee", Priority: 40, AdjustmentType: models.AdjustmentFixed, AdjustmentValue: models.NewDecimal(-10000), DaysOfWeek: []int{1, 2, 3, 4, 5}, StartTime: "10:00", EndTime: "15:00", IsActive: true},
+		{ID: 4, Name: "Prime time", Priority: 30, AdjustmentType: models.AdjustmentFixed, AdjustmentValue: models.NewDecimal(5000), StartTime: "18:00", EndTime: "22:00", IsActive: true},
+		{ID: 5, Name: "Premiere week", Priority: 20, AdjustmentType: models.AdjustmentPercent, AdjustmentValue: models.NewDecimal(15), PremiereDays: 7, IsActive: true},
+	}
+}
+
+func ruleIDs(eval models.PriceEvaluation) []int {
+	ids := []int{}
+	for _, applied := range eval.Applied {
+		ids = append(ids, applied.RuleID)
+	}
+	return ids
+}
+
+func TestEvaluatePricingRules_WeekendPrimeTime(t *testing.T) {
+	// Saturday evening
+	ctx := models.PricingContext{ShowTime: time.Date(2025, 12, 27, 19, 0, 0, 0, time.UTC), Occupancy: 85}
+
+	eval := EvaluatePricingRules(testPricingRules(), ctx, rupiah(100000), 2)
+
+	assert.Equal(t, []int{2, 4, 6}, ruleIDs(eval))
+	assert.Equal(t, rupiah(10000), eval.Applied[0].Adjustment)
+	assert.Equal(t, rupiah(10000), eval.Applied[1].Adjustment)
+	assert.Equal(t, rupiah(12000), eval.Applied[2].Adjustment)
+	assert.Equal(t, rupiah(132000), eval.FinalPrice)
+	assert.Equal(t, "show is on a Saturday", eval.Applied[0].Reason)
+	assert.Equal(t, "occupancy 85% is at least 80%", eval.Applied[2].Reason)
+}
+
+func TestEvaluatePricingRules_HolidayIsExclusive(t *testing.T) {
+	ctx := models.PricingContext{ShowTime: time.Date(2025, 12, 25, 19, 0, 0, 0, time.UTC), Holiday: "Christmas Day", Occupancy: 90}
+
+	eval := EvaluatePricingRules(testPricingRules(), ctx, rupiah(50000), 1)
+
+	assert.Equal(t, []int{1}, ruleIDs(eval))
+	assert.Equal(t, rupiah(60000), eval.FinalPrice)
+	assert.Equal(t, "show is on Christmas Day", eval.Applied[0].Reason)
+}
+
+func TestEvaluatePricingRules_MatineePremiere(t *testing.T) {
+	release := time.Date(2025, 12, 22, 0, 0, 0, 0, time.UTC)
+	// Wednesday afternoon, two days after release
+	ctx := models.PricingContext{ShowTime: time.Date(2025, 12, 24, 13, 0, 0, 0, time.UTC), ReleaseDate: &release}
+
+	eval := EvaluatePricingRules(testPricingRules(), ctx, rupiah(50000), 1)
+
+	assert.Equal(t, []int{3, 5}, ruleIDs(eval))
+	assert.Equal(t, rupiah(-10000), eval.Applied[0].Adjustment)
+	assert.Equal(t, rupiah(6000), eval.Applied[1].Adjustment)
+	assert.Equal(t, rupiah(46000), eval.FinalPrice)
+}
+
+func TestEvaluatePricingRules_Deterministic(t *testing.T) {
+	ctx := models.PricingContext{ShowTime: time.Date(2025, 12, 27, 19, 0, 0, 0, time.UTC), Occupancy: 85}
+	rules := testPricingRules()
+
+	first := EvaluatePricingRules(rules, ctx, rupiah(100000), 2)
+	for i, j := 0, len(rules)-1; i < j; i, j = i+1, j-1 {
+		rules[i], rules[j] = rules[j], rules[i]
+	}
+	second := EvaluatePricingRules(rules, ctx, rupiah(100000), 2)
+
+	assert.Equal(t, first, second)
+}
+
+func TestEvaluatePricingRules_Conditions(t *testing.T) {
+	theaterID := 2
+	rules := []models.PricingRule{
+		{ID: 1, Name: "Other theater", AdjustmentType: models.AdjustmentFixed, AdjustmentValue: models.NewDecimal(1000), TheaterID: &theaterID, IsActive: true},
+		{ID: 2, Name: "Inactive", AdjustmentType: models.AdjustmentFixed, AdjustmentValue: models.NewDecimal(1000)},
+		{ID: 3, Name: "Expired", AdjustmentType: models.AdjustmentFixed, AdjustmentValue: models.NewDecimal(1000), ValidUntil: "2025-12-01", IsActive: true},
+		{ID: 4, Name: "Late night", AdjustmentType: models.AdjustmentFixed, AdjustmentValue: models.NewDecimal(-60000), StartTime: "23:00", EndTime: "02:00", IsActive: true},
+	}
+	ctx := models.PricingContext{TheaterID: 1, ShowTime: time.Date(2025, 12, 27, 0, 30, 0, 0, time.UTC)}
+
+	eval := EvaluatePricingRules(rules, ctx, rupiah(50000), 1)
+
+	assert.Equal(t, []int{4}, ruleIDs(eval))
+	// Discounts stop at zero
+	assert.Equal(t, rupiah(-50000), eval.Applied[0].Adjustment)
+	assert.Equal(t, rupiah(0), eval.FinalPrice)
+}
+
+func TestRuleLineItems(t *testing.T) {
+	items := RuleLineItems(models.PriceEvaluation{Applied: []models.AppliedRule{
+		{RuleID: 2, Name: "Weekend", Adjustment: rupiah(10000)},
+		{RuleID: 4, Name: "Prime time", Adjustment: rupiah(0)},
+	}})
+
+	assert.Equal(t, []models.LineItem{{Kind: models.LineItemPricingRule, Description: "Weekend", Quantity: 1, UnitPrice: rupiah(10000), Amount: rupiah(10000)}}, items)
+}
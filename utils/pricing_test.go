@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rupiah returns a whole amount of Rupiah
+func rupiah(amount int64) models.Money {
+	return models.NewMoney(amount * 100)
+}
+
+func testPriceList() PriceList {
+	return PriceList{
+		Price:   rupiah(50000),
+		Price3D: rupiah(75000),
+		Tiers: map[string]models.PriceTier{
+			models.TicketCategoryChild:   {Category: models.TicketCategoryChild, Price: rupiah(35000), Price3D: rupiah(55000)},
+			models.TicketCategoryStudent: {Category: models.TicketCategoryStudent, Price: rupiah(40000)},
+		},
+		Surcharges: map[string]models.Money{"vip": rupiah(25000)},
+	}
+}
+
+func TestPriceList_UnitPrice(t *testing.T) {
+	list := testPriceList()
+
+	assert.Equal(t, rupiah(50000), list.UnitPrice(models.TicketCategoryAdult))
+	assert.Equal(t, rupiah(35000), list.UnitPrice(models.TicketCategoryChild))
+
+	list.Is3D = true
+	assert.Equal(t, rupiah(75000), list.UnitPrice(models.TicketCategoryAdult))
+	assert.Equal(t, rupiah(55000), list.UnitPrice(models.TicketCategoryChild))
+	// A tier without a 3D price keeps its 2D price
+	assert.Equal(t, rupiah(40000), list.UnitPrice(models.TicketCategoryStudent))
+}
+
+func TestPriceSeats(t *testing.T) {
+	items, total, err := PriceSeats(testPriceList(), []models.BookedSeat{
+		{Seat: "A1", Category: models.TicketCategoryChild, SeatType: models.SeatTypeRegular},
+		{Seat: "J1", Category: models.TicketCategoryAdult, SeatType: "vip"},
+		{Seat: "J2", Category: models.TicketCategoryAdult, SeatType: "vip"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LineItem{
+		{Kind: models.LineItemTicket, Description: "Adult ticket", Quantity: 2, UnitPrice: rupiah(50000), Amount: rupiah(100000)},
+		{Kind: models.LineItemTicket, Description: "Child ticket", Quantity: 1, UnitPrice: rupiah(35000), Amount: rupiah(35000)},
+		{Kind: models.LineItemSurcharge, Description: "VIP seat surcharge", Quantity: 2, UnitPrice: rupiah(25000), Amount: rupiah(50000)},
+	}, items)
+	assert.Equal(t, rupiah(185000), total)
+}
+
+func TestPriceSeats_Invalid(t *testing.T) {
+	_, _, err := PriceSeats(testPriceList(), []models.BookedSeat{
+		{Seat: "A1", Category: models.TicketCategoryAdult, SeatType: "sweetbox"},
+	})
+	assert.Error(t, err)
+
+	_, _, err = PriceSeats(testPriceList(), []models.BookedSeat{
+		{Seat: "A1", Category: "infant"},
+	})
+	assert.Error(t, err)
+}
+
+func TestChargeLineItems(t *testing.T) {
+	items := []models.LineItem{
+		{Kind: models.LineItemTicket, Description: "Adult ticket", Quantity: 2, UnitPrice: rupiah(45500), Amount: rupiah(91000)},
+	}
+	charges := models.TheaterCharges{PPNRate: models.NewDecimal(11), ConvenienceFee: rupiah(4000), FeeTaxable: true}
+
+	charged := ChargeLineItems(items, 2, charges)
+
+	// 11% of 99.000 is 10.890
+	assert.Equal(t, []models.LineItem{
+		{Kind: models.LineItemFee, Description: "Convenience fee", Quantity: 2, UnitPrice: rupiah(4000), Amount: rupiah(8000)},
+		{Kind: models.LineItemTax, Description: "PPN 11%", Quantity: 1, UnitPrice: rupiah(10890), Amount: rupiah(10890)},
+	}, charged)
+	assert.Equal(t, rupiah(109890), LineItemsTotal(append(items, charged...)))
+}
+
+func TestChargeLineItems_Rounding(t *testing.T) {
+	items := []models.LineItem{
+		{Kind: models.LineItemTicket, Description: "Adult ticket", Quantity: 1, UnitPrice: rupiah(45455), Amount: rupiah(45455)},
+	}
+
+	// 11% of 45.455 is 5.000,05, PPN drops the fraction
+	charged := ChargeLineItems(items, 1, models.TheaterCharges{PPNRate: models.NewDecimal(11)})
+	assert.Equal(t, []models.LineItem{
+		{Kind: models.LineItemTax, Description: "PPN 11%", Quantity: 1, UnitPrice: rupiah(5000), Amount: rupiah(5000)},
+	}, charged)
+
+	// An untaxed fee stays out of the PPN base, and rates keep their decimals
+	charged = ChargeLineItems(items, 1, models.TheaterCharges{PPNRate: models.Decimal(1250), ConvenienceFee: rupiah(5000)})
+	assert.Equal(t, "PPN 12.5%", charged[1].Description)
+	assert.Equal(t, rupiah(5681), charged[1].Amount)
+}
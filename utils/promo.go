@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"fmt"
+)
+
+// CheckPromo returns why promo cannot be used for a booking, or nil when it
+// can. Redemption limits are counted by the caller under a lock.
+func CheckPromo(promo models.PromoCode, ctx models.PromoContext) error {
+	if !promo.IsActive {
+		return fmt.Errorf("promo code %s is not active", promo.Code)
+	}
+	if promo.ValidFrom != "" && ctx.Date < promo.ValidFrom {
+		return fmt.Errorf("promo code %s is valid from %s", promo.Code, promo.ValidFrom)
+	}
+	if promo.ValidUntil != "" && ctx.Date > promo.ValidUntil {
+		return fmt.Errorf("promo code %s expired on %s", promo.Code, promo.ValidUntil)
+	}
+	if len(promo.MovieIDs) > 0 && !containsInt(promo.MovieIDs, ctx.MovieID) {
+		return fmt.Errorf("promo code %s is not valid for this movie", promo.Code)
+	}
+	if len(promo.TheaterIDs) > 0 && !containsInt(promo.TheaterIDs, ctx.TheaterID) {
+		return fmt.Errorf("promo code %s is not valid at this theater", promo.Code)
+	}
+	if ctx.Subtotal.Amount < promo.MinPurchase.Amount {
+		return fmt.Errorf("promo code %s needs a minimum purchase of %s", promo.Code, FormatMoney(promo.MinPurchase))
+	}
+	return nil
+}
+
+// PromoDiscount returns the amount promo takes off subtotal. Percentages
+// round half away from zero and the discount never exceeds the subtotal.
+func PromoDiscount(promo models.PromoCode, subtotal models.Money) models.Money {
+	var discount models.Money
+	switch promo.DiscountType {
+	case models.AdjustmentPercent:
+		discount = subtotal.Percent(promo.DiscountValue)
+	case models.AdjustmentFixed:
+		discount = models.Money{Amount: int64(promo.DiscountValue), Currency: subtotal.Currency}
+	}
+
+	if promo.MaxDiscount != nil && discount.Amount > promo.MaxDiscount.Amount {
+		discount = *promo.MaxDiscount
+	}
+	if discount.Amount > subtotal.Amount {
+		discount = subtotal
+	}
+	return discount
+}
+
+// PromoSubtotal adds up the lines a promo code discounts: tickets, seat
+// surcharges and pricing rules, but not fees or tax
+func PromoSubtotal(items []models.LineItem) models.Money {
+	var subtotal []models.LineItem
+	for _, item := range items {
+		switch item.Kind {
+		case models.LineItemTicket, models.LineItemSurcharge, models.LineItemPricingRule, models.LineItemDiscount:
+			subtotal = append(subtotal, item)
+		}
+	}
+	return LineItemsTotal(subtotal)
+}
+
+// DiscountLineItems returns the lines that take discount off a booking whose
+// lines are items: the discount itself and, since stored lines never change,
+// a PPN correction for the smaller taxable amount
+func DiscountLineItems(items []models.LineItem, description string, discount models.Money, charges models.TheaterCharges) []models.LineItem {
+	lines := []models.LineItem{lineItem(models.LineItemDiscount, description, 1, discount.Neg())}
+	return withPPNCorrection(items, lines, charges)
+}
+
+// withPPNCorrection appends to lines added to a booking whose lines are items
+// the PPN adjustment that brings the booking's tax in line with its new
+// taxable amount. Bookings charged no PPN stay that way.
+func withPPNCorrection(items, lines []models.LineItem, charges models.TheaterCharges) []models.LineItem {
+	taxable := models.Money{Currency: lines[0].Amount.Currency}
+	var charged models.Money
+	all := append(append([]models.LineItem{}, items...), lines...)
+	for _, item := range all {
+		switch item.Kind {
+		case models.LineItemTax:
+			charged = charged.Add(item.Amount)
+		case models.LineItemFee:
+			if charges.FeeTaxable {
+				taxable = taxable.Add(item.Amount)
+			}
+		default:
+			taxable = taxable.Add(item.Amount)
+		}
+	}
+
+	if charged.IsZero() {
+		return lines
+	}
+	if correction := ppn(taxable, charges.PPNRate).Sub(charged); !correction.IsZero() {
+		lines = append(lines, lineItem(models.LineItemTax, ppnLabel(charges.PPNRate)+" adjustment", 1, correction))
+	}
+	return lines
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
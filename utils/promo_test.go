@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPromo(t *testing.T) {
+	promo := models.PromoCode{
+		Code:        "NONTON20",
+		ValidFrom:   "2025-12-01",
+		ValidUntil:  "2025-12-31",
+		MinPurchase: rupiah(50000),
+		MovieIDs:    []int{1, 2},
+		IsActive:    true,
+	}
+	ctx := models.PromoContext{MovieID: 2, TheaterID: 1, Date: "2025-12-24", Subtotal: rupiah(80000)}
+
+	assert.NoError(t, CheckPromo(promo, ctx))
+
+	expired := ctx
+	expired.Date = "2026-01-01"
+	assert.EqualError(t, CheckPromo(promo, expired), "promo code NONTON20 expired on 2025-12-31")
+
+	otherMovie := ctx
+	otherMovie.MovieID = 3
+	assert.EqualError(t, CheckPromo(promo, otherMovie), "promo code NONTON20 is not valid for this movie")
+
+	small := ctx
+	small.Subtotal = rupiah(45000)
+	assert.EqualError(t, CheckPromo(promo, small), "promo code NONTON20 needs a minimum purchase of Rp 50.000")
+
+	promo.TheaterIDs = []int{2}
+	assert.EqualError(t, CheckPromo(promo, ctx), "promo code NONTON20 is not valid at this theater")
+}
+
+func TestPromoDiscount(t *testing.T) {
+	percent := models.PromoCode{DiscountType: models.AdjustmentPercent, DiscountValue: models.NewDecimal(20)}
+	assert.Equal(t, rupiah(16000), PromoDiscount(percent, rupiah(80000)))
+
+	limit := rupiah(10000)
+	percent.MaxDiscount = &limit
+	assert.Equal(t, rupiah(10000), PromoDiscount(percent, rupiah(80000)))
+
+	// A fixed discount never takes the booking below zero
+	fixed := models.PromoCode{DiscountType: models.AdjustmentFixed, DiscountValue: models.NewDecimal(25000)}
+	assert.Equal(t, rupiah(25000), PromoDiscount(fixed, rupiah(80000)))
+	assert.Equal(t, rupiah(20000), PromoDiscount(fixed, rupiah(20000)))
+}
+
+func TestDiscountLineItems(t *testing.T) {
+	items := []models.LineItem{
+		{Kind: models.LineItemTicket, Description: "Adult ticket", Quantity: 2, UnitPrice: rupiah(45500), Amount: rupiah(91000)},
+		{Kind: models.LineItemFee, Description: "Convenience fee", Quantity: 2, UnitPrice: rupiah(4000), Amount: rupiah(8000)},
+		{Kind: models.LineItemTax, Description: "PPN 11%", Quantity: 1, UnitPrice: rupiah(10890), Amount: rupiah(10890)},
+	}
+	charges := models.TheaterCharges{PPNRate: models.NewDecimal(11), ConvenienceFee: rupiah(4000), FeeTaxable: true}
+
+	assert.Equal(t, rupiah(91000), PromoSubtotal(items))
+
+	lines := DiscountLineItems(items, "Promo NONTON20", rupiah(18200), charges)
+
+	// 11% of 80.800 is 8.888, 2.002 less than before
+	assert.Equal(t, []models.LineItem{
+		{Kind: models.LineItemDiscount, Description: "Promo NONTON20", Quantity: 1, UnitPrice: rupiah(-18200), Amount: rupiah(-18200)},
+		{Kind: models.LineItemTax, Description: "PPN 11% adjustment", Quantity: 1, UnitPrice: rupiah(-2002), Amount: rupiah(-2002)},
+	}, lines)
+	assert.Equal(t, rupiah(89688), LineItemsTotal(append(items, lines...)))
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryBuilder collects the conditions of a WHERE clause together with their
+// arguments. Conditions are written with ? placeholders which are numbered
+// $1, $2, ... in the order they are added, so values never end up in the SQL
+// text itself.
+type QueryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// Where adds a condition, replacing each ? in it with the next placeholder
+// bound to the matching arg
+func (b *QueryBuilder) Where(condition string, args ...interface{}) {
+	parts := strings.Split(condition, "?")
+	if len(parts)-1 != len(args) {
+		panic(fmt.Sprintf("query builder: %d placeholders but %d args in %q", len(parts)-1, len(args), condition))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(parts[0])
+	for i, part := range parts[1:] {
+		sb.WriteString(b.Arg(args[i]))
+		sb.WriteString(part)
+	}
+	b.conditions = append(b.conditions, sb.String())
+}
+
+// Arg binds value to the next placeholder and returns it, for use outside the
+// WHERE clause such as in LIMIT
+func (b *QueryBuilder) Arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// WhereClause returns the conditions joined with AND, prefixed with WHERE, or
+// an empty string when there are none
+func (b *QueryBuilder) WhereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// Args returns the values bound so far, in placeholder order
+func (b *QueryBuilder) Args() []interface{} {
+	return b.args
+}
+
+// Cursor marks the last row of a page in a keyset-paginated list: the value
+// of the sort column and the id that breaks ties. Sort and Order are kept so
+// a cursor cannot be replayed against a different ordering.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// EncodeCursor returns cursor as an opaque URL-safe string
+func EncodeCursor(cursor Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a cursor made by EncodeCursor
+func DecodeCursor(s string) (Cursor, error) {
+	var cursor Cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, fmt.Errorf("malformed cursor")
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return cursor, fmt.Errorf("malformed cursor")
+	}
+	return cursor, nil
+}
+
+var numericCursorValue = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// CheckValue reports an error unless the cursor's sort value parses as
+// sqlType, the type it is cast to in the query, so a tampered cursor is
+// rejected before it reaches the database
+func (c Cursor) CheckValue(sqlType string) error {
+	var err error
+	switch sqlType {
+	case "timestamptz", "timestamp":
+		_, err = time.Parse(time.RFC3339Nano, c.Value)
+	case "integer":
+		_, err = strconv.ParseInt(c.Value, 10, 32)
+	case "numeric":
+		if !numericCursorValue.MatchString(c.Value) {
+			err = fmt.Errorf("not a number")
+		}
+	default:
+		err = fmt.Errorf("unknown type %q", sqlType)
+	}
+	if err != nil {
+		return fmt.Errorf("malformed cursor")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	var b QueryBuilder
+	assert.Equal(t, "", b.WhereClause())
+
+	b.Where("show_time > NOW()")
+	b.Where("movie_id = ?", 3)
+	b.Where("price BETWEEN ? AND ?", "40000.00", "60000.00")
+	limit := b.Arg(21)
+
+	assert.Equal(t, "WHERE show_time > NOW() AND movie_id = $1 AND price BETWEEN $2 AND $3", b.WhereClause())
+	assert.Equal(t, "$4", limit)
+	assert.Equal(t, []interface{}{3, "40000.00", "60000.00", 21}, b.Args())
+}
+
+func TestQueryBuilder_PlaceholderMismatch(t *testing.T) {
+	var b QueryBuilder
+	assert.Panics(t, func() { b.Where("movie_id = ?") })
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	cursor := Cursor{Sort: "price", Order: "desc", Value: "50000.00", ID: 12}
+
+	decoded, err := DecodeCursor(EncodeCursor(cursor))
+
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	_, err = DecodeCursor("not a cursor")
+	assert.Error(t, err)
+}
+
+func TestCursor_CheckValue(t *testing.T) {
+	assert.NoError(t, Cursor{Value: "2025-12-25T11:00:00Z"}.CheckValue("timestamptz"))
+	assert.NoError(t, Cursor{Value: "50000.00"}.CheckValue("numeric"))
+	assert.NoError(t, Cursor{Value: "42"}.CheckValue("integer"))
+
+	assert.Error(t, Cursor{Value: "yesterday"}.CheckValue("timestamptz"))
+	assert.Error(t, Cursor{Value: "1e9"}.CheckValue("numeric"))
+	assert.Error(t, Cursor{Value: "NaN"}.CheckValue("numeric"))
+	assert.Error(t, Cursor{Value: "99999999999"}.CheckValue("integer"))
+	assert.Error(t, Cursor{Value: "42"}.CheckValue("text"))
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ScreeningViolations returns the scheduling rules a screening breaks: a show
+// date outside the movie's run, or 3D in a hall that cannot project it.
+// showDate is the YYYY-MM-DD date at the theater; a movie without a release
+// or end date is unbounded on that side.
+func ScreeningViolations(movie models.Movie, hall models.Hall, showDate string, is3D bool) []string {
+	var violations []string
+	if movie.ReleaseDate != "" && showDate < movie.ReleaseDate {
+		violations = append(violations, fmt.Sprintf("%s is not released until %s", movie.Title, movie.ReleaseDate))
+	}
+	if movie.EndDate != "" && showDate > movie.EndDate {
+		violations = append(violations, fmt.Sprintf("%s ended its run on %s", movie.Title, movie.EndDate))
+	}
+	if is3D && !hall.Has3DCapability {
+		violations = append(violations, fmt.Sprintf("%s cannot show 3D screenings", hall.Name))
+	}
+	return violations
+}
+
+// ExpandSchedule returns the show times of a recurring schedule in order:
+// each of times (HH:MM) on each of days (0 = Sunday) from the from date to
+// the to date inclusive (YYYY-MM-DD), read as wall-clock times in loc.
+func ExpandSchedule(days []int, times []string, from, to string, loc *time.Location) ([]time.Time, error) {
+	start, err := time.ParseInLocation("2006-01-02", from, loc)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.ParseInLocation("2006-01-02", to, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	clocks := make([]time.Time, 0, len(times))
+	for _, t := range times {
+		clock, err := time.Parse("15:04", t)
+		if err != nil {
+			return nil, err
+		}
+		clocks = append(clocks, clock)
+	}
+	sort.Slice(clocks, func(i, j int) bool { return clocks[i].Before(clocks[j]) })
+
+	weekdays := make(map[time.Weekday]bool, len(days))
+	for _, d := range days {
+		weekdays[time.Weekday(d)] = true
+	}
+
+	var showTimes []time.Time
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if !weekdays[day.Weekday()] {
+			continue
+		}
+		for _, clock := range clocks {
+			showTimes = append(showTimes, time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, loc))
+		}
+	}
+	return showTimes, nil
+}
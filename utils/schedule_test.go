@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"cinema-ticket-api/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScreeningViolations(t *testing.T) {
+	movie := models.Movie{Title: "The Batman", ReleaseDate: "2022-03-04", EndDate: "2022-06-04"}
+	hall := models.Hall{Name: "Studio 2"}
+
+	assert.Empty(t, ScreeningViolations(movie, hall, "2022-03-04", false))
+	assert.Empty(t, ScreeningViolations(movie, hall, "2022-06-04", false))
+	assert.Empty(t, ScreeningViolations(models.Movie{Title: "Open run"}, hall, "2030-01-01", false))
+
+	assert.Equal(t, []string{"The Batman is not released until 2022-03-04"}, ScreeningViolations(movie, hall, "2022-03-03", false))
+	assert.Equal(t, []string{
+		"The Batman ended its run on 2022-06-04",
+		"Studio 2 cannot show 3D screenings",
+	}, ScreeningViolations(movie, hall, "2022-06-05", true))
+
+	hall.Has3DCapability = true
+	assert.Empty(t, ScreeningViolations(movie, hall, "2022-04-01", true))
+}
+
+func TestExpandSchedule(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+
+	// Monday 1 December 2025 to Sunday 7 December, on weekends only
+	showTimes, err := ExpandSchedule([]int{6, 0}, []string{"19:30", "13:00"}, "2025-12-01", "2025-12-07", loc)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 12, 6, 13, 0, 0, 0, loc),
+		time.Date(2025, 12, 6, 19, 30, 0, 0, loc),
+		time.Date(2025, 12, 7, 13, 0, 0, 0, loc),
+		time.Date(2025, 12, 7, 19, 30, 0, 0, loc),
+	}, showTimes)
+
+	showTimes, err = ExpandSchedule([]int{1}, []string{"10:00"}, "2025-12-02", "2025-12-07", loc)
+	assert.NoError(t, err)
+	assert.Empty(t, showTimes)
+
+	_, err = ExpandSchedule([]int{1}, []string{"25:00"}, "2025-12-01", "2025-12-07", loc)
+	assert.Error(t, err)
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerifySignature(t *testing.T) {
+	payload := []byte(`{"event_id":"evt_1"}`)
+	signature := SignPayload(payload, "webhook_secret")
+
+	assert.True(t, VerifySignature(payload, signature, "webhook_secret"))
+	assert.False(t, VerifySignature(payload, signature, "other_secret"))
+	assert.False(t, VerifySignature([]byte(`{"event_id":"evt_2"}`), signature, "webhook_secret"))
+}
+
+func TestVerifySignature_Invalid(t *testing.T) {
+	payload := []byte(`{"event_id":"evt_1"}`)
+
+	assert.False(t, VerifySignature(payload, "", "webhook_secret"))
+	assert.False(t, VerifySignature(payload, "not-hex", "webhook_secret"))
+	assert.False(t, VerifySignature(payload, SignPayload(payload, ""), ""))
+}
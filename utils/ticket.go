@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/skip2/go-qrcode"
+)
+
+// ticketAudience marks tokens that are tickets so they are never accepted as login tokens
+const ticketAudience = "ticket"
+
+type TicketClaims struct {
+	BookingID   int    `json:"booking_id"`
+	ScreeningID int    `json:"screening_id"`
+	Seat        string `json:"seat"`
+	jwt.RegisteredClaims
+}
+
+// GenerateTicketToken signs a ticket for one seat. ticketID identifies the
+// booked seat and expiresAt is usually the end of the screening.
+func GenerateTicketToken(ticketID, bookingID, screeningID int, seat string, expiresAt time.Time) (string, error) {
+	claims := &TicketClaims{
+		BookingID:   bookingID,
+		ScreeningID: screeningID,
+		Seat:        seat,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        strconv.Itoa(ticketID),
+			Audience:  jwt.ClaimStrings{ticketAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret())
+}
+
+func VerifyTicketToken(tokenString string) (*TicketClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TicketClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret(), nil
+	}, jwt.WithAudience(ticketAudience), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*TicketClaims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	return claims, nil
+}
+
+// TicketQRCode renders a signed ticket token as a QR code PNG
+func TicketQRCode(token string) ([]byte, error) {
+	return qrcode.Encode(token, qrcode.Medium, 256)
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAndVerifyTicketToken(t *testing.T) {
+	os.Setenv("JWT_SECRET", "test_secret_key")
+
+	expiresAt := time.Now().Add(3 * time.Hour)
+	token, err := GenerateTicketToken(10, 1, 2, "A1", expiresAt)
+	assert.NoError(t, err)
+
+	claims, err := VerifyTicketToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", claims.ID)
+	assert.Equal(t, 1, claims.BookingID)
+	assert.Equal(t, 2, claims.ScreeningID)
+	assert.Equal(t, "A1", claims.Seat)
+	assert.WithinDuration(t, expiresAt, claims.ExpiresAt.Time, time.Second)
+
+	// A ticket must never authenticate a user
+	_, err = VerifyToken(token)
+	assert.Error(t, err)
+}
+
+func TestVerifyTicketToken_Invalid(t *testing.T) {
+	expired, err := GenerateTicketToken(10, 1, 2, "A1", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+
+	_, err = VerifyTicketToken(expired)
+	assert.Error(t, err)
+
+	loginToken, err := GenerateToken(1, "test@example.com")
+	assert.NoError(t, err)
+
+	_, err = VerifyTicketToken(loginToken)
+	assert.Error(t, err)
+}
+
+func TestVerifyTicketToken_ReadsSecretOnUse(t *testing.T) {
+	// .env is loaded after package initialization, so the secret must be read
+	// when a ticket is signed or checked rather than once at startup
+	os.Setenv("JWT_SECRET", "test_secret_key")
+	defer os.Setenv("JWT_SECRET", "test_secret_key")
+
+	token, err := GenerateTicketToken(10, 1, 2, "A1", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	os.Setenv("JWT_SECRET", "rotated_secret_key")
+	_, err = VerifyTicketToken(token)
+	assert.Error(t, err)
+}
+
+func TestTicketQRCode(t *testing.T) {
+	png, err := TicketQRCode("signed.ticket.token")
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(png, []byte("\x89PNG")))
+}